@@ -0,0 +1,277 @@
+package gbindata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/compress/gcompress"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Bundle binary layout:
+// "GBDL" | version(1 byte) | len(comp)(1 byte) | comp | body
+// body is compressed with comp unless comp is "none":
+// uvarint(len(index)) | index json | data of all files
+
+type (
+	// BundleOptions decides which files are packed and how they are compressed.
+	// Include and Exclude are path.Match patterns, matched against both slash separated
+	// relative path and base name. Empty Include means all files.
+	BundleOptions struct {
+		Include []string
+		Exclude []string
+		Comp    gcompress.Comp
+	}
+
+	// FileMeta is the metadata stored for every packed file.
+	FileMeta struct {
+		Name    string      `json:"name"` // Slash separated path relative to bundle root.
+		Mode    fs.FileMode `json:"mode"`
+		ModTime int64       `json:"mtime"` // Unix nanoseconds.
+		Size    int64       `json:"size"`
+		SHA256  string      `json:"sha256"`
+		offset  int64
+	}
+)
+
+const (
+	bundleMagic   = "GBDL"
+	bundleVersion = 1
+)
+
+// DefaultBundleOptions packs all files with gzip.
+var DefaultBundleOptions = BundleOptions{Comp: gcompress.CompGzip}
+
+func matchAny(patterns []string, rel string) (bool, error) {
+	for _, p := range patterns {
+		ok, err := path.Match(p, rel)
+		if err != nil {
+			return false, gerrors.Wrap(err, "pattern "+p)
+		}
+		if !ok {
+			if ok, err = path.Match(p, path.Base(rel)); err != nil {
+				return false, gerrors.Wrap(err, "pattern "+p)
+			}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Pack packs directory tree into bundle data which can be loaded by Load.
+func Pack(dir string, opt BundleOptions) ([]byte, error) {
+	if opt.Comp == "" {
+		opt.Comp = DefaultBundleOptions.Comp
+	}
+	if _, err := gcompress.ToComp(string(opt.Comp)); err != nil {
+		return nil, err
+	}
+
+	var metas []*FileMeta
+	data := bytes.Buffer{}
+	err := filepath.WalkDir(dir, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fullPath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		excluded, err := matchAny(opt.Exclude, rel)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if excluded {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if excluded || !d.Type().IsRegular() {
+			return nil
+		}
+		if len(opt.Include) > 0 {
+			included, err := matchAny(opt.Include, rel)
+			if err != nil {
+				return err
+			}
+			if !included {
+				return nil
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(fullPath)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		metas = append(metas, &FileMeta{
+			Name:    rel,
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime().UnixNano(),
+			Size:    int64(len(b)),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+		data.Write(b)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := json.Marshal(metas)
+	if err != nil {
+		return nil, err
+	}
+	out := bytes.Buffer{}
+	out.WriteString(bundleMagic)
+	out.WriteByte(bundleVersion)
+	out.WriteByte(byte(len(opt.Comp)))
+	out.WriteString(string(opt.Comp))
+
+	body := io.Writer(&out)
+	var cw io.WriteCloser
+	if opt.Comp != gcompress.CompNone {
+		if cw, err = gcompress.NewCompWriter(opt.Comp, nil, &out); err != nil {
+			return nil, err
+		}
+		body = cw
+	}
+	lenBuf := make([]byte, binary.MaxVarintLen64)
+	if _, err := body.Write(lenBuf[:binary.PutUvarint(lenBuf, uint64(len(index)))]); err != nil {
+		return nil, err
+	}
+	if _, err := body.Write(index); err != nil {
+		return nil, err
+	}
+	if _, err := body.Write(data.Bytes()); err != nil {
+		return nil, err
+	}
+	if cw != nil {
+		if err := cw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// Load parses bundle data created by Pack, and verifies hashes of all files.
+func Load(bundleData []byte) (*Bundle, error) {
+	if len(bundleData) < len(bundleMagic)+2 || string(bundleData[:len(bundleMagic)]) != bundleMagic {
+		return nil, gerrors.New("invalid bundle data")
+	}
+	p := len(bundleMagic)
+	if bundleData[p] != bundleVersion {
+		return nil, gerrors.New("unsupported bundle version %d", bundleData[p])
+	}
+	compLen := int(bundleData[p+1])
+	p += 2
+	if len(bundleData) < p+compLen {
+		return nil, gerrors.New("invalid bundle data")
+	}
+	comp, err := gcompress.ToComp(string(bundleData[p : p+compLen]))
+	if err != nil {
+		return nil, err
+	}
+	body := bundleData[p+compLen:]
+	if comp != gcompress.CompNone {
+		cr, err := gcompress.NewCompReader(comp, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		body, err = io.ReadAll(cr)
+		cr.Close()
+		if err != nil {
+			return nil, gerrors.Wrap(err, "decompress bundle")
+		}
+	}
+
+	indexLen, n := binary.Uvarint(body)
+	if n <= 0 || uint64(len(body)-n) < indexLen {
+		return nil, gerrors.New("invalid bundle index")
+	}
+	var metas []*FileMeta
+	if err := json.Unmarshal(body[n:n+int(indexLen)], &metas); err != nil {
+		return nil, gerrors.Wrap(err, "invalid bundle index")
+	}
+	data := body[n+int(indexLen):]
+
+	offset := int64(0)
+	for _, m := range metas {
+		if m.Size < 0 || offset+m.Size > int64(len(data)) {
+			return nil, gerrors.New("bundle file %s out of range", m.Name)
+		}
+		m.offset = offset
+		offset += m.Size
+		sum := sha256.Sum256(data[m.offset : m.offset+m.Size])
+		if hex.EncodeToString(sum[:]) != m.SHA256 {
+			return nil, gerrors.New("bundle file %s hash mismatch", m.Name)
+		}
+	}
+	return newBundle(metas, data)
+}
+
+// LoadHex loads bundle from hex string generated by EncDir.
+func LoadHex(hexString string) (*Bundle, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(hexString, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return Load(b)
+}
+
+// MustLoadHex is like LoadHex but panics on error, it is used by generated code.
+func MustLoadHex(hexString string) *Bundle {
+	b, err := LoadHex(hexString)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Files lists metadata of all files sorted by name.
+func (b *Bundle) Files() []FileMeta {
+	var res []FileMeta
+	for _, v := range b.files {
+		res = append(res, *v.meta)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Extract writes all files into dir, with their modes and modification times.
+func (b *Bundle) Extract(dir string) error {
+	for _, m := range b.Files() {
+		target := filepath.Join(dir, filepath.FromSlash(m.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f := b.files[m.Name]
+		if err := os.WriteFile(target, f.data, m.Mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, f.modTime(), f.modTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package gbindata
+
+import (
+	"github.com/cryptowilliam/goutil/compress/gcompress"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":       "<html></html>",
+		"css/site.css":     "body {}",
+		"js/app.js":        "console.log(1)",
+		"js/app.js.map":    "{}",
+		".git/config":      "[core]",
+		"img/icons/a.svg":  "<svg/>",
+		"img/icons/b.svg":  "<svg></svg>",
+		"img/icons/readme": "icons",
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.WriteFile(full, []byte(content), 0600); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.Chtimes(full, mtime, mtime); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	for _, comp := range []gcompress.Comp{gcompress.CompNone, gcompress.CompGzip, gcompress.CompZStd} {
+		data, err := Pack(dir, BundleOptions{Exclude: []string{".git", "*.map", "img/icons/readme"}, Comp: comp})
+		if err != nil {
+			t.Error(comp, err)
+			return
+		}
+		b, err := Load(data)
+		if err != nil {
+			t.Error(comp, err)
+			return
+		}
+		if err := fstest.TestFS(b, "index.html", "css/site.css", "js/app.js", "img/icons/a.svg", "img/icons/b.svg"); err != nil {
+			t.Error(comp, err)
+			return
+		}
+		if len(b.Files()) != 5 {
+			t.Errorf("%s: expect 5 files, got %d", comp, len(b.Files()))
+			return
+		}
+		fi, err := b.Stat("css/site.css")
+		if err != nil || !fi.ModTime().Equal(mtime) || fi.Mode().Perm() != 0600 || fi.Size() != 7 {
+			t.Errorf("%s: invalid file info %v %v", comp, fi, err)
+			return
+		}
+	}
+
+	// Include patterns.
+	data, err := Pack(dir, BundleOptions{Include: []string{"*.svg"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := Load(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(b.Files()) != 2 || b.Files()[0].Name != "img/icons/a.svg" {
+		t.Errorf("include failed: %v", b.Files())
+		return
+	}
+
+	// Corrupted data must be rejected.
+	data, _ = Pack(dir, BundleOptions{Comp: gcompress.CompNone})
+	data[len(data)-1] ^= 0xff
+	if _, err := Load(data); err == nil {
+		t.Error("corrupted bundle loaded")
+		return
+	}
+
+	// Serve by http.
+	data, _ = Pack(dir, DefaultBundleOptions)
+	b, _ = Load(data)
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/css/site.css", nil)
+	httpFS := b.HTTPFileSystem()
+	f, err := httpFS.Open("/js/app.js")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	content, _ := io.ReadAll(f)
+	f.Close()
+	if string(content) != files["js/app.js"] {
+		t.Errorf("http.FileSystem read %q", content)
+		return
+	}
+	http.FileServer(httpFS).ServeHTTP(resp, req)
+	if resp.Code != 200 || resp.Body.String() != files["css/site.css"] {
+		t.Errorf("serve failed %d %s", resp.Code, resp.Body.String())
+		return
+	}
+
+	// Generated go file.
+	goFile := filepath.Join(t.TempDir(), "assets.go")
+	if err := EncDir(dir, goFile, "assets", "Static", DefaultBundleOptions); err != nil {
+		t.Error(err)
+		return
+	}
+	src, _ := os.ReadFile(goFile)
+	if !strings.Contains(string(src), "package assets") || !strings.Contains(string(src), `var Static = gbindata.MustLoadHex("0x`) {
+		t.Errorf("invalid generated file %s", src[:200])
+		return
+	}
+	hexStr := string(src[strings.Index(string(src), `"0x`)+1 : strings.LastIndex(string(src), `"`)])
+	if len(MustLoadHex(hexStr).Files()) != len(files) {
+		t.Error("generated bundle mismatch")
+		return
+	}
+
+	// Extract.
+	outDir := t.TempDir()
+	if err := b.Extract(outDir); err != nil {
+		t.Error(err)
+		return
+	}
+	extracted, err := os.ReadFile(filepath.Join(outDir, "img", "icons", "b.svg"))
+	if err != nil || string(extracted) != files["img/icons/b.svg"] {
+		t.Errorf("extract failed %s %v", extracted, err)
+	}
+}
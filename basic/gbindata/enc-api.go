@@ -60,3 +60,32 @@ func Enc(binaryFilename, encodedGoFilename, packageName, varName string) error {
 
 	return nil
 }
+
+var templateBundle = `// DO NOT EDIT BY HAND
+//
+// Generated by bindata
+
+package %s
+
+import "github.com/cryptowilliam/goutil/basic/gbindata"
+
+// %s is a read-only file system, it implements fs.FS.
+var %s = gbindata.MustLoadHex("0x%s")
+`
+
+// EncDir packs directory tree into a go file, the generated variable is a *Bundle loaded at package initialization.
+func EncDir(dir, encodedGoFilename, packageName, varName string, opt BundleOptions) error {
+	if !gfs.DirExits(dir) {
+		return gerrors.Errorf("dir %s not exists", dir)
+	}
+	if !gstring.EndWith(encodedGoFilename, ".go") {
+		return gerrors.Errorf("filename %s doesn't end with .go", encodedGoFilename)
+	}
+
+	data, err := Pack(dir, opt)
+	if err != nil {
+		return err
+	}
+	src := fmt.Sprintf(templateBundle, packageName, varName, varName, hex.EncodeToString(data))
+	return os.WriteFile(encodedGoFilename, []byte(src), 0644)
+}
@@ -1,25 +1,68 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/cryptowilliam/goutil/basic/gbindata"
+	"github.com/cryptowilliam/goutil/compress/gcompress"
 	"os"
+	"strings"
 )
 
+func splitPatterns(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 func main() {
-	fmt.Println("Example:\nenc souce-binary-filename package-name var-name")
-	if len(os.Args) != 4 {
-		fmt.Println("Arguments number should be 4")
+	dir := flag.String("dir", "", "directory to pack, if empty, single file mode is used")
+	include := flag.String("include", "", "comma separated glob patterns of files to pack, empty means all")
+	exclude := flag.String("exclude", "", "comma separated glob patterns of files or directories to skip")
+	pkgname := flag.String("package", "main", "package name of generated go file")
+	varname := flag.String("var", "Assets", "variable name in generated go file")
+	out := flag.String("out", "", "generated go filename, default is <dir>.go")
+	comp := flag.String("comp", string(gcompress.CompGzip), "compress algorithm of directory mode")
+	flag.Usage = func() {
+		fmt.Println("Example:\nenc souce-binary-filename package-name var-name\nenc -dir ./static -exclude '*.map,.git' -package assets -var Static")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Single file mode, compatible with legacy positional arguments.
+	if *dir == "" {
+		if flag.NArg() != 3 {
+			flag.Usage()
+			fmt.Println("Arguments number should be 4")
+			return
+		}
+		binfile := flag.Arg(0)
+		fmt.Println("Start to encode", binfile)
+		if err := gbindata.Enc(binfile, binfile+".go", flag.Arg(1), flag.Arg(2)); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(binfile, "encode success")
 		return
 	}
 
-	binfile := os.Args[1]
-	pkgname := os.Args[2]
-	varname := os.Args[3]
-	fmt.Println("Start to encode", binfile)
-	err := gbindata.Enc(binfile, binfile+".go", pkgname, varname)
+	algo, err := gcompress.ToComp(*comp)
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		*out = strings.TrimRight(*dir, `/\`) + ".go"
+	}
+	opt := gbindata.BundleOptions{Include: splitPatterns(*include), Exclude: splitPatterns(*exclude), Comp: algo}
+	fmt.Println("Start to pack", *dir)
+	if err := gbindata.EncDir(*dir, *out, *pkgname, *varname, opt); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
-	fmt.Println(binfile, "encode success")
+	fmt.Println(*dir, "pack success", *out)
 }
@@ -0,0 +1,197 @@
+package gbindata
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Bundle is a read-only in-memory file system loaded from bundle data,
+	// it implements fs.FS, fs.ReadFileFS, fs.ReadDirFS and fs.StatFS.
+	Bundle struct {
+		files map[string]*bundleFile
+		dirs  map[string]*bundleDir
+	}
+
+	bundleFile struct {
+		meta *FileMeta
+		data []byte
+	}
+
+	bundleDir struct {
+		name    string
+		modTime time.Time
+		entries []fs.DirEntry
+	}
+
+	// bundleInfo implements fs.FileInfo and fs.DirEntry.
+	bundleInfo struct {
+		name    string
+		size    int64
+		mode    fs.FileMode
+		modTime time.Time
+	}
+
+	openFile struct {
+		*bytes.Reader
+		info bundleInfo
+	}
+
+	openDir struct {
+		info    bundleInfo
+		entries []fs.DirEntry
+		pos     int
+	}
+)
+
+func newBundle(metas []*FileMeta, data []byte) (*Bundle, error) {
+	b := &Bundle{files: map[string]*bundleFile{}, dirs: map[string]*bundleDir{".": {name: "."}}}
+	for _, m := range metas {
+		if !fs.ValidPath(m.Name) || m.Name == "." {
+			return nil, &fs.PathError{Op: "load", Path: m.Name, Err: fs.ErrInvalid}
+		}
+		f := &bundleFile{meta: m, data: data[m.offset : m.offset+m.Size]}
+		b.files[m.Name] = f
+
+		// Create parent directories, modification time of directory is the latest one of its children.
+		for dir := path.Dir(m.Name); ; dir = path.Dir(dir) {
+			d, ok := b.dirs[dir]
+			if !ok {
+				d = &bundleDir{name: dir}
+				b.dirs[dir] = d
+			}
+			if f.modTime().After(d.modTime) {
+				d.modTime = f.modTime()
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+
+	for name, f := range b.files {
+		parent := b.dirs[path.Dir(name)]
+		parent.entries = append(parent.entries, f.info())
+	}
+	for name, d := range b.dirs {
+		if name != "." {
+			parent := b.dirs[path.Dir(name)]
+			parent.entries = append(parent.entries, d.info())
+		}
+	}
+	for _, d := range b.dirs {
+		sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+	}
+	return b, nil
+}
+
+func (f *bundleFile) modTime() time.Time {
+	return time.Unix(0, f.meta.ModTime)
+}
+
+func (f *bundleFile) info() bundleInfo {
+	return bundleInfo{name: path.Base(f.meta.Name), size: f.meta.Size, mode: f.meta.Mode, modTime: f.modTime()}
+}
+
+func (d *bundleDir) info() bundleInfo {
+	return bundleInfo{name: path.Base(d.name), mode: fs.ModeDir | 0555, modTime: d.modTime}
+}
+
+func (i bundleInfo) Name() string               { return i.name }
+func (i bundleInfo) Size() int64                { return i.size }
+func (i bundleInfo) Mode() fs.FileMode          { return i.mode }
+func (i bundleInfo) ModTime() time.Time         { return i.modTime }
+func (i bundleInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i bundleInfo) Sys() interface{}           { return nil }
+func (i bundleInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i bundleInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *openDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remain := d.entries[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.entries)
+		return remain, nil
+	}
+	if len(remain) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remain) {
+		count = len(remain)
+	}
+	d.pos += count
+	return remain[:count], nil
+}
+
+// Open implements fs.FS, opened file implements io.Seeker and io.ReaderAt too.
+func (b *Bundle) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if f, ok := b.files[name]; ok {
+		return &openFile{Reader: bytes.NewReader(f.data), info: f.info()}, nil
+	}
+	if d, ok := b.dirs[name]; ok {
+		return &openDir{info: d.info(), entries: d.entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS, returned data is a copy.
+func (b *Bundle) ReadFile(name string) ([]byte, error) {
+	f, ok := b.files[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte{}, f.data...), nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (b *Bundle) ReadDir(name string) ([]fs.DirEntry, error) {
+	d, ok := b.dirs[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]fs.DirEntry{}, d.entries...), nil
+}
+
+// Stat implements fs.StatFS.
+func (b *Bundle) Stat(name string) (fs.FileInfo, error) {
+	if f, ok := b.files[name]; ok {
+		return f.info(), nil
+	}
+	if d, ok := b.dirs[name]; ok {
+		return d.info(), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Hash returns hex encoded SHA256 of file, it can be used as ETag.
+func (b *Bundle) Hash(name string) (string, bool) {
+	f, ok := b.files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", false
+	}
+	return f.meta.SHA256, true
+}
+
+// HTTPFileSystem returns http.FileSystem of bundle, so it can be served by http.FileServer or gfileserver.
+func (b *Bundle) HTTPFileSystem() http.FileSystem {
+	return http.FS(b)
+}
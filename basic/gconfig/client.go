@@ -10,9 +10,11 @@ import (
 	"github.com/cryptowilliam/goutil/sys/gfs"
 	"github.com/cryptowilliam/goutil/sys/gsysinfo"
 	"github.com/gocarina/gocsv"
+	"net/http"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 type (
@@ -21,12 +23,19 @@ type (
 		password string
 		salt     string
 		cfgDir   string
+		mu       sync.RWMutex
+
+		// Remote config center, see UseCenter.
+		centerURL  string
+		namespace  string
+		versions   map[string]int64
+		httpClient *http.Client
 	}
 )
 
 // NewClient creates new config client.
 func NewClient(customConfigDir string) (*Client, error) {
-	c := &Client{configs: map[string]string{}}
+	c := &Client{configs: map[string]string{}, versions: map[string]int64{}}
 
 	cfgDir := ""
 	err := error(nil)
@@ -110,8 +119,25 @@ func (c *Client) decryptFn(key string, val interface{}) (newVal interface{}, mod
 // Store writes config content `v` into file `configFileName`.
 // configFileName is config file short name with suffix, for example `myapp.json`.
 func (c *Client) Store(configFileName string, v interface{}) error {
+	str, err := c.marshal(configFileName, v)
+	if err != nil {
+		return err
+	}
+	return c.cache(configFileName, str)
+}
+
+// Store into cache and storage.
+func (c *Client) cache(configFileName, str string) error {
+	c.mu.Lock()
+	c.configs[configFileName] = str
+	c.mu.Unlock()
+	return gfs.StringToFile(str, filepath.Join(c.cfgDir, configFileName))
+}
+
+// Marshal and encrypt config content `v`, so it can be stored locally or published to config center.
+func (c *Client) marshal(configFileName string, v interface{}) (string, error) {
 	if v == nil || reflect.TypeOf(v) == nil {
-		return gerrors.New("can't marshal nil value")
+		return "", gerrors.New("can't marshal nil value")
 	}
 
 	// Marshal and encrypt from structure.
@@ -120,28 +146,27 @@ func (c *Client) Store(configFileName string, v interface{}) error {
 	if gstring.EndWith(configFileName, ".json") {
 		str, err = gjson.MarshalString(v, true)
 		if err != nil {
-			return err
+			return "", err
 		}
 		err = gjson.Iterate(&str, true, c.encryptFn) // Encrypt JSON string if necessary.
 	} else if gstring.EndWith(configFileName, ".csv") {
 		str, err = gocsv.MarshalString(v)
 	} else {
-		return gerrors.New("unsupported suffix of config %s", configFileName)
+		return "", gerrors.New("unsupported suffix of config %s", configFileName)
 	}
 	if err != nil {
-		return err
+		return "", err
 	}
-
-	// Store into cache and storage.
-	c.configs[configFileName] = str
-	return gfs.StringToFile(str, filepath.Join(c.cfgDir, configFileName))
+	return str, nil
 }
 
 // Load loads config and Unmarshal it into `v`.
 // configFileName is config file short name with suffix, for example `myapp.json`.
 func (c *Client) Load(configFileName string, v interface{}, allowEmpty bool) error {
 	// Load config string.
+	c.mu.RLock()
 	cfgVal, ok := c.configs[configFileName]
+	c.mu.RUnlock()
 	if (!ok || cfgVal == "") && !allowEmpty {
 		return gerrors.New("can't find config with %s", configFileName)
 	}
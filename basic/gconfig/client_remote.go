@@ -0,0 +1,214 @@
+package gconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/net/ghttp"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// ConfigChange is sent by Watch when config in config center changed.
+	ConfigChange struct {
+		Name    string
+		Version int64
+		Deleted bool
+	}
+)
+
+// MinWatchInterval is the minimum delay between polls of Watch after errors or early not modified replies.
+const MinWatchInterval = 500 * time.Millisecond
+
+// UseCenter makes client work with config center server.
+// Configs fetched from center are cached under ConfigDir, so Load still works when center is unreachable.
+func (c *Client) UseCenter(centerURL, namespace string) error {
+	if _, err := url.Parse(centerURL); err != nil {
+		return err
+	}
+	if err := verifyName(namespace, "-"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.centerURL = strings.TrimRight(centerURL, "/")
+	c.namespace = namespace
+	// Long-poll requests are bounded by context and server side timeout, not by client timeout.
+	c.httpClient = &http.Client{}
+	return nil
+}
+
+// Version returns version of config fetched from config center, 0 if never fetched.
+func (c *Client) Version(configFileName string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.versions[configFileName]
+}
+
+func (c *Client) docURL(path, configFileName string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.centerURL == "" {
+		return "", gerrors.New("config center not set, call UseCenter first")
+	}
+	return fmt.Sprintf("%s/v1/%s/%s/%s", c.centerURL, path, url.PathEscape(c.namespace), url.PathEscape(configFileName)), nil
+}
+
+func (c *Client) do(ctx context.Context, method ghttp.Method, reqURL string, body []byte) (*Document, int, error) {
+	req, err := http.NewRequestWithContext(ctx, string(method), reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	b, err := ghttp.ReadBodyBytes(resp)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return doc, resp.StatusCode, nil
+}
+
+func statusErr(code int, configFileName string) error {
+	switch code {
+	case http.StatusNotFound:
+		return gerrors.ErrNotExist
+	case http.StatusConflict:
+		return ErrVersionConflict
+	}
+	return gerrors.New("config center returns status %d for %s", code, configFileName)
+}
+
+// Fetch downloads config from config center and caches it under ConfigDir, then it can be read by Load.
+func (c *Client) Fetch(ctx context.Context, configFileName string) error {
+	reqURL, err := c.docURL("config", configFileName)
+	if err != nil {
+		return err
+	}
+	doc, code, err := c.do(ctx, ghttp.GET, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return statusErr(code, configFileName)
+	}
+	return c.applyDoc(configFileName, doc)
+}
+
+func (c *Client) applyDoc(configFileName string, doc *Document) error {
+	if err := c.cache(configFileName, doc.Content); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.versions[configFileName] = doc.Version
+	c.mu.Unlock()
+	return nil
+}
+
+// Publish marshals and encrypts `v`, then uploads it to config center and local cache.
+// Values with "EncryptMe" suffixed keys are encrypted before leaving the client, like Store does.
+// If onlyIfVersion > 0, publish fails with ErrVersionConflict unless remote version equals to it.
+func (c *Client) Publish(ctx context.Context, configFileName string, v interface{}, onlyIfVersion int64) error {
+	str, err := c.marshal(configFileName, v)
+	if err != nil {
+		return err
+	}
+	reqURL, err := c.docURL("config", configFileName)
+	if err != nil {
+		return err
+	}
+	if onlyIfVersion > 0 {
+		reqURL += fmt.Sprintf("?version=%d", onlyIfVersion)
+	}
+	doc, code, err := c.do(ctx, ghttp.PUT, reqURL, []byte(str))
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return statusErr(code, configFileName)
+	}
+	return c.applyDoc(configFileName, doc)
+}
+
+// Watch long-polls config center and updates local cache when config changed, every change is sent to notifyCh.
+// It blocks until ctx done, transient errors are retried after retryInterval, which is at least MinWatchInterval.
+// Polls answered not modified sooner than MinWatchInterval are delayed, so servers without long polling aren't flooded.
+func (c *Client) Watch(ctx context.Context, configFileName string, pollTimeout, retryInterval time.Duration, notifyCh chan<- ConfigChange) error {
+	reqURL, err := c.docURL("watch", configFileName)
+	if err != nil {
+		return err
+	}
+	if pollTimeout <= 0 {
+		pollTimeout = DefaultPollTimeout
+	}
+	if retryInterval < MinWatchInterval {
+		retryInterval = MinWatchInterval
+	}
+
+	for {
+		start := time.Now()
+		u := fmt.Sprintf("%s?version=%d&timeout=%s", reqURL, c.Version(configFileName), pollTimeout.String())
+		doc, code, err := c.do(ctx, ghttp.GET, u, nil)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		change := (*ConfigChange)(nil)
+		switch {
+		case err != nil:
+		case code == http.StatusNotModified:
+			select {
+			case <-time.After(MinWatchInterval - time.Since(start)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		case doc != nil:
+			if err = c.applyDoc(configFileName, doc); err == nil {
+				change = &ConfigChange{Name: configFileName, Version: doc.Version}
+			}
+		case code == http.StatusNotFound:
+			c.mu.Lock()
+			deleted := c.versions[configFileName] != 0
+			c.versions[configFileName] = 0
+			c.mu.Unlock()
+			if deleted {
+				change = &ConfigChange{Name: configFileName, Deleted: true}
+			} else {
+				err = gerrors.ErrNotExist
+			}
+		default:
+			err = statusErr(code, configFileName)
+		}
+
+		if change != nil {
+			select {
+			case notifyCh <- *change:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		if err != nil {
+			select {
+			case <-time.After(retryInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
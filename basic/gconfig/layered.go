@@ -0,0 +1,463 @@
+package gconfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gstruct"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Source is where the value of a config field comes from.
+	Source string
+
+	// FieldSource is the winning source of a config field, Name is file path, env var name or flag name.
+	FieldSource struct {
+		Source Source
+		Name   string
+	}
+
+	// LoadReport maps field path like "DB.Host" to its winning source, fields untouched by any source are absent.
+	LoadReport map[string]FieldSource
+
+	// LayeredLoader merges struct tag defaults, config files, environment variables and flags into a structure,
+	// later layer overrides earlier one.
+	//
+	// Supported tags:
+	// `default:"8080"`    default value
+	// `env:"APP_PORT"`    environment variable name, default is prefix + upper case path, "DB.Host" -> "PREFIX_DB_HOST"
+	// `flag:"port"`       flag name, default is lower case path, "DB.Host" -> "db.host"
+	// `required:"true"`   field must be provided by some source, or be non-zero
+	// `usage:"..."`       flag usage used by RegisterFlags
+	// File keys follow json/yaml/toml tags or field names, case-insensitively.
+	LayeredLoader struct {
+		files     []string
+		envPrefix string
+		flagSet   *flag.FlagSet
+	}
+
+	// allocatedPtr is a nil pointer field allocated temporarily, so layers can walk into it.
+	allocatedPtr struct {
+		path  string
+		field reflect.Value
+	}
+)
+
+const (
+	SourceDefault = Source("default")
+	SourceFile    = Source("file")
+	SourceEnv     = Source("env")
+	SourceFlag    = Source("flag")
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func NewLayeredLoader() *LayeredLoader {
+	return &LayeredLoader{}
+}
+
+// AddFile appends config file, format is decided by suffix: .json, .yaml, .yml or .toml.
+// Files are applied in the order they are added.
+func (l *LayeredLoader) AddFile(filename string) *LayeredLoader {
+	l.files = append(l.files, filename)
+	return l
+}
+
+// SetEnvPrefix sets prefix of default environment variable names, for example "MYAPP".
+func (l *LayeredLoader) SetEnvPrefix(prefix string) *LayeredLoader {
+	l.envPrefix = strings.TrimSuffix(strings.ToUpper(prefix), "_")
+	return l
+}
+
+// SetFlagSet sets parsed flag set, only flags explicitly set in command line override other sources.
+func (l *LayeredLoader) SetFlagSet(fs *flag.FlagSet) *LayeredLoader {
+	l.flagSet = fs
+	return l
+}
+
+func (l *LayeredLoader) envName(fi gstruct.FieldInfo) string {
+	if name, ok := fi.Field.Tag.Lookup("env"); ok && name != "" {
+		return name
+	}
+	name := strings.ToUpper(strings.ReplaceAll(fi.Path, ".", "_"))
+	if l.envPrefix != "" {
+		name = l.envPrefix + "_" + name
+	}
+	return name
+}
+
+func flagName(fi gstruct.FieldInfo) string {
+	if name, ok := fi.Field.Tag.Lookup("flag"); ok && name != "" {
+		return name
+	}
+	return strings.ToLower(fi.Path)
+}
+
+// RegisterFlags defines a string flag for every leaf field of `v`, so LayeredLoader can pick them up after parsing.
+func RegisterFlags(fs *flag.FlagSet, v interface{}) error {
+	allocated := allocPointers(v)
+	defer releasePointers(allocated, nil)
+	return gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+		if fi.Field.Tag.Get("flag") == "-" {
+			return val, false, nil
+		}
+		name := flagName(fi)
+		if fs.Lookup(name) == nil {
+			fs.String(name, fi.Field.Tag.Get("default"), fi.Field.Tag.Get("usage"))
+		}
+		return val, false, nil
+	})
+}
+
+// Load fills `v` from all layers, and reports which source won each field.
+func (l *LayeredLoader) Load(v interface{}) (LoadReport, error) {
+	report := LoadReport{}
+	// Nil pointer fields are skipped by gstruct.IterateEx, allocate them and
+	// release those no source touched after loading.
+	allocated := allocPointers(v)
+	defer releasePointers(allocated, report)
+
+	// Layer 1: defaults in struct tags.
+	err := gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+		def, ok := fi.Field.Tag.Lookup("default")
+		if !ok {
+			return val, false, nil
+		}
+		if err := setFromString(val, def); err != nil {
+			return val, false, gerrors.Wrap(err, "default value of "+fi.Path)
+		}
+		report[fi.Path] = FieldSource{Source: SourceDefault}
+		return val, false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Layer 2: config files.
+	for _, filename := range l.files {
+		if err := l.loadFile(filename, v, report); err != nil {
+			return nil, err
+		}
+	}
+
+	// Layer 3: environment variables.
+	err = gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+		name := l.envName(fi)
+		if fi.Field.Tag.Get("env") == "-" {
+			return val, false, nil
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			return val, false, nil
+		}
+		if err := setFromString(val, s); err != nil {
+			return val, false, gerrors.Wrap(err, fmt.Sprintf("env %s of %s", name, fi.Path))
+		}
+		report[fi.Path] = FieldSource{Source: SourceEnv, Name: name}
+		return val, false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Layer 4: flags set in command line.
+	if l.flagSet != nil {
+		setFlags := map[string]string{}
+		l.flagSet.Visit(func(f *flag.Flag) {
+			setFlags[f.Name] = f.Value.String()
+		})
+		err = gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+			name := flagName(fi)
+			s, ok := setFlags[name]
+			if !ok || fi.Field.Tag.Get("flag") == "-" {
+				return val, false, nil
+			}
+			if err := setFromString(val, s); err != nil {
+				return val, false, gerrors.Wrap(err, fmt.Sprintf("flag %s of %s", name, fi.Path))
+			}
+			report[fi.Path] = FieldSource{Source: SourceFlag, Name: name}
+			return val, false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate required fields.
+	var missing []string
+	err = gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+		if fi.Field.Tag.Get("required") != "true" {
+			return val, false, nil
+		}
+		if _, ok := report[fi.Path]; !ok && (val.IsZero() || isAllocated(allocated, fi.Path)) {
+			missing = append(missing, fi.Path)
+		}
+		return val, false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return report, gerrors.New("missing required config %s", strings.Join(missing, ", "))
+	}
+	return report, nil
+}
+
+// allocPointers allocates nil pointer fields of structure `v` recursively, parents come before children in result.
+// Pointers to types of their ancestors are skipped to stop at recursive types.
+func allocPointers(v interface{}) []allocatedPtr {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var res []allocatedPtr
+	allocStruct(rv.Elem(), "", map[reflect.Type]bool{rv.Elem().Type(): true}, &res)
+	return res
+}
+
+func allocStruct(v reflect.Value, pathPrefix string, ancestors map[reflect.Type]bool, res *[]allocatedPtr) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		path := pathPrefix + v.Type().Field(i).Name
+		ft := field.Type()
+		switch {
+		case ft.Kind() == reflect.Ptr:
+			elem := ft.Elem()
+			isStruct := elem.Kind() == reflect.Struct && !ft.Implements(textUnmarshalerType)
+			if isStruct && ancestors[elem] {
+				continue
+			}
+			if field.IsNil() {
+				field.Set(reflect.New(elem))
+				*res = append(*res, allocatedPtr{path: path, field: field})
+			}
+			if isStruct {
+				ancestors[elem] = true
+				allocStruct(field.Elem(), path+".", ancestors, res)
+				delete(ancestors, elem)
+			}
+		case ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(textUnmarshalerType):
+			if ancestors[ft] {
+				continue
+			}
+			ancestors[ft] = true
+			allocStruct(field, path+".", ancestors, res)
+			delete(ancestors, ft)
+		}
+	}
+}
+
+// releasePointers resets allocated pointers back to nil if no field at or under their paths is in report.
+func releasePointers(allocated []allocatedPtr, report LoadReport) {
+	for i := len(allocated) - 1; i >= 0; i-- {
+		touched := false
+		for path := range report {
+			if path == allocated[i].path || strings.HasPrefix(path, allocated[i].path+".") {
+				touched = true
+				break
+			}
+		}
+		if !touched {
+			allocated[i].field.Set(reflect.Zero(allocated[i].field.Type()))
+		}
+	}
+}
+
+func isAllocated(allocated []allocatedPtr, path string) bool {
+	for _, a := range allocated {
+		if a.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LayeredLoader) loadFile(filename string, v interface{}, report LoadReport) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	tagKey := ""
+	generic := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		tagKey = "json"
+		if err := json.Unmarshal(data, v); err != nil {
+			return gerrors.Wrap(err, filename)
+		}
+		err = json.Unmarshal(data, &generic)
+	case ".yaml", ".yml":
+		tagKey = "yaml"
+		if err := yaml.Unmarshal(data, v); err != nil {
+			return gerrors.Wrap(err, filename)
+		}
+		err = yaml.Unmarshal(data, &generic)
+	case ".toml":
+		tagKey = "toml"
+		if err := toml.Unmarshal(data, v); err != nil {
+			return gerrors.Wrap(err, filename)
+		}
+		err = toml.Unmarshal(data, &generic)
+	default:
+		return gerrors.New("unsupported suffix of config %s", filename)
+	}
+	if err != nil {
+		return gerrors.Wrap(err, filename)
+	}
+
+	// Find out which fields are present in file.
+	return gstruct.IterateEx(v, func(fi gstruct.FieldInfo, val reflect.Value) (reflect.Value, bool, error) {
+		if fileHasPath(generic, reflect.TypeOf(v).Elem(), strings.Split(fi.Path, "."), tagKey) {
+			report[fi.Path] = FieldSource{Source: SourceFile, Name: filename}
+		}
+		return val, false, nil
+	})
+}
+
+// fileHasPath checks whether decoded file content contains field path of structure type t.
+func fileHasPath(node interface{}, t reflect.Type, path []string, tagKey string) bool {
+	for _, name := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			return false
+		}
+		key := sf.Name
+		if tag := strings.Split(sf.Tag.Get(tagKey), ",")[0]; tag != "" {
+			if tag == "-" {
+				return false
+			}
+			key = tag
+		}
+		child, ok := lookupKey(node, key)
+		if !ok {
+			return false
+		}
+		node = child
+		t = sf.Type
+	}
+	return true
+}
+
+func lookupKey(node interface{}, key string) (interface{}, bool) {
+	switch m := node.(type) {
+	case map[string]interface{}:
+		if v, ok := m[key]; ok {
+			return v, true
+		}
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				return v, true
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			if ks, ok := k.(string); ok && strings.EqualFold(ks, key) {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// setFromString parses string into value of basic type, time.Duration, text unmarshaler,
+// pointer to them, or slice of them separated by comma.
+func setFromString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setFromString(v.Elem(), s)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		if s != "" {
+			items = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setFromString(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return gerrors.New("unsupported config field type %s", v.Type().String())
+	}
+	return nil
+}
+
+// String lists field sources sorted by path, for logging.
+func (r LoadReport) String() string {
+	var paths []string
+	for k := range r {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	sb := strings.Builder{}
+	for _, p := range paths {
+		src := r[p]
+		sb.WriteString(p + " <- " + string(src.Source))
+		if src.Name != "" {
+			sb.WriteString(":" + src.Name)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,115 @@
+package gconfig
+
+import (
+	"flag"
+	"github.com/cryptowilliam/goutil/basic/gtest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLayeredLoader_Load(t *testing.T) {
+	type (
+		DB struct {
+			Host string `default:"localhost" yaml:"host"`
+			Port int    `default:"5432" yaml:"port"`
+		}
+		Sample struct {
+			Name    string        `required:"true" yaml:"name"`
+			Debug   bool          `default:"false" flag:"debug"`
+			Timeout time.Duration `default:"3s" yaml:"timeout"`
+			Tags    []string      `env:"SAMPLE_TAGS"`
+			DB      DB            `yaml:"db"`
+			Token   string        `required:"true"`
+		}
+	)
+
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "app.yaml")
+	gtest.Assert(t, os.WriteFile(yamlFile, []byte("name: app\ndb:\n  host: db.local\n"), 0644))
+	tomlFile := filepath.Join(dir, "app.toml")
+	gtest.Assert(t, os.WriteFile(tomlFile, []byte("Timeout = \"5s\"\n[DB]\nPort = 6543\n"), 0644))
+	t.Setenv("MYAPP_DB_PORT", "7654")
+	t.Setenv("SAMPLE_TAGS", "a, b")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	s := Sample{}
+	gtest.Assert(t, RegisterFlags(fs, &s))
+	gtest.Assert(t, fs.Parse([]string{"-debug=true"}))
+
+	l := NewLayeredLoader().AddFile(yamlFile).SetEnvPrefix("myapp").SetFlagSet(fs)
+	if _, err := l.Load(&s); err == nil {
+		t.Errorf("missing required Token should fail")
+		return
+	}
+
+	t.Setenv("MYAPP_TOKEN", "tk")
+	s = Sample{}
+	report, err := l.AddFile(tomlFile).Load(&s)
+	gtest.Assert(t, err)
+	if s.Name != "app" || !s.Debug || s.Timeout != 5*time.Second || len(s.Tags) != 2 || s.Tags[1] != "b" ||
+		s.DB.Host != "db.local" || s.DB.Port != 7654 || s.Token != "tk" {
+		t.Errorf("unexpected config %+v", s)
+		return
+	}
+
+	expect := map[string]FieldSource{
+		"Name":    {SourceFile, yamlFile},
+		"Debug":   {SourceFlag, "debug"},
+		"Timeout": {SourceFile, tomlFile},
+		"Tags":    {SourceEnv, "SAMPLE_TAGS"},
+		"DB.Host": {SourceFile, yamlFile},
+		"DB.Port": {SourceEnv, "MYAPP_DB_PORT"},
+	}
+	for k, v := range expect {
+		if report[k] != v {
+			t.Errorf("field %s expect source %v, got %v", k, v, report[k])
+		}
+	}
+}
+
+func TestLayeredLoader_LoadPointers(t *testing.T) {
+	type (
+		Inner struct {
+			Host string
+			Port int `default:"5432"`
+		}
+		Sample struct {
+			Port  *int `default:"8080"`
+			DB    *Inner
+			Cache *Inner
+			Next  *Sample
+		}
+	)
+
+	t.Setenv("X_DB_HOST", "db.local")
+	s := Sample{}
+	report, err := NewLayeredLoader().SetEnvPrefix("X").Load(&s)
+	gtest.Assert(t, err)
+	if s.Port == nil || *s.Port != 8080 || s.DB == nil || s.DB.Host != "db.local" || s.DB.Port != 5432 {
+		t.Errorf("unexpected config %+v", s)
+		return
+	}
+	if report["Port"].Source != SourceDefault || report["DB.Host"] != (FieldSource{SourceEnv, "X_DB_HOST"}) {
+		t.Errorf("unexpected report %s", report)
+		return
+	}
+	// Cache is filled by default of Cache.Port, recursive Next is left nil.
+	if s.Cache == nil || s.Cache.Port != 5432 || s.Next != nil {
+		t.Errorf("unexpected config %+v", s)
+		return
+	}
+
+	type Optional struct {
+		DB *struct {
+			Host string
+		}
+	}
+	o := Optional{}
+	_, err = NewLayeredLoader().Load(&o)
+	gtest.Assert(t, err)
+	if o.DB != nil {
+		t.Errorf("untouched pointer should be left nil")
+	}
+}
@@ -0,0 +1,186 @@
+package gconfig
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gstruct"
+	"github.com/cryptowilliam/goutil/sys/gfs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// ChangeCallback is invoked once for every changed field matching its registered path.
+	ChangeCallback[T any] func(change gstruct.Change, oldCfg, newCfg *T)
+
+	// Reloader keeps config of type T up to date with its files.
+	// A file which fails to load or validate is rejected, and previous config is retained.
+	Reloader[T any] struct {
+		loader    *LayeredLoader
+		validate  func(cfg *T) error
+		curr      *T
+		callbacks []reloadCallback[T]
+		watchers  []*gfs.FsWatcher
+		reloadMu  sync.Mutex
+		mu        sync.RWMutex
+		done      chan struct{}
+	}
+
+	reloadCallback[T any] struct {
+		path string
+		fn   ChangeCallback[T]
+	}
+)
+
+// NewReloader loads config from loader, validate is optional.
+func NewReloader[T any](loader *LayeredLoader, validate func(cfg *T) error) (*Reloader[T], error) {
+	r := &Reloader[T]{loader: loader, validate: validate, done: make(chan struct{})}
+	cfg, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.curr = cfg
+	return r, nil
+}
+
+func (r *Reloader[T]) load() (*T, error) {
+	cfg := new(T)
+	if _, err := r.loader.Load(cfg); err != nil {
+		return nil, err
+	}
+	if r.validate != nil {
+		if err := r.validate(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// Get returns current config, it is shared and must not be modified.
+func (r *Reloader[T]) Get() *T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.curr
+}
+
+// OnChange registers callback for field path and all its children, for example "DB" matches "DB.Host".
+// Empty path matches all fields.
+func (r *Reloader[T]) OnChange(path string, fn ChangeCallback[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks = append(r.callbacks, reloadCallback[T]{path: path, fn: fn})
+}
+
+func pathMatch(registered, changed string) bool {
+	return registered == "" || registered == changed || strings.HasPrefix(changed, registered+".")
+}
+
+// Reload loads config again, replaces current one and invokes callbacks if anything changed.
+func (r *Reloader[T]) Reload() ([]gstruct.Change, error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	old := r.curr
+	changes, err := gstruct.Diff(*old, *cfg)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	if len(changes) == 0 {
+		r.mu.Unlock()
+		return nil, nil
+	}
+	r.curr = cfg
+	callbacks := append([]reloadCallback[T]{}, r.callbacks...)
+	r.mu.Unlock()
+
+	for _, change := range changes {
+		for _, cb := range callbacks {
+			if pathMatch(cb.path, change.Path) {
+				cb.fn(change, old, cfg)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Watch starts watching config files in background, files are checked every interval.
+// onErr is optional, it receives reload and watch errors.
+func (r *Reloader[T]) Watch(interval time.Duration, onErr func(err error)) error {
+	if len(r.loader.files) == 0 {
+		return gerrors.New("no config file to watch")
+	}
+
+	// Watch directories instead of files, so files replaced by editors with rename are still tracked.
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, v := range r.loader.files {
+		abs, err := filepath.Abs(v)
+		if err != nil {
+			return err
+		}
+		files[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+
+	notifyCh := make(chan gfs.ChangedEvent)
+	for dir := range dirs {
+		w := gfs.NewWatcher()
+		if err := w.Start(dir, interval, notifyCh, gfs.Create, gfs.Write, gfs.Rename, gfs.Move); err != nil {
+			r.Close()
+			return err
+		}
+		r.mu.Lock()
+		r.watchers = append(r.watchers, w)
+		r.mu.Unlock()
+	}
+
+	go func() {
+		for {
+			select {
+			case evt := <-notifyCh:
+				if evt.Err != nil {
+					if onErr != nil {
+						onErr(evt.Err)
+					}
+					continue
+				}
+				if !files[evt.Path] {
+					continue
+				}
+				if _, err := r.Reload(); err != nil && onErr != nil {
+					onErr(gerrors.Wrap(err, "reload "+evt.Path))
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops watching.
+func (r *Reloader[T]) Close() {
+	r.mu.Lock()
+	watchers := r.watchers
+	r.watchers = nil
+	r.mu.Unlock()
+
+	// Close watchers before event consumer exits, so watchers never block on sending events.
+	for _, w := range watchers {
+		w.Close()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+}
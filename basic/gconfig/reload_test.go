@@ -0,0 +1,67 @@
+package gconfig
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/basic/gtest"
+	"github.com/cryptowilliam/goutil/container/gstruct"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloader_Watch(t *testing.T) {
+	type Sample struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "app.json")
+	gtest.Assert(t, os.WriteFile(filename, []byte(`{"Name":"a","DB":{"Host":"h1","Port":1}}`), 0644))
+	r, err := NewReloader[Sample](NewLayeredLoader().AddFile(filename), func(cfg *Sample) error {
+		if cfg.DB.Port <= 0 {
+			return gerrors.New("invalid port %d", cfg.DB.Port)
+		}
+		return nil
+	})
+	gtest.Assert(t, err)
+	defer r.Close()
+
+	changedCh := make(chan gstruct.Change, 10)
+	r.OnChange("DB", func(change gstruct.Change, oldCfg, newCfg *Sample) {
+		changedCh <- change
+	})
+	errCh := make(chan error, 10)
+	gtest.Assert(t, r.Watch(50*time.Millisecond, func(err error) { errCh <- err }))
+
+	// Invalid config must be rejected.
+	gtest.Assert(t, os.WriteFile(filename, []byte(`{"Name":"b","DB":{"Host":"h1","Port":0}}`), 0644))
+	select {
+	case <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Errorf("invalid config not rejected")
+		return
+	}
+	if r.Get().Name != "a" {
+		t.Errorf("previous config should be retained")
+		return
+	}
+
+	gtest.Assert(t, os.WriteFile(filename, []byte(`{"Name":"c","DB":{"Host":"h2","Port":1}}`), 0644))
+	select {
+	case change := <-changedCh:
+		if change.Path != "DB.Host" || change.From != "h1" || change.To != "h2" {
+			t.Errorf("unexpected change %+v", change)
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("change callback not invoked")
+		return
+	}
+	if r.Get().Name != "c" {
+		t.Errorf("config not reloaded")
+	}
+}
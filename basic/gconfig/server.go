@@ -1,10 +1,375 @@
 package gconfig
 
+import (
+	"context"
+	"errors"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/net/ghttp"
+	"github.com/cryptowilliam/goutil/net/gweb"
+	"github.com/cryptowilliam/goutil/sys/gfs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
 type (
+	// Document is a versioned config file stored in config center.
+	// Content is stored as it is, values encrypted by Client are never decrypted by server.
+	Document struct {
+		Namespace string
+		Key       string
+		Version   int64
+		Content   string
+		UpdatedAt time.Time
+	}
+
+	// MgrCenter is config center server, it serves namespaced config documents over HTTP.
+	//
+	// GET    /v1/config/:ns               list documents of namespace, content excluded
+	// GET    /v1/config/:ns/:key          get document
+	// PUT    /v1/config/:ns/:key?version= create or update document, version is optional for compare-and-swap
+	// DELETE /v1/config/:ns/:key          delete document
+	// GET    /v1/watch/:ns/:key?version=&timeout=
+	//        long-poll until document version differs from `version`, 304 returned if timeout
 	MgrCenter struct {
+		router   *gweb.Router
+		storeDir string
+		docs     map[string]map[string]*Document
+		notify   map[string]chan struct{}
+		mu       sync.RWMutex
 	}
 )
 
-func NewConfigCenter() (*MgrCenter, error) {
-	return nil, nil
+const (
+	DefaultPollTimeout = 30 * time.Second
+	MaxPollTimeout     = 5 * time.Minute
+)
+
+var (
+	ErrVersionConflict = errors.New("config version conflict")
+
+	errFmt = gweb.ErrFormatter(func(err error) string { return err.Error() })
+)
+
+// NewConfigCenter creates config center server, documents are persisted into storeDir if it is not empty.
+func NewConfigCenter(storeDir string) (*MgrCenter, error) {
+	mc := &MgrCenter{
+		router:   gweb.NewRouter(),
+		storeDir: storeDir,
+		docs:     map[string]map[string]*Document{},
+		notify:   map[string]chan struct{}{},
+	}
+	if err := mc.restore(); err != nil {
+		return nil, err
+	}
+
+	mc.router.Handle(ghttp.GET, "/v1/config/:ns", mc.handleList)
+	mc.router.Handle(ghttp.GET, "/v1/config/:ns/:key", mc.handleGet)
+	mc.router.Handle(ghttp.PUT, "/v1/config/:ns/:key", mc.handlePut)
+	mc.router.Handle(ghttp.DELETE, "/v1/config/:ns/:key", mc.handleDelete)
+	mc.router.Handle(ghttp.GET, "/v1/watch/:ns/:key", mc.handleWatch)
+	return mc, nil
+}
+
+// Serve listens and serves HTTP requests, it blocks until error.
+func (mc *MgrCenter) Serve(addr string) error {
+	return mc.router.Serve(addr)
+}
+
+// ServeHTTP implements http.Handler.
+func (mc *MgrCenter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	mc.router.ServeHTTP(w, req)
+}
+
+func verifyName(ns, key string) error {
+	for _, v := range []string{ns, key} {
+		if v == "" || v == "." || v == ".." || strings.ContainsAny(v, `/\`) {
+			return gerrors.New("invalid config namespace or key '%s'", v)
+		}
+	}
+	return nil
+}
+
+func notifyKey(ns, key string) string {
+	return ns + "/" + key
+}
+
+// Get returns a copy of document.
+func (mc *MgrCenter) Get(ns, key string) (*Document, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	doc, ok := mc.docs[ns][key]
+	if !ok {
+		return nil, gerrors.ErrNotExist
+	}
+	cp := *doc
+	return &cp, nil
+}
+
+// List returns documents of namespace sorted by key, content excluded.
+func (mc *MgrCenter) List(ns string) []Document {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	var res []Document
+	for _, v := range mc.docs[ns] {
+		cp := *v
+		cp.Content = ""
+		res = append(res, cp)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// Put creates or updates document and returns the new version.
+// If expectVersion > 0, it fails with ErrVersionConflict unless current version equals to it.
+func (mc *MgrCenter) Put(ns, key, content string, expectVersion int64) (*Document, error) {
+	if err := verifyName(ns, key); err != nil {
+		return nil, err
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	curr, exist := mc.docs[ns][key]
+	currVer := int64(0)
+	if exist {
+		currVer = curr.Version
+	}
+	if expectVersion > 0 && expectVersion != currVer {
+		return nil, ErrVersionConflict
+	}
+
+	doc := &Document{Namespace: ns, Key: key, Version: currVer + 1, Content: content, UpdatedAt: time.Now()}
+	if mc.docs[ns] == nil {
+		mc.docs[ns] = map[string]*Document{}
+	}
+	mc.docs[ns][key] = doc
+	if err := mc.persist(ns); err != nil {
+		if exist {
+			mc.docs[ns][key] = curr
+		} else {
+			delete(mc.docs[ns], key)
+		}
+		return nil, err
+	}
+	mc.broadcast(ns, key)
+	cp := *doc
+	return &cp, nil
+}
+
+// Delete removes document.
+func (mc *MgrCenter) Delete(ns, key string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	doc, ok := mc.docs[ns][key]
+	if !ok {
+		return gerrors.ErrNotExist
+	}
+	delete(mc.docs[ns], key)
+	if err := mc.persist(ns); err != nil {
+		mc.docs[ns][key] = doc
+		return err
+	}
+	mc.broadcast(ns, key)
+	return nil
+}
+
+// Wait blocks until document version differs from `version`, or timeout / ctx done.
+// It returns nil document if document deleted, changed is false if timeout.
+func (mc *MgrCenter) Wait(ctx context.Context, ns, key string, version int64, timeout time.Duration) (doc *Document, changed bool, err error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		mc.mu.Lock()
+		curr, exist := mc.docs[ns][key]
+		currVer := int64(0)
+		if exist {
+			currVer = curr.Version
+		}
+		if currVer != version {
+			var res *Document
+			if exist {
+				cp := *curr
+				res = &cp
+			}
+			mc.mu.Unlock()
+			return res, true, nil
+		}
+		ch, ok := mc.notify[notifyKey(ns, key)]
+		if !ok {
+			ch = make(chan struct{})
+			mc.notify[notifyKey(ns, key)] = ch
+		}
+		mc.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-timer.C:
+			return nil, false, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// Wake up all waiters of document, caller must hold mc.mu.
+func (mc *MgrCenter) broadcast(ns, key string) {
+	if ch, ok := mc.notify[notifyKey(ns, key)]; ok {
+		close(ch)
+		delete(mc.notify, notifyKey(ns, key))
+	}
+}
+
+// Save all documents of namespace into one file, caller must hold mc.mu.
+func (mc *MgrCenter) persist(ns string) error {
+	if mc.storeDir == "" {
+		return nil
+	}
+	var docs []*Document
+	for _, v := range mc.docs[ns] {
+		docs = append(docs, v)
+	}
+	filename := filepath.Join(mc.storeDir, ns+".json")
+	if len(docs) == 0 {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	tmp := filename + ".tmp"
+	if err := gfs.JsonToFile(docs, true, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func (mc *MgrCenter) restore() error {
+	if mc.storeDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(mc.storeDir, os.ModePerm); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(mc.storeDir)
+	if err != nil {
+		return err
+	}
+	for _, v := range entries {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+		var docs []*Document
+		if err := gfs.FileToJson(filepath.Join(mc.storeDir, v.Name()), &docs); err != nil {
+			return gerrors.Wrap(err, "restore "+v.Name())
+		}
+		for _, doc := range docs {
+			if mc.docs[doc.Namespace] == nil {
+				mc.docs[doc.Namespace] = map[string]*Document{}
+			}
+			mc.docs[doc.Namespace][doc.Key] = doc
+		}
+	}
+	return nil
+}
+
+func writeErr(c *gweb.Ctx, err error) {
+	code := http.StatusInternalServerError
+	switch err {
+	case gerrors.ErrNotExist:
+		code = http.StatusNotFound
+	case ErrVersionConflict:
+		code = http.StatusConflict
+	}
+	c.WriteError(code, err, errFmt)
+}
+
+func parseVersion(c *gweb.Ctx) (int64, error) {
+	s := c.GetQueryParamString("version")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func (mc *MgrCenter) handleList(c *gweb.Ctx) {
+	docs := mc.List(c.GetUriSliceString("ns"))
+	if docs == nil {
+		docs = []Document{}
+	}
+	c.WriteStructJSON(http.StatusOK, docs, errFmt)
+}
+
+func (mc *MgrCenter) handleGet(c *gweb.Ctx) {
+	doc, err := mc.Get(c.GetUriSliceString("ns"), c.GetUriSliceString("key"))
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.WriteStructJSON(http.StatusOK, doc, errFmt)
+}
+
+func (mc *MgrCenter) handlePut(c *gweb.Ctx) {
+	version, err := parseVersion(c)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err, errFmt)
+		return
+	}
+	body, err := c.Body2Bytes()
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err, errFmt)
+		return
+	}
+	doc, err := mc.Put(c.GetUriSliceString("ns"), c.GetUriSliceString("key"), string(body), version)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.WriteStructJSON(http.StatusOK, doc, errFmt)
+}
+
+func (mc *MgrCenter) handleDelete(c *gweb.Ctx) {
+	if err := mc.Delete(c.GetUriSliceString("ns"), c.GetUriSliceString("key")); err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.WriteStatus(http.StatusNoContent)
+}
+
+func (mc *MgrCenter) handleWatch(c *gweb.Ctx) {
+	version, err := parseVersion(c)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err, errFmt)
+		return
+	}
+	timeout := DefaultPollTimeout
+	if s := c.GetQueryParamString("timeout"); s != "" {
+		timeout, err = time.ParseDuration(s)
+		if err != nil || timeout <= 0 {
+			c.WriteError(http.StatusBadRequest, gerrors.New("invalid timeout '%s'", s), errFmt)
+			return
+		}
+	}
+	if timeout > MaxPollTimeout {
+		timeout = MaxPollTimeout
+	}
+
+	doc, changed, err := mc.Wait(c.Context(), c.GetUriSliceString("ns"), c.GetUriSliceString("key"), version, timeout)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	if !changed {
+		c.WriteStatus(http.StatusNotModified)
+		return
+	}
+	if doc == nil {
+		writeErr(c, gerrors.ErrNotExist)
+		return
+	}
+	c.WriteStructJSON(http.StatusOK, doc, errFmt)
 }
@@ -0,0 +1,98 @@
+package gconfig
+
+import (
+	"context"
+	"github.com/cryptowilliam/goutil/basic/gtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMgrCenter_Client(t *testing.T) {
+	type Sample struct {
+		TokenEncryptMe string
+		Name           string
+	}
+
+	mc, err := NewConfigCenter(t.TempDir())
+	gtest.Assert(t, err)
+	srv := httptest.NewServer(mc)
+	defer srv.Close()
+
+	pub, err := NewClient(t.TempDir())
+	gtest.Assert(t, err)
+	pub.SetPassword("pwd", "nonce")
+	gtest.Assert(t, pub.UseCenter(srv.URL, "myapp"))
+	sub, err := NewClient(t.TempDir())
+	gtest.Assert(t, err)
+	sub.SetPassword("pwd", "nonce")
+	gtest.Assert(t, sub.UseCenter(srv.URL, "myapp"))
+
+	ctx := context.Background()
+	gtest.Assert(t, pub.Publish(ctx, "app.json", &Sample{TokenEncryptMe: "secret", Name: "v1"}, 0))
+	doc, err := mc.Get("myapp", "app.json")
+	gtest.Assert(t, err)
+	if doc.Version != 1 || strings.Contains(doc.Content, "secret") {
+		t.Errorf("unexpected document %+v", doc)
+		return
+	}
+
+	gtest.Assert(t, sub.Fetch(ctx, "app.json"))
+	s := Sample{}
+	gtest.Assert(t, sub.Load("app.json", &s, false))
+	if s.TokenEncryptMe != "secret" || s.Name != "v1" {
+		t.Errorf("unexpected config %+v", s)
+		return
+	}
+
+	// Stale version must be rejected.
+	if err := pub.Publish(ctx, "app.json", &Sample{Name: "stale"}, 5); err != ErrVersionConflict {
+		t.Errorf("expect version conflict, got %v", err)
+		return
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	notifyCh := make(chan ConfigChange, 1)
+	go sub.Watch(watchCtx, "app.json", time.Second, 100*time.Millisecond, notifyCh)
+	time.Sleep(200 * time.Millisecond)
+	gtest.Assert(t, pub.Publish(ctx, "app.json", &Sample{TokenEncryptMe: "secret2", Name: "v2"}, 1))
+
+	select {
+	case change := <-notifyCh:
+		if change.Version != 2 {
+			t.Errorf("unexpected change %+v", change)
+			return
+		}
+	case <-watchCtx.Done():
+		t.Errorf("watch timeout")
+		return
+	}
+	gtest.Assert(t, sub.Load("app.json", &s, false))
+	if s.TokenEncryptMe != "secret2" || s.Name != "v2" {
+		t.Errorf("unexpected config %+v", s)
+	}
+}
+
+func TestClient_WatchBackoff(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(t.TempDir())
+	gtest.Assert(t, err)
+	gtest.Assert(t, c.UseCenter(srv.URL, "myapp"))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*MinWatchInterval+MinWatchInterval/2)
+	defer cancel()
+	c.Watch(ctx, "app.json", time.Second, 0, make(chan ConfigChange))
+	// Server answers immediately without long polling.
+	if n := atomic.LoadInt32(&polls); n > 3 {
+		t.Errorf("expect at most 3 polls, got %d", n)
+	}
+}
@@ -0,0 +1,83 @@
+package gdaemon
+
+import (
+	"context"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"net"
+	"net/http"
+	"os/exec"
+)
+
+type (
+	// HealthChecker checks whether supervised process works, ctx carries check timeout.
+	HealthChecker interface {
+		Check(ctx context.Context) error
+	}
+
+	// HealthCheckFunc adapts function to HealthChecker.
+	HealthCheckFunc func(ctx context.Context) error
+
+	// HTTPCheck requests URL with GET, status code in [200, 400) is healthy unless ExpectStatus is set.
+	HTTPCheck struct {
+		URL          string
+		ExpectStatus int
+		Client       *http.Client
+	}
+
+	// TCPCheck dials Addr.
+	TCPCheck struct {
+		Addr string
+	}
+
+	// CommandCheck runs command, zero exit code is healthy.
+	CommandCheck struct {
+		Name string
+		Args []string
+	}
+)
+
+func (f HealthCheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+func (c HTTPCheck) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if c.ExpectStatus != 0 {
+		if resp.StatusCode != c.ExpectStatus {
+			return gerrors.New("health check %s returns status %d, expect %d", c.URL, resp.StatusCode, c.ExpectStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return gerrors.New("health check %s returns status %d", c.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+func (c TCPCheck) Check(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (c CommandCheck) Check(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, c.Name, c.Args...).CombinedOutput()
+	if err != nil {
+		return gerrors.Wrap(err, "health check command output: "+string(out))
+	}
+	return nil
+}
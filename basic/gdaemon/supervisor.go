@@ -0,0 +1,433 @@
+package gdaemon
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/sys/gsignal"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type (
+	RestartPolicy string
+
+	SupervisorState string
+
+	// SupervisorConfig describes child process and how it is supervised, zero values are replaced by defaults.
+	SupervisorConfig struct {
+		Name   string
+		Args   []string
+		Dir    string
+		Env    []string // Appended to environment of current process.
+		Stdout io.Writer
+		Stderr io.Writer
+
+		// PidFile contains PID of running child process, it is removed when child exits.
+		PidFile string
+
+		Policy            RestartPolicy
+		MinBackoff        time.Duration // Default 1s.
+		MaxBackoff        time.Duration // Default 1m.
+		ResetBackoffAfter time.Duration // Backoff is reset if child ran longer than it, default 1m.
+		MaxRestarts       int           // 0 means unlimited.
+		RestartWindow     time.Duration // MaxRestarts are counted in this sliding window, 0 means lifetime of supervisor.
+
+		HealthCheck    HealthChecker
+		HealthInterval time.Duration // Default 10s.
+		HealthTimeout  time.Duration // Default 5s.
+		HealthGrace    time.Duration // Delay of first check after child starts, default HealthInterval.
+		HealthFailures int           // Consecutive failures before restarting child, default 3.
+
+		StopSignal  os.Signal     // Sent to child when stopping, default SIGTERM.
+		StopTimeout time.Duration // Child is killed if it doesn't exit in time after StopSignal, default 10s.
+
+		// Signals caught by supervisor, ForwardSignals are forwarded to child,
+		// StopSignals stop child gracefully and make Run return. Nil means defaults.
+		ForwardSignals []os.Signal // Default SIGHUP.
+		StopSignals    []os.Signal // Default SIGINT and SIGTERM.
+		DisableSignals bool        // Don't catch any signal, for embedding supervisor in other applications.
+	}
+
+	// SupervisorStatus is the snapshot of supervisor, it is safe to be serialized for deploy tools.
+	SupervisorStatus struct {
+		State           SupervisorState `json:"state"`
+		Pid             int             `json:"pid,omitempty"`
+		Restarts        int             `json:"restarts"`
+		StartedAt       time.Time       `json:"startedAt,omitempty"`
+		LastExitAt      time.Time       `json:"lastExitAt,omitempty"`
+		LastExitCode    int             `json:"lastExitCode"`
+		LastError       string          `json:"lastError,omitempty"`
+		Healthy         bool            `json:"healthy"`
+		LastHealthCheck time.Time       `json:"lastHealthCheck,omitempty"`
+		HealthFailures  int             `json:"healthFailures"`
+		NextRestartAt   time.Time       `json:"nextRestartAt,omitempty"`
+	}
+
+	// Supervisor runs child process and keeps it alive.
+	Supervisor struct {
+		conf         SupervisorConfig
+		mu           sync.Mutex
+		status       SupervisorStatus
+		cmd          *exec.Cmd
+		restartTimes []time.Time
+		running      bool
+		stopCh       chan struct{}
+		stopOnce     sync.Once
+	}
+)
+
+const (
+	RestartAlways    = RestartPolicy("always")
+	RestartOnFailure = RestartPolicy("on-failure")
+	RestartNever     = RestartPolicy("never")
+
+	StateIdle     = SupervisorState("idle")
+	StateRunning  = SupervisorState("running")
+	StateBackoff  = SupervisorState("backoff")
+	StateStopping = SupervisorState("stopping")
+	StateStopped  = SupervisorState("stopped")
+	StateExited   = SupervisorState("exited")
+	StateFailed   = SupervisorState("failed")
+)
+
+var ErrTooManyRestarts = gerrors.New("too many restarts")
+
+// NewSupervisor verifies config and fills defaults.
+func NewSupervisor(conf SupervisorConfig) (*Supervisor, error) {
+	if conf.Name == "" {
+		return nil, gerrors.New("empty command name")
+	}
+	switch conf.Policy {
+	case "":
+		conf.Policy = RestartOnFailure
+	case RestartAlways, RestartOnFailure, RestartNever:
+	default:
+		return nil, gerrors.New("unknown restart policy %s", conf.Policy)
+	}
+	if conf.MaxRestarts < 0 || conf.HealthFailures < 0 {
+		return nil, gerrors.New("negative MaxRestarts or HealthFailures")
+	}
+	if conf.MinBackoff <= 0 {
+		conf.MinBackoff = time.Second
+	}
+	if conf.MaxBackoff <= 0 {
+		conf.MaxBackoff = time.Minute
+	}
+	if conf.MaxBackoff < conf.MinBackoff {
+		conf.MaxBackoff = conf.MinBackoff
+	}
+	if conf.ResetBackoffAfter <= 0 {
+		conf.ResetBackoffAfter = time.Minute
+	}
+	if conf.HealthInterval <= 0 {
+		conf.HealthInterval = 10 * time.Second
+	}
+	if conf.HealthTimeout <= 0 {
+		conf.HealthTimeout = 5 * time.Second
+	}
+	if conf.HealthGrace <= 0 {
+		conf.HealthGrace = conf.HealthInterval
+	}
+	if conf.HealthFailures == 0 {
+		conf.HealthFailures = 3
+	}
+	if conf.StopSignal == nil {
+		conf.StopSignal = syscall.SIGTERM
+	}
+	if conf.StopTimeout <= 0 {
+		conf.StopTimeout = 10 * time.Second
+	}
+	if conf.ForwardSignals == nil {
+		conf.ForwardSignals = []os.Signal{syscall.SIGHUP}
+	}
+	if conf.StopSignals == nil {
+		conf.StopSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return &Supervisor{conf: conf, status: SupervisorStatus{State: StateIdle}, stopCh: make(chan struct{})}, nil
+}
+
+// Status returns snapshot of current status.
+func (s *Supervisor) Status() SupervisorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// ServeHTTP writes status as JSON, so supervisor can be mounted to any http server.
+func (s *Supervisor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b, err := json.Marshal(s.Status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// Signal sends signal to running child.
+func (s *Supervisor) Signal(sig os.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cmd == nil || s.cmd.Process == nil {
+		return gerrors.New("child process not running")
+	}
+	return s.cmd.Process.Signal(sig)
+}
+
+// Stop stops child gracefully and makes Run return, supervisor can't be reused after Stop.
+func (s *Supervisor) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+func (s *Supervisor) setState(state SupervisorState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.State = state
+}
+
+func containsSignal(sigs []os.Signal, sig os.Signal) bool {
+	for _, v := range sigs {
+		if v == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// Run starts child and restarts it according to policy, it blocks until ctx done, Stop called,
+// stop signal caught, child exits without need of restart, or restart limit exceeded.
+// Error is returned if child failed and won't be restarted.
+func (s *Supervisor) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return gerrors.New("supervisor already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	if !s.conf.DisableSignals {
+		sigs := append(append([]os.Signal{}, s.conf.ForwardSignals...), s.conf.StopSignals...)
+		if len(sigs) > 0 {
+			stopRelay := gsignal.Relay(func(sig os.Signal) {
+				if containsSignal(s.conf.StopSignals, sig) {
+					s.Stop()
+				} else {
+					_ = s.Signal(sig)
+				}
+			}, sigs...)
+			defer stopRelay()
+		}
+	}
+
+	backoff := s.conf.MinBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			s.setState(StateStopped)
+			return nil
+		case <-s.stopCh:
+			s.setState(StateStopped)
+			return nil
+		default:
+		}
+
+		startedAt := time.Now()
+		exitCode, unhealthy, stopped, err := s.runOnce(ctx)
+
+		s.mu.Lock()
+		s.status.LastExitAt = time.Now()
+		s.status.LastExitCode = exitCode
+		s.status.LastError = ""
+		if err != nil {
+			s.status.LastError = err.Error()
+		}
+		s.mu.Unlock()
+
+		if stopped {
+			s.setState(StateStopped)
+			return nil
+		}
+		if s.conf.Policy == RestartNever || (s.conf.Policy == RestartOnFailure && exitCode == 0 && err == nil && !unhealthy) {
+			s.setState(StateExited)
+			if err == nil && exitCode != 0 {
+				err = gerrors.New("child exited with code %d", exitCode)
+			}
+			return err
+		}
+
+		// Check restart limit.
+		now := time.Now()
+		s.mu.Lock()
+		if s.conf.RestartWindow > 0 {
+			kept := s.restartTimes[:0]
+			for _, t := range s.restartTimes {
+				if now.Sub(t) < s.conf.RestartWindow {
+					kept = append(kept, t)
+				}
+			}
+			s.restartTimes = kept
+		}
+		if s.conf.MaxRestarts > 0 && len(s.restartTimes) >= s.conf.MaxRestarts {
+			s.status.State = StateFailed
+			s.mu.Unlock()
+			return gerrors.Wrap(ErrTooManyRestarts, strconv.Itoa(s.conf.MaxRestarts)+" restarts in "+s.conf.RestartWindow.String())
+		}
+		s.restartTimes = append(s.restartTimes, now)
+
+		// Exponential backoff, reset if child has been stable for a while.
+		if now.Sub(startedAt) >= s.conf.ResetBackoffAfter {
+			backoff = s.conf.MinBackoff
+		}
+		s.status.State = StateBackoff
+		s.status.NextRestartAt = now.Add(backoff)
+		s.mu.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			s.setState(StateStopped)
+			return nil
+		case <-s.stopCh:
+			s.setState(StateStopped)
+			return nil
+		}
+		s.mu.Lock()
+		s.status.Restarts++
+		s.status.NextRestartAt = time.Time{}
+		s.mu.Unlock()
+		if backoff *= 2; backoff > s.conf.MaxBackoff {
+			backoff = s.conf.MaxBackoff
+		}
+	}
+}
+
+// runOnce runs child until it exits, becomes unhealthy or supervisor stops.
+func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, unhealthy, stopped bool, err error) {
+	cmd := exec.Command(s.conf.Name, s.conf.Args...)
+	cmd.Dir = s.conf.Dir
+	if len(s.conf.Env) > 0 {
+		cmd.Env = append(os.Environ(), s.conf.Env...)
+	}
+	cmd.Stdout = s.conf.Stdout
+	cmd.Stderr = s.conf.Stderr
+	if err := cmd.Start(); err != nil {
+		return -1, false, false, err
+	}
+
+	s.mu.Lock()
+	s.cmd = cmd
+	s.status.State = StateRunning
+	s.status.Pid = cmd.Process.Pid
+	s.status.StartedAt = time.Now()
+	s.status.Healthy = s.conf.HealthCheck == nil
+	s.status.HealthFailures = 0
+	s.mu.Unlock()
+	if s.conf.PidFile != "" {
+		if err := os.WriteFile(s.conf.PidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			s.clearCmd()
+			return -1, false, false, gerrors.Wrap(err, "write pid file")
+		}
+	}
+	defer s.clearCmd()
+
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- cmd.Wait() }()
+
+	healthCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	unhealthyCh := make(chan error, 1)
+	if s.conf.HealthCheck != nil {
+		go s.healthLoop(healthCtx, unhealthyCh)
+	}
+
+	select {
+	case err = <-waitCh:
+	case err = <-unhealthyCh:
+		unhealthy = true
+		s.terminate(cmd, waitCh)
+	case <-ctx.Done():
+		stopped = true
+		s.terminate(cmd, waitCh)
+	case <-s.stopCh:
+		stopped = true
+		s.terminate(cmd, waitCh)
+	}
+	exitCode = cmd.ProcessState.ExitCode()
+	if _, ok := err.(*exec.ExitError); ok {
+		// Exit code is enough.
+		err = nil
+	}
+	return exitCode, unhealthy, stopped, err
+}
+
+func (s *Supervisor) clearCmd() {
+	s.mu.Lock()
+	s.cmd = nil
+	s.status.Pid = 0
+	s.status.Healthy = false
+	s.mu.Unlock()
+	if s.conf.PidFile != "" {
+		_ = os.Remove(s.conf.PidFile)
+	}
+}
+
+// terminate sends stop signal to child, and kills it after StopTimeout.
+func (s *Supervisor) terminate(cmd *exec.Cmd, waitCh <-chan error) {
+	s.setState(StateStopping)
+	if err := cmd.Process.Signal(s.conf.StopSignal); err != nil {
+		// Windows doesn't support signals except kill.
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-waitCh:
+	case <-time.After(s.conf.StopTimeout):
+		_ = cmd.Process.Kill()
+		<-waitCh
+	}
+}
+
+func (s *Supervisor) healthLoop(ctx context.Context, unhealthyCh chan<- error) {
+	timer := time.NewTimer(s.conf.HealthGrace)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, s.conf.HealthTimeout)
+		err := s.conf.HealthCheck.Check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.status.LastHealthCheck = time.Now()
+		if err == nil {
+			s.status.Healthy = true
+			s.status.HealthFailures = 0
+		} else {
+			s.status.Healthy = false
+			s.status.HealthFailures++
+		}
+		failures := s.status.HealthFailures
+		s.mu.Unlock()
+		if failures >= s.conf.HealthFailures {
+			unhealthyCh <- gerrors.Wrap(err, "health check failed "+strconv.Itoa(failures)+" times")
+			return
+		}
+		timer.Reset(s.conf.HealthInterval)
+	}
+}
@@ -0,0 +1,124 @@
+package gdaemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSupervisor_Restart(t *testing.T) {
+	s, err := NewSupervisor(SupervisorConfig{
+		Name:           "sh",
+		Args:           []string{"-c", "exit 3"},
+		MinBackoff:     10 * time.Millisecond,
+		MaxBackoff:     40 * time.Millisecond,
+		MaxRestarts:    3,
+		RestartWindow:  time.Minute,
+		DisableSignals: true,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Run(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) {
+		t.Errorf("expect ErrTooManyRestarts, got %v", err)
+		return
+	}
+	st := s.Status()
+	if st.State != StateFailed || st.Restarts != 3 || st.LastExitCode != 3 {
+		t.Errorf("unexpected status %+v", st)
+		return
+	}
+
+	// Successful exit is not restarted by on-failure policy.
+	s, _ = NewSupervisor(SupervisorConfig{Name: "sh", Args: []string{"-c", "exit 0"}, DisableSignals: true})
+	if err := s.Run(context.Background()); err != nil || s.Status().State != StateExited || s.Status().Restarts != 0 {
+		t.Errorf("unexpected result %v %+v", err, s.Status())
+	}
+}
+
+func TestSupervisor_HealthAndStop(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	s, _ := NewSupervisor(SupervisorConfig{
+		Name:           "sleep",
+		Args:           []string{"10"},
+		PidFile:        pidFile,
+		Policy:         RestartNever,
+		HealthCheck:    HealthCheckFunc(func(ctx context.Context) error { return errors.New("boom") }),
+		HealthInterval: 10 * time.Millisecond,
+		HealthFailures: 2,
+		DisableSignals: true,
+	})
+	if err := s.Run(context.Background()); err == nil {
+		t.Error("unhealthy child should be reported")
+		return
+	}
+	if st := s.Status(); !strings.Contains(st.LastError, "health check failed 2 times") || st.Pid != 0 {
+		t.Errorf("unexpected status %+v", st)
+		return
+	}
+
+	s, _ = NewSupervisor(SupervisorConfig{Name: "sleep", Args: []string{"10"}, PidFile: pidFile, DisableSignals: true})
+	done := make(chan error)
+	go func() { done <- s.Run(context.Background()) }()
+	for i := 0; i < 100 && s.Status().State != StateRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	b, err := os.ReadFile(pidFile)
+	if err != nil || string(b) != strconv.Itoa(s.Status().Pid) {
+		t.Errorf("invalid pid file %s %v", b, err)
+		return
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), `"state":"running"`) {
+		t.Errorf("invalid status json %s", rec.Body.String())
+		return
+	}
+	s.Stop()
+	select {
+	case err := <-done:
+		if err != nil || s.Status().State != StateStopped {
+			t.Errorf("unexpected stop result %v %+v", err, s.Status())
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("stop timeout")
+		return
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Error("pid file should be removed")
+	}
+}
+
+func TestHealthCheckers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := (HTTPCheck{URL: srv.URL + "/ok"}).Check(ctx); err != nil {
+		t.Error(err)
+	}
+	if err := (HTTPCheck{URL: srv.URL + "/bad"}).Check(ctx); err == nil {
+		t.Error("http check should fail")
+	}
+	if err := (TCPCheck{Addr: strings.TrimPrefix(srv.URL, "http://")}).Check(ctx); err != nil {
+		t.Error(err)
+	}
+	if err := (CommandCheck{Name: "sh", Args: []string{"-c", "exit 1"}}).Check(ctx); err == nil {
+		t.Error("command check should fail")
+	}
+}
@@ -0,0 +1,284 @@
+package gerrors
+
+import (
+	"context"
+	stdErr "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type (
+	// Category is gRPC-like classification of errors.
+	Category string
+
+	// Code is a registered error code, it can be matched with errors.Is(err, code).
+	Code struct {
+		Code       string
+		Category   Category
+		HTTPStatus int
+		Retryable  bool
+	}
+)
+
+// Categories, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	CategoryCanceled           = Category("Canceled")
+	CategoryUnknown            = Category("Unknown")
+	CategoryInvalidArgument    = Category("InvalidArgument")
+	CategoryDeadlineExceeded   = Category("DeadlineExceeded")
+	CategoryNotFound           = Category("NotFound")
+	CategoryAlreadyExists      = Category("AlreadyExists")
+	CategoryPermissionDenied   = Category("PermissionDenied")
+	CategoryResourceExhausted  = Category("ResourceExhausted")
+	CategoryFailedPrecondition = Category("FailedPrecondition")
+	CategoryAborted            = Category("Aborted")
+	CategoryOutOfRange         = Category("OutOfRange")
+	CategoryUnimplemented      = Category("Unimplemented")
+	CategoryInternal           = Category("Internal")
+	CategoryUnavailable        = Category("Unavailable")
+	CategoryDataLoss           = Category("DataLoss")
+	CategoryUnauthenticated    = Category("Unauthenticated")
+)
+
+var (
+	codeRegistry   = map[string]*Code{}
+	codeRegistryMu sync.RWMutex
+
+	CodeUnknown            = RegisterCode("UNKNOWN", CategoryUnknown, 0, false)
+	CodeCanceled           = RegisterCode("CANCELED", CategoryCanceled, 0, false)
+	CodeInvalidArgument    = RegisterCode("INVALID_ARGUMENT", CategoryInvalidArgument, 0, false)
+	CodeDeadlineExceeded   = RegisterCode("DEADLINE_EXCEEDED", CategoryDeadlineExceeded, 0, true)
+	CodeNotFound           = RegisterCode("NOT_FOUND", CategoryNotFound, 0, false)
+	CodeAlreadyExists      = RegisterCode("ALREADY_EXISTS", CategoryAlreadyExists, 0, false)
+	CodePermissionDenied   = RegisterCode("PERMISSION_DENIED", CategoryPermissionDenied, 0, false)
+	CodeResourceExhausted  = RegisterCode("RESOURCE_EXHAUSTED", CategoryResourceExhausted, 0, true)
+	CodeFailedPrecondition = RegisterCode("FAILED_PRECONDITION", CategoryFailedPrecondition, 0, false)
+	CodeAborted            = RegisterCode("ABORTED", CategoryAborted, 0, true)
+	CodeOutOfRange         = RegisterCode("OUT_OF_RANGE", CategoryOutOfRange, 0, false)
+	CodeUnimplemented      = RegisterCode("UNIMPLEMENTED", CategoryUnimplemented, 0, false)
+	CodeInternal           = RegisterCode("INTERNAL", CategoryInternal, 0, false)
+	CodeUnavailable        = RegisterCode("UNAVAILABLE", CategoryUnavailable, 0, true)
+	CodeDataLoss           = RegisterCode("DATA_LOSS", CategoryDataLoss, 0, false)
+	CodeUnauthenticated    = RegisterCode("UNAUTHENTICATED", CategoryUnauthenticated, 0, false)
+
+	// Codes of sentinel errors and standard errors which are not GErr, earlier one wins if an error matches many.
+	sentinelCodes = []struct {
+		err  error
+		code *Code
+	}{
+		{ErrNotExist, CodeNotFound},
+		{ErrNotFound, CodeNotFound},
+		{ErrAlreadyExist, CodeAlreadyExists},
+		{ErrNotSupport, CodeUnimplemented},
+		{ErrNotImplemented, CodeUnimplemented},
+		{ErrTimeout, CodeDeadlineExceeded},
+		{context.Canceled, CodeCanceled},
+		{context.DeadlineExceeded, CodeDeadlineExceeded},
+	}
+)
+
+// HTTPStatus returns default HTTP status code of category.
+func (c Category) HTTPStatus() int {
+	switch c {
+	case CategoryCanceled:
+		return 499 // Client Closed Request
+	case CategoryInvalidArgument, CategoryOutOfRange:
+		return http.StatusBadRequest
+	case CategoryDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CategoryNotFound:
+		return http.StatusNotFound
+	case CategoryAlreadyExists, CategoryAborted:
+		return http.StatusConflict
+	case CategoryPermissionDenied:
+		return http.StatusForbidden
+	case CategoryResourceExhausted:
+		return http.StatusTooManyRequests
+	case CategoryFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case CategoryUnimplemented:
+		return http.StatusNotImplemented
+	case CategoryUnavailable:
+		return http.StatusServiceUnavailable
+	case CategoryUnauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// RegisterCode registers a new error code, httpStatus 0 means default status of category.
+// It panics if code already registered, so it should be called in package level var declaration or init.
+func RegisterCode(code string, category Category, httpStatus int, retryable bool) *Code {
+	if httpStatus == 0 {
+		httpStatus = category.HTTPStatus()
+	}
+	c := &Code{Code: code, Category: category, HTTPStatus: httpStatus, Retryable: retryable}
+
+	codeRegistryMu.Lock()
+	defer codeRegistryMu.Unlock()
+	if _, ok := codeRegistry[code]; ok {
+		panic(fmt.Sprintf("error code %s already registered", code))
+	}
+	codeRegistry[code] = c
+	return c
+}
+
+// LookupCode finds registered code.
+func LookupCode(code string) (*Code, bool) {
+	codeRegistryMu.RLock()
+	defer codeRegistryMu.RUnlock()
+	c, ok := codeRegistry[code]
+	return c, ok
+}
+
+// RegisteredCodes lists all registered codes sorted by code.
+func RegisteredCodes() []*Code {
+	codeRegistryMu.RLock()
+	defer codeRegistryMu.RUnlock()
+	var res []*Code
+	for _, v := range codeRegistry {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Code < res[j].Code })
+	return res
+}
+
+// Error implements error interface, so code can be used as errors.Is target.
+func (c *Code) Error() string {
+	return c.Code
+}
+
+// New creates error with this code.
+func (c *Code) New(format string, args ...interface{}) gerror {
+	res := New(format, args...).(*GErr)
+	res.code = c
+	return res
+}
+
+// Wrap wraps error with this code.
+func (c *Code) Wrap(err error, message string) gerror {
+	if err == nil {
+		return nil
+	}
+	res := Wrap(err, message).(*GErr)
+	res.code = c
+	return res
+}
+
+// CodeOf returns code of the outermost error in chain which has one, CodeUnknown if no code found, nil if err is nil.
+// Chain is walked in the order of errors.Is, joined errors are visited one by one, so the first one with code wins.
+func CodeOf(err error) *Code {
+	if err == nil {
+		return nil
+	}
+	if c := chainCode(err); c != nil {
+		return c
+	}
+	return CodeUnknown
+}
+
+// chainCode returns code of err itself, or the first one found in errors it wraps.
+func chainCode(err error) *Code {
+	if err == nil {
+		return nil
+	}
+	if ge, ok := err.(gerror); ok {
+		if c := ge.Details().code; c != nil {
+			return c
+		}
+	}
+	comparable := reflect.TypeOf(err).Comparable()
+	isFn, hasIs := err.(interface{ Is(error) bool })
+	for _, sc := range sentinelCodes {
+		if (comparable && err == sc.err) || (hasIs && isFn.Is(sc.err)) {
+			return sc.code
+		}
+	}
+	switch v := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range v.Unwrap() {
+			if c := chainCode(inner); c != nil {
+				return c
+			}
+		}
+		return nil
+	case interface{ Unwrap() error }:
+		return chainCode(v.Unwrap())
+	}
+	return nil
+}
+
+// HTTPStatus returns HTTP status code of error, 200 if err is nil.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return CodeOf(err).HTTPStatus
+}
+
+// IsRetryable reports whether the operation failed with err is worth retrying.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if CodeOf(err).Retryable {
+		return true
+	}
+	return IsTemporary(err)
+}
+
+// IsTemporary reports whether err or any error it wraps is temporary or timeout, like net.Error.
+func IsTemporary(err error) bool {
+	var te interface{ Temporary() bool }
+	if stdErr.As(err, &te) && te.Temporary() {
+		return true
+	}
+	var to interface{ Timeout() bool }
+	return stdErr.As(err, &to) && to.Timeout()
+}
+
+// IsFatal reports whether any GErr in chain is fatal.
+func IsFatal(err error) bool {
+	var ge gerror
+	for e := err; e != nil && stdErr.As(e, &ge); e = stdErr.Unwrap(ge) {
+		if ge.Details().IsFatal {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDetails merges key/value details of all GErr in chain, outer one wins.
+func GetDetails(err error) map[string]interface{} {
+	res := map[string]interface{}{}
+	var walk func(e error)
+	walk = func(e error) {
+		if e == nil {
+			return
+		}
+		switch v := e.(type) {
+		case *JoinedErr:
+			for k, val := range v.KVs {
+				if _, ok := res[k]; !ok {
+					res[k] = val
+				}
+			}
+			for _, inner := range v.errs {
+				walk(inner)
+			}
+			return
+		case *GErr:
+			for k, val := range v.KVs {
+				if _, ok := res[k]; !ok {
+					res[k] = val
+				}
+			}
+		}
+		walk(stdErr.Unwrap(e))
+	}
+	walk(err)
+	return res
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	extErr "github.com/go-errors/errors"
 	pkgErr "github.com/pkg/errors"
-	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -21,6 +20,16 @@ type (
 		Num     string
 		Msg     string
 		Stack   string
+		KVs     map[string]interface{}
+
+		code  *Code
+		cause error
+	}
+
+	// JoinedErr is returned by Join if more than one error joined.
+	JoinedErr struct {
+		*GErr
+		errs []error
 	}
 
 	// gerror interface
@@ -29,6 +38,8 @@ type (
 		Details() GErr
 		SetErrNum(errNum error)
 		SetFatal()
+		WithDetail(key string, val interface{}) gerror
+		GetCode() *Code
 	}
 )
 
@@ -40,7 +51,7 @@ var (
 	ErrNotFound       = stdErr.New("not found") // This is not a really run error, it means Database/Collection not exist in mongodb.
 	ErrNotSupport     = stdErr.New("not support")
 	ErrNotImplemented = stdErr.New("not implemented")
-	ErrTimeout		  = stdErr.New("timeout")
+	ErrTimeout        = stdErr.New("timeout")
 )
 
 // Implements error interface, stack will not display in output.
@@ -71,8 +82,58 @@ func (ge *GErr) SetFatal() {
 	ge.IsFatal = true
 }
 
+// WithDetail attaches key/value detail, it implements gerror interface.
+func (ge *GErr) WithDetail(key string, val interface{}) gerror {
+	if ge.KVs == nil {
+		ge.KVs = map[string]interface{}{}
+	}
+	ge.KVs[key] = val
+	return ge
+}
+
+// GetCode returns registered code of this error or the nearest wrapped one, nil if no code.
+// It implements gerror interface.
+func (ge *GErr) GetCode() *Code {
+	if ge.code != nil {
+		return ge.code
+	}
+	var inner gerror
+	if ge.cause != nil && stdErr.As(ge.cause, &inner) {
+		return inner.GetCode()
+	}
+	return nil
+}
+
+// Unwrap returns wrapped error, so errors.Is/As work across Wrap chains.
+func (ge *GErr) Unwrap() error {
+	return ge.cause
+}
+
+// Is reports whether error has code `target`, so errors.Is(err, CodeNotFound) works.
+func (ge *GErr) Is(target error) bool {
+	c, ok := target.(*Code)
+	return ok && ge.code == c
+}
+
+// Unwrap returns all joined errors, so errors.Is/As can match any of them.
+func (je *JoinedErr) Unwrap() []error {
+	return je.errs
+}
+
+// WithDetail attaches key/value detail, it implements gerror interface.
+func (je *JoinedErr) WithDetail(key string, val interface{}) gerror {
+	je.GErr.WithDetail(key, val)
+	return je
+}
+
+// Errors returns all joined errors.
+func (je *JoinedErr) Errors() []error {
+	return append([]error{}, je.errs...)
+}
+
 func IsGerror(err error) bool {
-	return strings.Contains(itfType(err), "GErr")
+	_, ok := err.(gerror)
+	return ok
 }
 
 // New error.
@@ -101,39 +162,57 @@ func Errorf(format string, args ...interface{}) gerror {
 	return New(format, args...)
 }
 
-// Wrap error to GErr.
+// Wrap error to GErr, wrapped error can be matched by errors.Is/As.
 func Wrap(err error, message string) gerror {
 	if err == nil {
 		return nil
 	}
 
-	if IsGerror(err) {
-		return err.(gerror)
+	if ge, ok := err.(gerror); ok {
+		d := ge.Details()
+		msg := d.Msg
+		if je, ok := err.(*JoinedErr); ok {
+			msg = je.Error()
+		}
+		if message != "" {
+			msg = message + ": " + msg
+		}
+		return &GErr{
+			IsFatal: d.IsFatal,
+			Num:     d.Num,
+			Msg:     msg,
+			Stack:   d.Stack,
+			cause:   err,
+		}
 	} else {
 		return &GErr{
 			IsFatal: false,
 			Num:     "",
 			Msg:     pkgErr.Wrap(err, message).Error(),
 			Stack:   GetStack(err),
+			cause:   err,
 		}
 	}
 }
 
-// Join combine multiple errors to one error.
+// Join combine multiple errors to one error, joined errors can be matched by errors.Is/As.
 func Join(err error, errs ...error) error {
 	errCount := 0
 	errJoin := ""
 	errLast := error(nil)
+	var joined []error
 	if err != nil {
 		errCount++
-		errJoin += New("error[%d]:%s;", errCount, err.Error()).Error()
+		errJoin += fmt.Sprintf("error[%d]:%s;", errCount, err.Error())
 		errLast = err
+		joined = append(joined, err)
 	}
 	for _, v := range errs {
 		if v != nil {
 			errCount++
-			errJoin += New("error[%d]:%s;", errCount, v.Error()).Error()
+			errJoin += fmt.Sprintf("error[%d]:%s;", errCount, v.Error())
 			errLast = v
+			joined = append(joined, v)
 		}
 	}
 
@@ -143,7 +222,13 @@ func Join(err error, errs ...error) error {
 	if errCount == 1 {
 		return errLast
 	}
-	return New(errJoin)
+	return &JoinedErr{
+		GErr: &GErr{
+			Msg:   errJoin,
+			Stack: extErr.Errorf("%s", errJoin).ErrorStack(),
+		},
+		errs: joined,
+	}
 }
 
 func JoinArray(errs []error) error {
@@ -185,20 +270,6 @@ func endWith(s, toFind string) bool {
 	return pos >= 0 && pos == len(s)-len(toFind)
 }
 
-func itfType(x interface{}) string {
-	if x == nil {
-		return "nil"
-	}
-	return reflect.TypeOf(x).String()
-}
-
-func interfaceType(x interface{}) string {
-	if x == nil {
-		return "nil"
-	}
-	return reflect.TypeOf(x).String()
-}
-
 // Get stack for 3 types of error.
 func GetStack(err error) string {
 	if err == nil {
@@ -207,9 +278,9 @@ func GetStack(err error) string {
 	stack := ""
 
 	// GErr
-	if strings.Contains(interfaceType(err), "GErr") {
+	if ge, ok := err.(gerror); ok {
 		// Stack has been generated.
-		return err.(*GErr).Stack
+		return ge.Details().Stack
 	}
 
 	// pkg/errors
@@ -1,8 +1,12 @@
 package gerrors
 
 import (
+	"encoding/json"
+	stdErr "errors"
+	"fmt"
 	"github.com/cryptowilliam/goutil/basic/gtest"
 	pkgErr "github.com/pkg/errors"
+	"net/http"
 	"strings"
 	"testing"
 )
@@ -77,3 +81,57 @@ func TestJoin(t *testing.T) {
 		}
 	}
 }
+
+func TestChain(t *testing.T) {
+	codeQuota := RegisterCode("TEST_QUOTA", CategoryResourceExhausted, 0, true)
+	base := codeQuota.New("quota of %s exceeded", "user1").WithDetail("user", "user1")
+	wrapped := Wrap(Wrap(base, "call api"), "handle request")
+	if !stdErr.Is(wrapped, codeQuota) || !stdErr.Is(wrapped, base) {
+		t.Errorf("errors.Is failed on wrapped error")
+		return
+	}
+	if CodeOf(wrapped) != codeQuota || HTTPStatus(wrapped) != http.StatusTooManyRequests || !IsRetryable(wrapped) {
+		t.Errorf("code of wrapped error lost")
+		return
+	}
+	if wrapped.Error() != "handle request: call api: quota of user1 exceeded" {
+		t.Errorf("unexpected message %s", wrapped.Error())
+		return
+	}
+
+	joined := Join(New("err1"), Wrap(ErrNotExist, "load"))
+	var ge *GErr
+	if !stdErr.Is(joined, ErrNotExist) || !stdErr.As(joined, &ge) || CodeOf(Wrap(joined, "")) != CodeNotFound {
+		t.Errorf("errors.Is/As failed on joined error")
+		return
+	}
+	// Code of joined errors follows chain order, not order of sentinels.
+	for i := 0; i < 10; i++ {
+		if c := CodeOf(Join(ErrTimeout, ErrNotExist)); c != CodeDeadlineExceeded {
+			t.Errorf("code of joined errors is %s", c.Code)
+			return
+		}
+		if c := CodeOf(Wrap(Join(fmt.Errorf("read: %w", ErrNotExist), base), "")); c != CodeNotFound {
+			t.Errorf("code of joined errors is %s", c.Code)
+			return
+		}
+	}
+	if c, ok := LookupCode("TEST_QUOTA"); !ok || c != codeQuota {
+		t.Errorf("LookupCode failed")
+		return
+	}
+
+	b, err := json.Marshal(Wrap(Join(wrapped, ErrTimeout), "batch"))
+	gtest.Assert(t, err)
+	p := ErrPayload{}
+	gtest.Assert(t, json.Unmarshal(b, &p))
+	if p.Cause == nil || len(p.Cause.Errors) != 2 || p.Cause.Errors[0].Code != "TEST_QUOTA" ||
+		p.Cause.Errors[0].Cause.Cause.Details["user"] != "user1" || p.Cause.Errors[1].Code != "DEADLINE_EXCEEDED" || p.Stack == "" {
+		t.Errorf("unexpected payload %s", string(b))
+	}
+	remote := FromPayload(&p)
+	if remote.Error() != "batch: "+Join(wrapped, ErrTimeout).Error() || !stdErr.Is(remote, codeQuota) ||
+		!stdErr.Is(remote, CodeDeadlineExceeded) || GetDetails(remote)["user"] != "user1" || GetStack(remote) == "" {
+		t.Errorf("unexpected error %v from payload", remote)
+	}
+}
@@ -0,0 +1,83 @@
+package gerrors
+
+import (
+	"encoding/json"
+	stdErr "errors"
+)
+
+type (
+	// ErrPayload is JSON representation of an error chain, it is designed to be returned by APIs.
+	ErrPayload struct {
+		Code       string                 `json:"Code,omitempty"`
+		Category   Category               `json:"Category,omitempty"`
+		HTTPStatus int                    `json:"HTTPStatus,omitempty"`
+		Num        string                 `json:"Num,omitempty"`
+		Msg        string                 `json:"Msg"`
+		IsFatal    bool                   `json:"IsFatal,omitempty"`
+		Details    map[string]interface{} `json:"Details,omitempty"`
+		Stack      string                 `json:"Stack,omitempty"`
+		Cause      *ErrPayload            `json:"Cause,omitempty"`
+		Errors     []*ErrPayload          `json:"Errors,omitempty"`
+	}
+)
+
+// ToPayload converts error chain into payload, stacks are included only if withStack is true.
+func ToPayload(err error, withStack bool) *ErrPayload {
+	if err == nil {
+		return nil
+	}
+
+	res := &ErrPayload{Msg: err.Error()}
+	if withStack {
+		res.Stack = GetStack(err)
+	}
+	c := CodeOf(err)
+	res.Code, res.Category, res.HTTPStatus = c.Code, c.Category, c.HTTPStatus
+
+	switch v := err.(type) {
+	case *JoinedErr:
+		res.Num, res.IsFatal, res.Details = v.Num, v.IsFatal, v.KVs
+		for _, inner := range v.errs {
+			res.Errors = append(res.Errors, ToPayload(inner, withStack))
+		}
+		return res
+	case *GErr:
+		res.Num, res.Msg, res.IsFatal, res.Details = v.Num, v.Msg, v.IsFatal, v.KVs
+	}
+	res.Cause = ToPayload(stdErr.Unwrap(err), withStack)
+	return res
+}
+
+// MarshalJSON marshals the full error chain with stacks.
+func (ge *GErr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ToPayload(ge, true))
+}
+
+// MarshalJSON marshals the full error chain with stacks.
+func (je *JoinedErr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ToPayload(je, true))
+}
+
+// FromPayload rebuilds error chain from payload, like error returned by remote service.
+// Registered codes are restored, so CodeOf and errors.Is(err, code) work, but sentinel errors are not.
+func FromPayload(p *ErrPayload) error {
+	if p == nil {
+		return nil
+	}
+
+	ge := &GErr{IsFatal: p.IsFatal, Num: p.Num, Msg: p.Msg, Stack: p.Stack, KVs: p.Details}
+	if c, ok := LookupCode(p.Code); ok && c != CodeUnknown {
+		ge.code = c
+	}
+	if len(p.Errors) > 0 {
+		je := &JoinedErr{GErr: ge}
+		for _, inner := range p.Errors {
+			je.errs = append(je.errs, FromPayload(inner))
+		}
+		return je
+	}
+	if p.Cause != nil {
+		ge.cause = FromPayload(p.Cause)
+	}
+	return ge
+}
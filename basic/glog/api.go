@@ -17,12 +17,10 @@ func Init(saveDisk bool) {
 	conf, err := DefaultConfig()
 	if err != nil {
 		panic(err)
-		return
 	}
 	conf.SaveDisk = saveDisk
 	if err := DefaultLogger.Init(conf); err != nil {
 		panic(err)
-		return
 	}
 	initialized.Store(true)
 }
@@ -35,6 +33,26 @@ func SetClock(c gtime.Clock) {
 	DefaultLogger.SetClock(c)
 }
 
+func SetLevel(level Level) {
+	DefaultLogger.SetLevel(level)
+}
+
+func GetLevel() Level {
+	return DefaultLogger.GetLevel()
+}
+
+func AddSink(s Sink) {
+	DefaultLogger.AddSink(s)
+}
+
+func SetSinks(sinks ...Sink) {
+	DefaultLogger.SetSinks(sinks...)
+}
+
+func With(fields Fields) Interface {
+	return DefaultLogger.With(fields)
+}
+
 func Debgf(format string, a ...interface{}) {
 	DefaultLogger.Debgf(format, a...)
 }
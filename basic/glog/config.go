@@ -2,10 +2,14 @@ package glog
 
 import (
 	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/compress/gcompress"
+	"github.com/cryptowilliam/goutil/container/gvolume"
 	"github.com/cryptowilliam/goutil/sys/gmachineid"
 	"github.com/cryptowilliam/goutil/sys/gproc"
 	"github.com/cryptowilliam/goutil/sys/gsysinfo"
 	"path"
+	"time"
 )
 
 type (
@@ -15,11 +19,48 @@ type (
 		FileNameFormat string
 		PrintScreen    bool
 
+		// Minimum level to output, it can be changed at runtime by SetLevel.
+		Level Level
+		// Encoding of disk and screen output, text/json/logfmt.
+		Encoding Encoding
+
+		// Rotation of disk log files.
+		Rotate RotateConfig
+
 		MachId  string
 		AppName string
 	}
+
+	// RotateConfig controls rotation and retention of log files, zero value disables each limit.
+	RotateConfig struct {
+		// Rotate current file once its size exceeds MaxFileSize.
+		MaxFileSize gvolume.Volume
+		// Max count of rotated files to keep, current file excluded.
+		MaxFiles int
+		// Rotated files older than MaxAge are removed.
+		MaxAge time.Duration
+		// Compress rotated files, gzip or zstd recommended, empty or none disables compression.
+		Compress gcompress.Comp
+	}
 )
 
+func (rc RotateConfig) Verify() error {
+	if rc.MaxFiles < 0 {
+		return gerrors.New("invalid MaxFiles %d", rc.MaxFiles)
+	}
+	if rc.MaxAge < 0 {
+		return gerrors.New("invalid MaxAge %s", rc.MaxAge.String())
+	}
+	if rc.Compress == "" || rc.Compress == gcompress.CompNone {
+		return nil
+	}
+	if rc.Compress == gcompress.CompZip {
+		return gerrors.New("zip is not supported for log compression")
+	}
+	_, err := gcompress.ToComp(string(rc.Compress))
+	return err
+}
+
 func DefaultConfig() (*Config, error) {
 	res := &Config{}
 
@@ -27,6 +68,10 @@ func DefaultConfig() (*Config, error) {
 	res.FileNameFormat = "2006-01-02.log" // YEAR-MONTH-DAY.log
 	res.SaveDisk = true
 	res.PrintScreen = true
+	res.Level = LevelDebg
+	res.Encoding = EncodingText
+	// Retention and compression remove or change files, so they are left to user.
+	res.Rotate = RotateConfig{MaxFileSize: 256 * gvolume.MB}
 	// Get default logs directory
 	pi, err := gproc.GetProcInfo(gproc.GetPidOfMyself())
 	if err != nil {
@@ -0,0 +1,125 @@
+package glog
+
+import (
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Encoder serializes a log item into one line, without the trailing newline.
+	Encoder interface {
+		Encode(item *LogItem) ([]byte, error)
+	}
+
+	Encoding string
+
+	// TextEncoder is the human friendly format, machine ID, app name and ext tags are appended as key=value pairs.
+	TextEncoder struct{}
+
+	// JSONEncoder outputs one JSON object per line.
+	JSONEncoder struct{}
+
+	// LogfmtEncoder outputs key=value pairs, see https://brandur.org/logfmt.
+	LogfmtEncoder struct{}
+)
+
+const (
+	EncodingText   Encoding = "text"
+	EncodingJSON   Encoding = "json"
+	EncodingLogfmt Encoding = "logfmt"
+
+	textTimeFormat = "2006-01-02 15:04:05.000 -07"
+)
+
+func NewEncoder(encoding Encoding) (Encoder, error) {
+	switch encoding {
+	case EncodingText, "":
+		return &TextEncoder{}, nil
+	case EncodingJSON:
+		return &JSONEncoder{}, nil
+	case EncodingLogfmt:
+		return &LogfmtEncoder{}, nil
+	}
+	return nil, gerrors.New("unsupported log encoding '%s'", encoding)
+}
+
+func (e *TextEncoder) Encode(item *LogItem) ([]byte, error) {
+	sb := strings.Builder{}
+	sb.WriteString(item.Time.Format(textTimeFormat))
+	sb.WriteString(" [")
+	sb.WriteString(string(item.Level))
+	sb.WriteString("] ")
+	sb.WriteString(item.Text)
+	write := func(k, v string) {
+		sb.WriteString(" ")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(logfmtValue(v))
+	}
+	if item.MachId != "" {
+		write("machid", item.MachId)
+	}
+	if item.AppName != "" {
+		write("app", item.AppName)
+	}
+	for _, k := range sortedTagKeys(item.Tags) {
+		write(k, item.Tags[k])
+	}
+	return []byte(sb.String()), nil
+}
+
+func (e *JSONEncoder) Encode(item *LogItem) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (e *LogfmtEncoder) Encode(item *LogItem) ([]byte, error) {
+	sb := strings.Builder{}
+	write := func(k, v string) {
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(logfmtValue(v))
+	}
+
+	write("time", item.Time.Format(time.RFC3339Nano))
+	write("level", string(item.Level))
+	if item.MachId != "" {
+		write("machid", item.MachId)
+	}
+	if item.AppName != "" {
+		write("app", item.AppName)
+	}
+	write("msg", item.Text)
+	for _, k := range sortedTagKeys(item.Tags) {
+		write(k, item.Tags[k])
+	}
+	return []byte(sb.String()), nil
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	var keys []string
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Quote value only if it would break key=value parsing.
+func logfmtValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	for _, c := range v {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,51 @@
+package glog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	item := NewLogItem(LevelInfo, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), "hello world")
+	item.MachId = "m1"
+	item.AppName = "app"
+	item.SetExtTag("k", "v")
+	item.SetExtTag("q", `a "b"`)
+
+	cl := []struct {
+		enc    Encoding
+		expect string
+	}{
+		{EncodingText, `2021-03-04 05:06:07.000 +00 [INFO] hello world machid=m1 app=app k=v q="a \"b\""`},
+		{EncodingJSON, `{"Time":"2021-03-04T05:06:07Z","Level":"INFO","Text":"hello world","MachId":"m1","AppName":"app","ExtTags":{"k":"v","q":"a \"b\""}}`},
+		{EncodingLogfmt, `time=2021-03-04T05:06:07Z level=INFO machid=m1 app=app msg="hello world" k=v q="a \"b\""`},
+	}
+	for _, v := range cl {
+		enc, err := NewEncoder(v.enc)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, err := enc.Encode(&item)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(b) != v.expect {
+			t.Errorf("%s encoder expect %s, got %s", v.enc, v.expect, string(b))
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	lv, err := ParseLevel("Warning")
+	if err != nil || lv != LevelWarn {
+		t.Errorf("ParseLevel error")
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel should fail")
+	}
+	if !LevelErro.Enabled(LevelWarn) || LevelInfo.Enabled(LevelWarn) {
+		t.Errorf("Level.Enabled error")
+	}
+}
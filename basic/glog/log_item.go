@@ -1,6 +1,8 @@
 package glog
 
 import (
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
 	"strings"
 	"time"
 )
@@ -8,11 +10,16 @@ import (
 type (
 	Level string
 
+	// Fields is structured context attached to log items by With.
+	Fields map[string]interface{}
+
 	LogItem struct {
-		Time  time.Time
-		Level Level
-		Text  string
-		Tags  map[string]string `json:"ExtTags,omitempty" bson:"ExtTags,omitempty"`
+		Time    time.Time
+		Level   Level
+		Text    string
+		MachId  string            `json:"MachId,omitempty" bson:"MachId,omitempty"`
+		AppName string            `json:"AppName,omitempty" bson:"AppName,omitempty"`
+		Tags    map[string]string `json:"ExtTags,omitempty" bson:"ExtTags,omitempty"`
 	}
 )
 
@@ -24,6 +31,43 @@ const (
 	LevelFata Level = "FATA"
 )
 
+var levelRanks = map[Level]int{
+	LevelDebg: 0,
+	LevelInfo: 1,
+	LevelWarn: 2,
+	LevelErro: 3,
+	LevelFata: 4,
+}
+
+// ParseLevel accepts both the 4-letter level names and common aliases like "debug" or "error".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debg", "debug":
+		return LevelDebg, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "erro", "error":
+		return LevelErro, nil
+	case "fata", "fatal":
+		return LevelFata, nil
+	}
+	return "", gerrors.New("unknown log level '%s'", s)
+}
+
+// Enabled reports whether items of level l pass a filter whose minimum level is min.
+func (l Level) Enabled(min Level) bool {
+	return l.rank() >= min.rank()
+}
+
+func (l Level) rank() int {
+	if r, ok := levelRanks[l]; ok {
+		return r
+	}
+	return levelRanks[LevelDebg]
+}
+
 func NewLogItem(level Level, time time.Time, text string) LogItem {
 	return LogItem{Level: level, Time: time, Text: text}
 }
@@ -36,6 +80,14 @@ func (l *LogItem) SetExtTag(key, value string) *LogItem {
 	return l
 }
 
+// SetFields converts fields values to strings and stores them as ext tags.
+func (l *LogItem) SetFields(fields Fields) *LogItem {
+	for k, v := range fields {
+		l.SetExtTag(k, fmt.Sprint(v))
+	}
+	return l
+}
+
 func (l *LogItem) GetExtTagEx(key string) (string, bool) {
 	if l.Tags == nil {
 		return "", false
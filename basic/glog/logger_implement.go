@@ -3,94 +3,146 @@ package glog
 import (
 	"fmt"
 	"github.com/cryptowilliam/goutil/basic/gerrors"
-	"github.com/cryptowilliam/goutil/sys/gfs"
 	"github.com/cryptowilliam/goutil/sys/gtime"
-	"github.com/sttts/color"
-	"io"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type (
 	DefaultImpl struct {
-		clock           gtime.Clock
-		conf            *Config
-		currLogFilename string
-		currLogFile     *os.File
-		currLogFileMu   sync.Mutex
-		printMu         sync.Mutex
+		clock  gtime.Clock
+		conf   *Config
+		core   *loggerCore
+		fields map[string]string
+	}
+
+	// loggerCore is shared by a logger and all children created by With.
+	loggerCore struct {
+		level   atomic.Value
+		sinks   []Sink
+		sinksMu sync.RWMutex
 	}
 )
 
+func newLoggerCore(level Level) *loggerCore {
+	c := &loggerCore{}
+	c.level.Store(level)
+	return c
+}
+
 func NewInsideLogger(c gtime.Clock) *DefaultImpl {
 	return &DefaultImpl{clock: c}
 }
 
+// SetClock sets clock of log items, and of sinks which rotate files by time.
 func (lgz *DefaultImpl) SetClock(c gtime.Clock) {
 	lgz.clock = c
+	for _, s := range lgz.getSinks() {
+		if cs, ok := s.(interface{ SetClock(gtime.Clock) }); ok {
+			cs.SetClock(c)
+		}
+	}
 }
 
-func (lgz *DefaultImpl) Logging(log LogItem) error {
-	return nil
+// SetLevel changes minimum output level of this logger and all loggers derived from it.
+func (lgz *DefaultImpl) SetLevel(level Level) {
+	if lgz.core != nil {
+		lgz.core.level.Store(level)
+	}
 }
 
-func (lgz *DefaultImpl) LoggingEx(level Level, text string, tags map[string]string) error {
-	return nil
+func (lgz *DefaultImpl) GetLevel() Level {
+	if lgz.core == nil {
+		return LevelDebg
+	}
+	return lgz.core.level.Load().(Level)
 }
 
-// Create or update log file.
-func (lgz *DefaultImpl) getFile(tm time.Time) (*os.File, error) {
-	if len(lgz.conf.SaveDir) == 0 {
-		return nil, gerrors.New("Empty log dierctory")
+// AddSink appends an output destination.
+func (lgz *DefaultImpl) AddSink(s Sink) {
+	if lgz.core == nil {
+		return
 	}
+	lgz.core.sinksMu.Lock()
+	lgz.core.sinks = append(lgz.core.sinks, s)
+	lgz.core.sinksMu.Unlock()
+}
 
-	var err error
-	var newLogFilename string
-	newLogFilename = lgz.conf.SaveDir + "/" + tm.Format(lgz.conf.FileNameFormat)
+// SetSinks replaces all output destinations, previous sinks are not closed.
+func (lgz *DefaultImpl) SetSinks(sinks ...Sink) {
+	if lgz.core == nil {
+		return
+	}
+	lgz.core.sinksMu.Lock()
+	lgz.core.sinks = append([]Sink{}, sinks...)
+	lgz.core.sinksMu.Unlock()
+}
 
-	// Update log filename and log file descriptor
-	if lgz.currLogFilename != newLogFilename {
-		if lgz.currLogFile != nil {
-			lgz.currLogFile.Close()
-			lgz.currLogFile = nil
-		}
+func (lgz *DefaultImpl) getSinks() []Sink {
+	if lgz.core == nil {
+		return nil
+	}
+	lgz.core.sinksMu.RLock()
+	defer lgz.core.sinksMu.RUnlock()
+	return lgz.core.sinks
+}
 
-		for {
-			pi, err := gfs.GetPathInfo(newLogFilename)
-			if err != nil {
-				return nil, err
-			}
-			if !pi.Exist {
-				lgz.currLogFile, err = os.Create(newLogFilename)
-				if err != nil {
-					return nil, err
-				}
-			}
-			if pi.Exist && pi.IsFolder {
-				err := os.RemoveAll(newLogFilename)
-				if err != nil {
-					return nil, err
-				} else {
-					continue
-				}
-			}
-			break
-		}
+// With returns a child logger which attaches fields to every log item,
+// child shares level and sinks with its parent.
+func (lgz *DefaultImpl) With(fields Fields) Interface {
+	child := *lgz
+	child.fields = make(map[string]string, len(lgz.fields)+len(fields))
+	for k, v := range lgz.fields {
+		child.fields[k] = v
+	}
+	for k, v := range fields {
+		child.fields[k] = fmt.Sprint(v)
+	}
+	return &child
+}
 
-		lgz.currLogFilename = newLogFilename
+// Logging dispatches log item to all sinks if its level is enabled.
+func (lgz *DefaultImpl) Logging(log LogItem) error {
+	if lgz.core == nil {
+		return gerrors.New("logger not initialized")
+	}
+	if !log.Level.Enabled(lgz.GetLevel()) {
+		return nil
 	}
 
-	// O log file
-	if lgz.currLogFile == nil {
-		lgz.currLogFile, err = os.OpenFile(lgz.currLogFilename, os.O_RDWR|os.O_CREATE, 0755)
-		lgz.currLogFile.Seek(0, io.SeekEnd)
-		if err != nil {
-			return nil, err
+	if len(lgz.fields) > 0 {
+		tags := log.Tags
+		log.Tags = nil
+		for k, v := range lgz.fields {
+			log.SetExtTag(k, v)
+		}
+		for k, v := range tags {
+			log.SetExtTag(k, v)
 		}
 	}
-	return lgz.currLogFile, nil
+	if lgz.conf != nil {
+		if log.MachId == "" {
+			log.MachId = lgz.conf.MachId
+		}
+		if log.AppName == "" {
+			log.AppName = lgz.conf.AppName
+		}
+	}
+
+	var errs []error
+	for _, s := range lgz.getSinks() {
+		errs = append(errs, s.Write(&log))
+	}
+	return gerrors.JoinArray(errs)
+}
+
+func (lgz *DefaultImpl) LoggingEx(level Level, text string, tags map[string]string) error {
+	item := NewLogItem(level, lgz.clock.Now(), text)
+	item.Tags = tags
+	return lgz.Logging(item)
 }
 
 // 注意，这里的receiver必须用*DefaultImpl，不可以用logger，否则conf将无法保存进l里面去
@@ -104,91 +156,49 @@ func (lgz *DefaultImpl) Init(config *Config) error {
 		}
 	}
 
-	lgz.conf = config
-	lgz.clock = gtime.GetSysClock()
-
-	if lgz.conf.SaveDisk {
-		fmt.Println(fmt.Sprintf("items logging into %s", lgz.conf.SaveDir))
+	level := config.Level
+	if level == "" {
+		level = LevelDebg
+	}
+	enc, err := NewEncoder(config.Encoding)
+	if err != nil {
+		return err
+	}
 
-		// 检查日志输出文件夹是否正常
-		if err := os.MkdirAll(lgz.conf.SaveDir, os.ModePerm); err != nil {
-			return err
-		}
-		pi, err := gfs.GetPathInfo(lgz.conf.SaveDir)
+	core := newLoggerCore(level)
+	if config.SaveDisk {
+		fmt.Println(fmt.Sprintf("items logging into %s", config.SaveDir))
+		fs, err := NewFileSink(config.SaveDir, config.FileNameFormat, enc, config.Rotate)
 		if err != nil {
 			return err
 		}
-		if !pi.Exist {
-			return gerrors.New(lgz.conf.SaveDir + " create failed")
-		}
-		if !pi.IsFolder {
-			return gerrors.New(lgz.conf.SaveDir + " create failed because it is not a folder")
-		}
+		core.sinks = append(core.sinks, fs)
+	}
+	if config.PrintScreen {
+		_, isText := enc.(*TextEncoder)
+		core.sinks = append(core.sinks, NewConsoleSink(enc, isText))
 	}
+
+	lgz.conf = config
+	lgz.clock = gtime.GetSysClock()
+	lgz.core = core
 	return nil
 }
 
 // Output to disk and screen if user want it
 func (lgz *DefaultImpl) WriteMsg(when time.Time, msg string, level Level) error {
-	msg = lgz.clock.Now().Format("2006-01-02 15:04:05.000 -07 [") + string(level) + "] " + msg
-
-	if lgz.conf.SaveDisk {
-		f, err := DefaultLogger.getFile(when)
-		if err != nil {
-			return gerrors.Wrap(err, "WriteMsg")
-		}
-
-		// Output log file
-		DefaultLogger.currLogFileMu.Lock()
-		if f != nil {
-			_, err := f.Write([]byte(msg + "\n"))
-			if err != nil {
-				DefaultLogger.currLogFileMu.Unlock()
-				return err
-			}
-		}
-		DefaultLogger.currLogFileMu.Unlock()
-
-	}
-
-	// Print screen
-	if lgz.conf.PrintScreen {
-		lgz.printMu.Lock()
-		switch level {
-		case LevelDebg:
-			color.Println(color.Green(msg))
-		case LevelInfo:
-			color.Println(color.Cyan(msg))
-		case LevelWarn:
-			color.Println(color.Yellow(msg))
-		case LevelErro:
-			color.Println(color.Red(msg))
-		case LevelFata:
-			color.Println(color.Magenta(msg))
-		}
-		lgz.printMu.Unlock()
-	}
-
-	return nil
+	return lgz.Logging(NewLogItem(level, when, msg))
 }
 
 func (lgz *DefaultImpl) Destroy() {
-	lgz.currLogFileMu.Lock()
-	if lgz.currLogFile != nil {
-		lgz.currLogFile.Sync()
-		lgz.currLogFile.Close()
+	for _, s := range lgz.getSinks() {
+		s.Close()
 	}
-	lgz.currLogFileMu.Unlock()
 }
 
 func (lgz *DefaultImpl) Flush() {
-	f, err := DefaultLogger.getFile(lgz.clock.Now())
-	if err == nil {
-		DefaultLogger.currLogFileMu.Lock()
-		if f != nil {
-			f.Sync()
-		}
-		DefaultLogger.currLogFileMu.Unlock()
+	for _, s := range lgz.getSinks() {
+		s.Sync()
 	}
 }
 
@@ -220,8 +230,11 @@ func clear(Text string) string {
 }
 
 func (lgz *DefaultImpl) logging(message string, level Level) {
-	message = clear(message)
-	if initialized.Load().(bool) {
+	if initialized.Load().(bool) && lgz.core != nil {
+		if !level.Enabled(lgz.GetLevel()) {
+			return
+		}
+		message = clear(message)
 		now := lgz.clock.Now()
 
 		// Output logs to disk and screen if user want it.
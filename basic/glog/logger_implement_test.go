@@ -21,3 +21,35 @@ func Test_Infof(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestDefaultImpl_With(t *testing.T) {
+	conf := &Config{PrintScreen: false, SaveDisk: false, Level: LevelInfo, MachId: "m1", AppName: "app1"}
+	lgz := NewInsideLogger(nil)
+	if err := lgz.Init(conf); err != nil {
+		t.Error(err)
+		return
+	}
+	sink := NewMemorySink()
+	lgz.SetSinks(sink)
+
+	lgz.Debgf("dropped")
+	lgz.With(Fields{"user": "alice", "id": 7}).Infof("hello %s", "world")
+	lgz.SetLevel(LevelErro)
+	lgz.Warnf("dropped")
+	lgz.Errof("kept")
+
+	items := sink.Items()
+	if len(items) != 2 {
+		t.Errorf("expect 2 items, got %d", len(items))
+		return
+	}
+	if items[0].Text != "hello world" || items[0].GetExtTag("user") != "alice" || items[0].GetExtTag("id") != "7" {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+	if items[0].MachId != "m1" || items[0].AppName != "app1" {
+		t.Errorf("MachId/AppName not attached %+v", items[0])
+	}
+	if items[1].Level != LevelErro || len(items[1].Tags) != 0 {
+		t.Errorf("unexpected item %+v", items[1])
+	}
+}
@@ -10,4 +10,5 @@ type Interface interface {
 	Fata(err error, wrapMsg ...string)
 	AssertOk(err error, wrapMsg ...string)
 	AssertTrue(express bool, wrapMsg ...string)
+	With(fields Fields) Interface
 }
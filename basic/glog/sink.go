@@ -0,0 +1,121 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/sttts/color"
+	"io"
+	"os"
+	"sync"
+)
+
+type (
+	// Sink is the destination of log items, DefaultImpl dispatches every item to all its sinks.
+	Sink interface {
+		Write(item *LogItem) error
+		Sync() error
+		Close() error
+	}
+
+	// ConsoleSink prints log items to screen, colored by level if required.
+	ConsoleSink struct {
+		enc     Encoder
+		out     io.Writer
+		colored bool
+		mu      sync.Mutex
+	}
+
+	// MemorySink keeps log items in memory, it is useful for tests.
+	MemorySink struct {
+		items []LogItem
+		mu    sync.RWMutex
+	}
+)
+
+// NewConsoleSink creates a sink printing to stdout, colored output only works with stdout.
+func NewConsoleSink(enc Encoder, colored bool) *ConsoleSink {
+	return &ConsoleSink{enc: enc, out: os.Stdout, colored: colored}
+}
+
+// NewWriterSink creates a sink printing to any writer without color.
+func NewWriterSink(enc Encoder, out io.Writer) *ConsoleSink {
+	return &ConsoleSink{enc: enc, out: out, colored: false}
+}
+
+func (s *ConsoleSink) Write(item *LogItem) error {
+	b, err := s.enc.Encode(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.colored {
+		_, err = fmt.Fprintln(s.out, string(b))
+		return err
+	}
+	switch item.Level {
+	case LevelDebg:
+		color.Println(color.Green("%s", b))
+	case LevelInfo:
+		color.Println(color.Cyan("%s", b))
+	case LevelWarn:
+		color.Println(color.Yellow("%s", b))
+	case LevelErro:
+		color.Println(color.Red("%s", b))
+	case LevelFata:
+		color.Println(color.Magenta("%s", b))
+	default:
+		color.Println(color.None("%s", b))
+	}
+	return nil
+}
+
+func (s *ConsoleSink) Sync() error {
+	if f, ok := s.out.(*os.File); ok {
+		// Sync on terminal returns EINVAL, ignore it.
+		_ = f.Sync()
+	}
+	return nil
+}
+
+func (s *ConsoleSink) Close() error {
+	return s.Sync()
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Write(item *LogItem) error {
+	cp := *item
+	cp.Tags = nil
+	for k, v := range item.Tags {
+		cp.SetExtTag(k, v)
+	}
+
+	s.mu.Lock()
+	s.items = append(s.items, cp)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemorySink) Sync() error {
+	return nil
+}
+
+func (s *MemorySink) Close() error {
+	return nil
+}
+
+// Items returns a copy of all log items received.
+func (s *MemorySink) Items() []LogItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]LogItem{}, s.items...)
+}
+
+func (s *MemorySink) Reset() {
+	s.mu.Lock()
+	s.items = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,395 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/compress/gcompress"
+	"github.com/cryptowilliam/goutil/sys/gfs"
+	"github.com/cryptowilliam/goutil/sys/gtime"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// FileSink writes log items into SaveDir, log file is switched by FileNameFormat of item time,
+	// and rotated by size if RotateConfig.MaxFileSize set.
+	FileSink struct {
+		dir             string
+		nameFormat      string
+		enc             Encoder
+		rotate          RotateConfig
+		currLogFilename string
+		currLogFile     *os.File
+		currSize        int64
+		closed          bool
+		clock           gtime.Clock // Time of rotation and retention, item time is used to choose file.
+		mu              sync.Mutex
+
+		millCh chan struct{}
+		millWg sync.WaitGroup
+	}
+
+	// rotatedFile is a finished log file, which is compressed and removed by retention policy.
+	rotatedFile struct {
+		path    string
+		modTime time.Time
+	}
+)
+
+const (
+	backupTimeFormat = "20060102T150405.000"
+)
+
+func NewFileSink(dir, nameFormat string, enc Encoder, rotate RotateConfig) (*FileSink, error) {
+	if len(dir) == 0 {
+		return nil, gerrors.New("Empty log dierctory")
+	}
+	if err := rotate.Verify(); err != nil {
+		return nil, err
+	}
+
+	// 检查日志输出文件夹是否正常
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	pi, err := gfs.GetPathInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !pi.Exist {
+		return nil, gerrors.New(dir + " create failed")
+	}
+	if !pi.IsFolder {
+		return nil, gerrors.New(dir + " create failed because it is not a folder")
+	}
+
+	s := &FileSink{dir: dir, nameFormat: nameFormat, enc: enc, rotate: rotate, clock: gtime.GetSysClock(), millCh: make(chan struct{}, 1)}
+	s.millWg.Add(1)
+	go s.millLoop()
+	// Apply compression and retention to files left by previous runs.
+	s.triggerMill()
+	return s, nil
+}
+
+// SetClock sets clock used by rotation and retention.
+func (s *FileSink) SetClock(c gtime.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+}
+
+// Create or update log file.
+func (s *FileSink) getFile(tm time.Time) (*os.File, error) {
+	var err error
+	var newLogFilename string
+	newLogFilename = s.dir + "/" + tm.Format(s.nameFormat)
+
+	// Update log filename and log file descriptor
+	if s.currLogFilename != newLogFilename {
+		if s.currLogFile != nil {
+			s.currLogFile.Close()
+			s.currLogFile = nil
+			// Previous file is finished, compress and clean up it in background.
+			s.triggerMill()
+		}
+
+		for {
+			pi, err := gfs.GetPathInfo(newLogFilename)
+			if err != nil {
+				return nil, err
+			}
+			if pi.Exist && pi.IsFolder {
+				err := os.RemoveAll(newLogFilename)
+				if err != nil {
+					return nil, err
+				} else {
+					continue
+				}
+			}
+			break
+		}
+
+		s.currLogFilename = newLogFilename
+	}
+
+	// Open log file
+	if s.currLogFile == nil {
+		s.currLogFile, err = os.OpenFile(s.currLogFilename, os.O_RDWR|os.O_CREATE, 0755)
+		if err != nil {
+			return nil, err
+		}
+		s.currSize, err = s.currLogFile.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.currLogFile, nil
+}
+
+// Rename current file to a backup name of time tm, next getFile call will create a new one.
+// Caller must hold s.mu, so no lines are written between closing and reopening.
+func (s *FileSink) rotateCurrent(tm time.Time) error {
+	if s.currLogFile == nil {
+		return nil
+	}
+	if err := s.currLogFile.Close(); err != nil {
+		return err
+	}
+	s.currLogFile = nil
+
+	if err := os.Rename(s.currLogFilename, backupName(s.currLogFilename, tm)); err != nil {
+		return err
+	}
+	s.triggerMill()
+	return nil
+}
+
+// backupName returns unused backup name of log file path.
+func backupName(path string, tm time.Time) string {
+	backup := path + "." + tm.Format(backupTimeFormat)
+	for i := 1; gfs.FileExits(backup); i++ {
+		backup = fmt.Sprintf("%s.%s%d", path, tm.Format(backupTimeFormat), i)
+	}
+	return backup
+}
+
+func (s *FileSink) Write(item *LogItem) error {
+	b, err := s.enc.Encode(item)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return gerrors.New("file sink closed")
+	}
+	f, err := s.getFile(item.Time)
+	if err != nil {
+		return err
+	}
+	maxSize := int64(s.rotate.MaxFileSize.Bytes())
+	if maxSize > 0 && s.currSize > 0 && s.currSize+int64(len(b)) > maxSize {
+		if err := s.rotateCurrent(item.Time); err != nil {
+			return err
+		}
+		if f, err = s.getFile(item.Time); err != nil {
+			return err
+		}
+	}
+	n, err := f.Write(b)
+	s.currSize += int64(n)
+	return err
+}
+
+func (s *FileSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.currLogFile != nil {
+		return s.currLogFile.Sync()
+	}
+	return nil
+}
+
+// Rotate forces current log file to be rotated, even it doesn't reach MaxFileSize.
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotateCurrent(s.clock.Now())
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := error(nil)
+	if s.currLogFile != nil {
+		s.currLogFile.Sync()
+		err = s.currLogFile.Close()
+		s.currLogFile = nil
+		s.currLogFilename = ""
+	}
+	close(s.millCh)
+	s.mu.Unlock()
+
+	// Wait for pending compression.
+	s.millWg.Wait()
+	return err
+}
+
+func (s *FileSink) triggerMill() {
+	select {
+	case s.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *FileSink) millLoop() {
+	defer s.millWg.Done()
+	for range s.millCh {
+		if err := s.mill(); err != nil {
+			fmt.Println(gerrors.Wrap(err, "glog rotate"))
+		}
+	}
+}
+
+// mill compresses finished log files and removes files exceed MaxFiles or MaxAge.
+// Writer may reopen a finished file for late items, so files are removed, or renamed to unique backup
+// names before compression, under s.mu after checking they are not opened.
+func (s *FileSink) mill() error {
+	s.mu.Lock()
+	now := s.clock.Now()
+	files, err := s.listRotated(now)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var remains []rotatedFile
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for i, v := range files {
+		expired := s.rotate.MaxAge > 0 && now.Sub(v.modTime) > s.rotate.MaxAge
+		if (s.rotate.MaxFiles > 0 && i >= s.rotate.MaxFiles) || expired {
+			errs = append(errs, s.removeFinished(v.path))
+			continue
+		}
+		remains = append(remains, v)
+	}
+
+	ext := gcompress.FileExt(s.rotate.Compress)
+	if ext != "" {
+		for _, v := range remains {
+			if strings.HasSuffix(v.path, ext) {
+				continue
+			}
+			path, err := s.detach(v.path, now)
+			if err != nil || path == "" {
+				errs = append(errs, err)
+				continue
+			}
+			errs = append(errs, compressFile(path, path+ext, s.rotate.Compress))
+		}
+	}
+	return gerrors.JoinArray(errs)
+}
+
+// isOpen reports whether path is current file or will be opened soon, s.mu must be locked.
+func (s *FileSink) isOpen(path string, now time.Time) bool {
+	// Before first write there is no current file, but today's file will be reopened soon.
+	today := s.dir + "/" + now.Format(s.nameFormat)
+	return filepath.Clean(path) == filepath.Clean(s.currLogFilename) || filepath.Clean(path) == filepath.Clean(today)
+}
+
+// removeFinished removes path unless it has been reopened by writer.
+func (s *FileSink) removeFinished(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isOpen(path, s.clock.Now()) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// detach renames date log file to unique backup name, so writer creates a new file for late items
+// instead of appending to the one being compressed. Backups are returned as is, never reopened.
+// It returns empty path if file has been reopened or removed.
+func (s *FileSink) detach(path string, now time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isOpen(path, s.clock.Now()) || !gfs.FileExits(path) {
+		return "", nil
+	}
+	if _, err := time.Parse(s.nameFormat, filepath.Base(path)); err != nil {
+		return path, nil
+	}
+	backup := backupName(path, now)
+	if err := os.Rename(path, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// List finished log files of this sink, files opened by writer excluded, s.mu must be locked.
+func (s *FileSink) listRotated(now time.Time) ([]rotatedFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	ext := gcompress.FileExt(s.rotate.Compress)
+
+	var res []rotatedFile
+	for _, v := range entries {
+		if v.IsDir() {
+			continue
+		}
+		path := s.dir + "/" + v.Name()
+		if s.isOpen(path, now) {
+			continue
+		}
+		name := v.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		if ext != "" {
+			name = strings.TrimSuffix(name, ext)
+		}
+		if !s.isLogFilename(name) {
+			continue
+		}
+		fi, err := v.Info()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rotatedFile{path: path, modTime: fi.ModTime()})
+	}
+	return res, nil
+}
+
+// isLogFilename checks whether name is a date log file or a backup of it.
+func (s *FileSink) isLogFilename(name string) bool {
+	if _, err := time.Parse(s.nameFormat, name); err == nil {
+		return true
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] != '.' {
+			continue
+		}
+		if _, err := time.Parse(s.nameFormat, name[:i]); err != nil {
+			continue
+		}
+		suffix := name[i+1:]
+		if len(suffix) >= len(backupTimeFormat) {
+			if _, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)]); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compress into a temporary file then rename, so a crash never leaves a truncated archive.
+func compressFile(src, dst string, algo gcompress.Comp) error {
+	tmp := dst + ".tmp"
+	if err := gcompress.NewFileCompress(src, tmp, algo); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
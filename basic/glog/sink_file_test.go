@@ -0,0 +1,126 @@
+package glog
+
+import (
+	"github.com/cryptowilliam/goutil/compress/gcompress"
+	"github.com/cryptowilliam/goutil/container/gvolume"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	rc := RotateConfig{MaxFileSize: gvolume.Volume(1) * gvolume.KB, MaxFiles: 2, Compress: gcompress.CompGzip}
+	sink, err := NewFileSink(dir, "2006-01-02.log", &TextEncoder{}, rc)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tm := time.Now()
+	line := strings.Repeat("x", 100)
+	for i := 0; i < 50; i++ {
+		if err := sink.Write(&LogItem{Time: tm, Level: LevelInfo, Text: line}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	curr := filepath.Join(dir, tm.Format("2006-01-02.log"))
+	size, err := os.Stat(curr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if size.Size() > 1024 {
+		t.Errorf("current log file size %d exceeds MaxFileSize", size.Size())
+	}
+	gzs, err := filepath.Glob(filepath.Join(dir, "*.gz"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(gzs) != 2 || len(entries) != 3 {
+		t.Errorf("expect 2 compressed backups and 3 files in total, got %d and %d", len(gzs), len(entries))
+		return
+	}
+
+	plain := filepath.Join(dir, "plain.txt")
+	if err := gcompress.NewFileDecompress(gzs[0], plain, gcompress.CompGzip); err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := os.ReadFile(plain)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(b), line) {
+		t.Errorf("decompressed backup content mismatch")
+	}
+}
+
+func TestFileSink_LateItems(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, "2006-01-02.log", &TextEncoder{}, RotateConfig{Compress: gcompress.CompGzip})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	today := time.Now()
+	yesterday := today.Add(-24 * time.Hour)
+	// Switching between days makes mill compress yesterday's file while late items arrive.
+	for i := 0; i < 2000; i++ {
+		tm := today
+		if i%2 == 0 {
+			tm = yesterday
+		}
+		if err := sink.Write(&LogItem{Time: tm, Level: LevelInfo, Text: "line"}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lines := 0
+	for _, v := range entries {
+		path := filepath.Join(dir, v.Name())
+		if strings.HasSuffix(path, ".gz") {
+			plain := filepath.Join(t.TempDir(), "plain")
+			if err := gcompress.NewFileDecompress(path, plain, gcompress.CompGzip); err != nil {
+				t.Error(err)
+				return
+			}
+			path = plain
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lines += strings.Count(string(b), "line")
+	}
+	if lines != 2000 {
+		t.Errorf("expect 2000 lines, got %d", lines)
+	}
+}
@@ -2,6 +2,7 @@ package gpanic
 
 import (
 	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
 	"github.com/cryptowilliam/goutil/basic/glog"
 	"os"
 )
@@ -26,3 +27,20 @@ func HandlePanic(exit bool) {
 		}
 	}
 }
+
+// Function:
+// Recover panic and pass it to fn as error, the error includes stack of panicking goroutine.
+// Usage:
+// Call it like "defer gpanic.Recover(func(err error) {...})", recover only works in function called by defer directly.
+func Recover(fn func(err error)) {
+	if r := recover(); r != nil {
+		// Stack is captured before the panicking frames are unwound.
+		var err error
+		if e, ok := r.(error); ok {
+			err = gerrors.Wrap(e, "panic")
+		} else {
+			err = gerrors.New("panic: %v", r)
+		}
+		fn(err)
+	}
+}
@@ -0,0 +1,135 @@
+package gprogress
+
+import (
+	"fmt"
+	"github.com/cryptowilliam/goutil/container/gspeed"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// BarRenderer renders snapshot as text progress bars, sub tasks are indented below their parent.
+	BarRenderer struct {
+		Width int  // Width of bar, default 30.
+		Bytes bool // Counters are bytes, so rate is printed like "1.50MB/s".
+	}
+
+	// TerminalPrinter redraws bars of task in place on terminal.
+	TerminalPrinter struct {
+		Renderer BarRenderer
+		w        io.Writer
+		lines    int
+		mu       sync.Mutex
+	}
+)
+
+func formatETA(seconds float64) string {
+	if seconds < 0 {
+		return "--:--:--"
+	}
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+func (r BarRenderer) rate(s Snapshot) string {
+	if r.Bytes {
+		speed := gspeed.FromBytesUint64(uint64(s.Rate))
+		return speed.StringWithByteUnit() + "/s"
+	}
+	return fmt.Sprintf("%.1f/s", s.Rate)
+}
+
+// Line renders single task without its children.
+func (r BarRenderer) Line(s Snapshot) string {
+	width := r.Width
+	if width <= 0 {
+		width = 30
+	}
+	filled := int(s.Fraction * float64(width))
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%s [%s] %5.1f%%", s.Name, bar, s.Fraction*100))
+	if s.Total > 0 || s.Current > 0 {
+		sb.WriteString(fmt.Sprintf(" %d/%d %s", s.Current, s.Total, r.rate(s)))
+	}
+	if s.Done {
+		sb.WriteString(" done")
+	} else {
+		sb.WriteString(" ETA " + formatETA(s.ETA))
+	}
+	return sb.String()
+}
+
+// Render renders task tree, one line per task.
+func (r BarRenderer) Render(s Snapshot) []string {
+	return r.render(s, "")
+}
+
+func (r BarRenderer) render(s Snapshot, indent string) []string {
+	lines := []string{indent + r.Line(s)}
+	for _, c := range s.Children {
+		lines = append(lines, r.render(c, indent+"  ")...)
+	}
+	return lines
+}
+
+func NewTerminalPrinter(w io.Writer) *TerminalPrinter {
+	return &TerminalPrinter{w: w}
+}
+
+// Print redraws task, previous printed lines are overwritten with ANSI escape codes.
+func (p *TerminalPrinter) Print(t *Task) error {
+	return p.print(t.Snapshot())
+}
+
+func (p *TerminalPrinter) print(s Snapshot) error {
+	lines := p.Renderer.Render(s)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sb := strings.Builder{}
+	if p.lines > 0 {
+		// Move cursor up and clear to end of screen.
+		sb.WriteString(fmt.Sprintf("\x1b[%dA\x1b[J", p.lines))
+	}
+	for _, l := range lines {
+		sb.WriteString(l + "\n")
+	}
+	p.lines = len(lines)
+	_, err := io.WriteString(p.w, sb.String())
+	return err
+}
+
+// Refresh prints task every interval until task done or stop called, final state is always printed.
+func (p *TerminalPrinter) Refresh(t *Task, interval time.Duration) (stop func()) {
+	stopCh := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if snap := t.Snapshot(); p.print(snap) != nil || snap.Done {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-stopCh:
+				_ = p.Print(t)
+				return
+			}
+		}
+	}()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() { close(stopCh) })
+		<-exited
+	}
+}
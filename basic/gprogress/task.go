@@ -0,0 +1,232 @@
+package gprogress
+
+import (
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/container/gspeed"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Task tracks progress of a job with counter and total, or of its weighted sub tasks.
+	// All tasks in the same tree share one lock, so it is safe to update them concurrently.
+	Task struct {
+		mu         *sync.Mutex
+		name       string
+		weight     float64
+		parent     *Task
+		children   []*Task
+		current    int64
+		total      int64
+		startedAt  time.Time
+		finishedAt time.Time
+		speed      *gspeed.SpeedCounter
+	}
+
+	// Snapshot is the state of a task at some moment, it is used by renderers and JSON status endpoints.
+	Snapshot struct {
+		Name     string     `json:"name"`
+		Current  int64      `json:"current"`
+		Total    int64      `json:"total"`
+		Fraction float64    `json:"fraction"`
+		Rate     float64    `json:"rate"` // Counts per second.
+		Elapsed  float64    `json:"elapsedSeconds"`
+		ETA      float64    `json:"etaSeconds"` // -1 means unknown.
+		Done     bool       `json:"done"`
+		Weight   float64    `json:"weight,omitempty"`
+		Children []Snapshot `json:"children,omitempty"`
+	}
+)
+
+// DefaultRateWindow is the time window of rate smoothing.
+const DefaultRateWindow = 5 * time.Second
+
+// NewTask creates root task, total 0 means unknown total or a task with sub tasks only.
+func NewTask(name string, total int64) *Task {
+	return newTask(&sync.Mutex{}, name, total, 1, nil)
+}
+
+func newTask(mu *sync.Mutex, name string, total int64, weight float64, parent *Task) *Task {
+	t := &Task{mu: mu, name: name, total: total, weight: weight, parent: parent, startedAt: time.Now()}
+	t.speed = gspeed.NewCounter(DefaultRateWindow)
+	t.speed.BeginCount()
+	return t
+}
+
+// SubTask creates child task, fraction of parent is weighted average of its children.
+func (t *Task) SubTask(name string, total int64, weight float64) *Task {
+	if weight <= 0 {
+		weight = 1
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	child := newTask(t.mu, name, total, weight, t)
+	t.children = append(t.children, child)
+	return child
+}
+
+// Add increases counter by n.
+func (t *Task) Add(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current += n
+	if n > 0 {
+		t.speed.Add(uint64(n))
+	}
+}
+
+// Set sets counter to n.
+func (t *Task) Set(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if delta := n - t.current; delta > 0 {
+		t.speed.Add(uint64(delta))
+	}
+	t.current = n
+}
+
+// SetTotal updates total, for example after content length is known.
+func (t *Task) SetTotal(total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = total
+}
+
+// Done marks task and all its sub tasks finished.
+func (t *Task) Done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done(time.Now())
+}
+
+func (t *Task) done(now time.Time) {
+	if t.finishedAt.IsZero() {
+		t.finishedAt = now
+	}
+	for _, c := range t.children {
+		c.done(now)
+	}
+}
+
+func (t *Task) fraction() float64 {
+	if !t.finishedAt.IsZero() {
+		return 1
+	}
+	if len(t.children) > 0 {
+		sum, weights := 0.0, 0.0
+		for _, c := range t.children {
+			sum += c.weight * c.fraction()
+			weights += c.weight
+		}
+		return sum / weights
+	}
+	if t.total <= 0 {
+		return 0
+	}
+	return math.Min(float64(t.current)/float64(t.total), 1)
+}
+
+// rate is smoothed by gspeed.SpeedCounter, average rate is used after task done,
+// or if counter is updated too soon after start to compute speed.
+func (t *Task) rate(now time.Time) float64 {
+	if t.finishedAt.IsZero() {
+		s, err := t.speed.Get()
+		if err == nil && !math.IsNaN(float64(*s)) && !math.IsInf(float64(*s), 0) {
+			return s.GetByteSize()
+		}
+	} else {
+		now = t.finishedAt
+	}
+	if elapsed := now.Sub(t.startedAt).Seconds(); elapsed > 0 {
+		return float64(t.current) / elapsed
+	}
+	return 0
+}
+
+// eta estimates remaining time by counter rate of leaf task, or by elapsed time and fraction.
+func (t *Task) eta(now time.Time) time.Duration {
+	if !t.finishedAt.IsZero() {
+		return 0
+	}
+	if len(t.children) == 0 && t.total > 0 {
+		if rate := t.rate(now); rate > 0 {
+			remain := float64(t.total - t.current)
+			return time.Duration(math.Max(remain, 0) / rate * float64(time.Second))
+		}
+	}
+	f := t.fraction()
+	if f <= 0 {
+		return -1
+	}
+	elapsed := now.Sub(t.startedAt)
+	return time.Duration(float64(elapsed) * (1 - f) / f)
+}
+
+// Fraction returns completed fraction in [0, 1].
+func (t *Task) Fraction() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fraction()
+}
+
+// Rate returns smoothed counts per second of the task itself.
+func (t *Task) Rate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rate(time.Now())
+}
+
+// ETA returns estimated remaining time, false if it can't be estimated yet.
+func (t *Task) ETA() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	eta := t.eta(time.Now())
+	return eta, eta >= 0
+}
+
+// Snapshot returns state of task and its sub tasks.
+func (t *Task) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshot(time.Now())
+}
+
+func (t *Task) snapshot(now time.Time) Snapshot {
+	end := now
+	if !t.finishedAt.IsZero() {
+		end = t.finishedAt
+	}
+	s := Snapshot{
+		Name:     t.name,
+		Current:  t.current,
+		Total:    t.total,
+		Fraction: t.fraction(),
+		Rate:     t.rate(now),
+		Elapsed:  end.Sub(t.startedAt).Seconds(),
+		ETA:      -1,
+		Done:     !t.finishedAt.IsZero(),
+	}
+	if t.parent != nil {
+		s.Weight = t.weight
+	}
+	if eta := t.eta(now); eta >= 0 {
+		s.ETA = eta.Seconds()
+	}
+	for _, c := range t.children {
+		s.Children = append(s.Children, c.snapshot(now))
+	}
+	return s
+}
+
+// ServeHTTP writes JSON snapshot, so task can be mounted as HTTP status endpoint.
+func (t *Task) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b, err := json.Marshal(t.Snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,82 @@
+package gprogress
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTask(t *testing.T) {
+	root := NewTask("download", 0)
+	a := root.SubTask("a.zip", 100, 3)
+	b := root.SubTask("b.zip", 50, 1)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Add(1)
+		}()
+	}
+	wg.Wait()
+	b.Set(50)
+
+	// (3 * 0.5 + 1 * 1) / 4
+	if f := root.Fraction(); math.Abs(f-0.625) > 1e-9 {
+		t.Errorf("expect 0.625, got %f", f)
+		return
+	}
+	if eta, ok := a.ETA(); !ok || eta < 0 {
+		t.Errorf("eta of a should be known, got %v %v", eta, ok)
+		return
+	}
+	if _, ok := NewTask("empty", 0).ETA(); ok {
+		t.Error("eta of empty task should be unknown")
+		return
+	}
+
+	// JSON snapshot.
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	snap := Snapshot{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(snap.Children) != 2 || snap.Children[0].Current != 50 || snap.Children[0].Weight != 3 {
+		t.Errorf("invalid snapshot %s", rec.Body.String())
+		return
+	}
+
+	root.Done()
+	if root.Fraction() != 1 || !root.Snapshot().Children[0].Done {
+		t.Error("done should finish all sub tasks")
+	}
+}
+
+func TestTerminalPrinter(t *testing.T) {
+	root := NewTask("job", 200)
+	root.Add(100)
+	lines := BarRenderer{Width: 10}.Render(root.Snapshot())
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "job [=====>    ]  50.0% 100/200") {
+		t.Errorf("unexpected bar %q", lines)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	p := NewTerminalPrinter(buf)
+	stop := p.Refresh(root, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	root.Done()
+	stop()
+	out := buf.String()
+	if !strings.Contains(out, "\x1b[1A\x1b[J") || !strings.HasSuffix(out, " done\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
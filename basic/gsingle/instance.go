@@ -1,34 +1,240 @@
 package gsingle
 
-// test succeed under macOS but !sometimes! failed under linux: https://github.com/marcsauter/single
-// test succeed under linux: https://github.com/allan-simon/go-singleinstance
+// Lock file is held by OS advisory lock, which is released by OS when owner process exits, so lock of dead process
+// is taken over. Lock file also holds PID and creation time of owner process for diagnosis, it is kept after unlocking.
+// Primary instance listens on a unix socket, secondary instances forward their arguments to it and exit.
+// Unix socket is supported on windows 10 1803 and later.
 
 import (
+	"bufio"
+	"encoding/json"
 	"github.com/cryptowilliam/goutil/basic/gerrors"
-	"github.com/marcsauter/single"
+	"github.com/cryptowilliam/goutil/sys/gproc"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
-type Instance struct {
-	lock *single.Single
-}
+type (
+	Instance struct {
+		lockFile   string
+		socketFile string
+		locked     bool
+		file       *os.File // Opened lock file while locked.
+		listener   net.Listener
+		argsCh     chan []string
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+	}
+
+	lockInfo struct {
+		Pid        gproc.ProcId `json:"pid"`
+		CreateTime int64        `json:"createTime"` // Unix milliseconds.
+	}
+)
+
+const (
+	DefaultForwardTimeout = 5 * time.Second
+	forwardAck            = "ok"
+)
+
+var (
+	ErrNotPrimary = gerrors.New("not primary instance")
+	errLocked     = gerrors.New("locked by others")
+)
 
+// New creates instance lock under temporary dir.
 func New(appName string) *Instance {
-	res := Instance{}
-	res.lock = single.New(appName)
-	return &res
+	return NewEx(appName, os.TempDir())
+}
+
+// NewEx creates instance lock under dir, path of unix socket must be short, about 100 bytes.
+func NewEx(appName, dir string) *Instance {
+	return &Instance{
+		lockFile:   filepath.Join(dir, appName+".lock"),
+		socketFile: filepath.Join(dir, appName+".sock"),
+	}
+}
+
+func selfLockInfo() lockInfo {
+	res := lockInfo{Pid: gproc.GetPidOfMyself()}
+	if tm, err := gproc.GetPidCreateTime(res.Pid); err == nil {
+		res.CreateTime = tm.UnixMilli()
+	}
+	return res
+}
+
+func (l *Instance) tryLock() (bool, error) {
+	b, err := json.Marshal(selfLockInfo())
+	if err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(l.lockFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if err == errLocked {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteAt(b, 0)
+	}
+	if err != nil {
+		unlockFile(f)
+		f.Close()
+		return false, err
+	}
+	l.file = f
+	return true, nil
 }
 
+// IsSingle tries to acquire lock, it returns true if this is the only running instance.
 func (l *Instance) IsSingle() (bool, error) {
-	if err := l.lock.CheckLock(); err != nil && err == single.ErrAlreadyRunning {
-		return false, nil
-	} else if err != nil {
-		// Another error occurred, might be worth handling it as well
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locked {
+		return true, nil
+	}
+	ok, err := l.tryLock()
+	if err != nil {
 		return false, gerrors.Errorf("failed to acquire exclusive app lock: %v", err)
 	}
+	l.locked = ok
+	return ok, nil
+}
 
-	return true, nil
+// Listen starts receiving arguments forwarded by other instances, it must be called by primary instance.
+func (l *Instance) Listen() (<-chan []string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.locked {
+		return nil, ErrNotPrimary
+	}
+	if l.listener != nil {
+		return l.argsCh, nil
+	}
+
+	// Socket file left by dead primary instance.
+	if err := os.Remove(l.socketFile); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", l.socketFile)
+	if err != nil {
+		return nil, err
+	}
+	l.listener = ln
+	l.argsCh = make(chan []string, 16)
+	l.wg.Add(1)
+	go l.serve(ln)
+	return l.argsCh, nil
+}
+
+func (l *Instance) serve(ln net.Listener) {
+	defer l.wg.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(DefaultForwardTimeout))
+			var args []string
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&args); err != nil {
+				return
+			}
+			l.argsCh <- args
+			_, _ = conn.Write([]byte(forwardAck + "\n"))
+		}()
+	}
 }
 
+// Forward sends args to primary instance, it retries until primary instance starts listening or timeout.
+func (l *Instance) Forward(args []string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultForwardTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("unix", l.socketFile, time.Until(deadline))
+		if err != nil {
+			if time.Now().Add(100 * time.Millisecond).After(deadline) {
+				return gerrors.Wrap(err, "forward to primary instance")
+			}
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(deadline)
+		if args == nil {
+			args = []string{}
+		}
+		if err := json.NewEncoder(conn).Encode(args); err != nil {
+			return err
+		}
+		ack, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return gerrors.Wrap(err, "read ack of primary instance")
+		}
+		if ack != forwardAck+"\n" {
+			return gerrors.New("invalid ack %q of primary instance", ack)
+		}
+		return nil
+	}
+}
+
+// HandOff is the common pattern of single instance application.
+// Primary instance gets a channel receiving arguments of later instances,
+// other instances forward args to primary instance and get nil channel, they should exit then.
+func (l *Instance) HandOff(args []string) (primary bool, argsCh <-chan []string, err error) {
+	ok, err := l.IsSingle()
+	if err != nil {
+		return false, nil, err
+	}
+	if !ok {
+		return false, nil, l.Forward(args, DefaultForwardTimeout)
+	}
+	argsCh, err = l.Listen()
+	if err != nil {
+		return true, nil, err
+	}
+	return true, argsCh, nil
+}
+
+// UnLock stops listening, closes argument channel and releases lock.
 func (l *Instance) UnLock() error {
-	return l.lock.TryUnlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.locked {
+		return nil
+	}
+	if l.listener != nil {
+		l.listener.Close()
+		// Drain channel, so no handler blocks on sending.
+		go func(ch chan []string) {
+			for range ch {
+			}
+		}(l.argsCh)
+		l.wg.Wait()
+		close(l.argsCh)
+		l.listener = nil
+		os.Remove(l.socketFile)
+	}
+	l.locked = false
+	// Lock file is not removed, otherwise others may lock the removed file and the new one at the same time.
+	_ = l.file.Truncate(0)
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
 }
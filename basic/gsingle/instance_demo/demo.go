@@ -4,24 +4,35 @@ import (
 	"fmt"
 	"github.com/cryptowilliam/goutil/basic/gsingle"
 	"log"
+	"os"
 	"time"
 )
 
+// Run it in several terminals with different arguments,
+// later instances forward their arguments to the first one and exit.
 func main() {
 	lock := gsingle.New("your-app-name")
 	defer lock.UnLock()
 
-	ok, err := lock.IsSingle()
+	primary, argsCh, err := lock.HandOff(os.Args[1:])
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	if ok {
-		fmt.Println("Is single process")
-	} else {
-		fmt.Println("Another process running")
+	if !primary {
+		fmt.Println("Another process running, arguments forwarded")
+		return
 	}
 
-	time.Sleep(60 * time.Second)
-	log.Println("finished")
+	fmt.Println("Is single process")
+	timeout := time.After(60 * time.Second)
+	for {
+		select {
+		case args := <-argsCh:
+			log.Println("received", args)
+		case <-timeout:
+			log.Println("finished")
+			return
+		}
+	}
 }
@@ -0,0 +1,104 @@
+package gsingle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInstance_HandOff(t *testing.T) {
+	dir := t.TempDir()
+	first := NewEx("test-app", dir)
+	primary, argsCh, err := first.HandOff([]string{"a"})
+	if err != nil || !primary || argsCh == nil {
+		t.Errorf("first instance should be primary, %v %v", primary, err)
+		return
+	}
+
+	second := NewEx("test-app", dir)
+	go func() {
+		primary, ch, err := second.HandOff([]string{"open", "file.txt"})
+		if err != nil || primary || ch != nil {
+			t.Errorf("second instance should not be primary, %v %v", primary, err)
+		}
+	}()
+	select {
+	case args := <-argsCh:
+		if !reflect.DeepEqual(args, []string{"open", "file.txt"}) {
+			t.Errorf("unexpected args %v", args)
+			return
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("args not received")
+		return
+	}
+
+	if err := first.UnLock(); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := <-argsCh; ok {
+		t.Error("channel should be closed after UnLock")
+		return
+	}
+	if ok, err := second.IsSingle(); err != nil || !ok {
+		t.Errorf("lock should be released, %v %v", ok, err)
+		return
+	}
+	second.UnLock()
+}
+
+func TestInstance_StaleLock(t *testing.T) {
+	dir := t.TempDir()
+	// PID which can't exist.
+	if err := os.WriteFile(filepath.Join(dir, "test-app.lock"), []byte(`{"pid":2147483000,"createTime":1}`), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+	inst := NewEx("test-app", dir)
+	defer inst.UnLock()
+	if ok, err := inst.IsSingle(); err != nil || !ok {
+		t.Errorf("stale lock should be taken over, %v %v", ok, err)
+		return
+	}
+	if _, err := inst.Listen(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := NewEx("not-running", dir).Forward(nil, 300*time.Millisecond); err == nil {
+		t.Error("forward without primary instance should fail")
+	}
+}
+
+func TestInstance_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		wg      sync.WaitGroup
+		winners int32
+		insts   []*Instance
+	)
+	for i := 0; i < 20; i++ {
+		inst := NewEx("test-app", dir)
+		insts = append(insts, inst)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := inst.IsSingle(); err != nil {
+				t.Error(err)
+			} else if ok {
+				atomic.AddInt32(&winners, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, inst := range insts {
+		inst.UnLock()
+	}
+	if winners != 1 {
+		t.Errorf("%d instances acquired lock", winners)
+	}
+}
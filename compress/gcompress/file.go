@@ -1,10 +1,86 @@
 package gcompress
 
+import (
+	"io"
+	"os"
+)
+
 type (
 	FileCompress struct {
 	}
 )
 
+// NewFileCompress compresses input file into output file, output file will be truncated if exists.
 func NewFileCompress(inputFilename, outputFilename string, algo Comp) error {
-	return nil
+	in, err := os.Open(inputFilename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(outputFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w, err := NewCompWriter(algo, nil, out)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		w.Close()
+		out.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// NewFileDecompress decompresses input file into output file, output file will be truncated if exists.
+func NewFileDecompress(inputFilename, outputFilename string, algo Comp) error {
+	in, err := os.Open(inputFilename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := NewCompReader(algo, in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	out, err := os.OpenFile(outputFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// FileExt returns the conventional filename extension of compressed file, with leading dot.
+func FileExt(algo Comp) string {
+	switch algo {
+	case CompGzip, CompPgZip:
+		return ".gz"
+	case CompZStd:
+		return ".zst"
+	case CompZLib:
+		return ".zz"
+	case CompSnappy:
+		return ".sz"
+	case CompNone:
+		return ""
+	}
+	return "." + string(algo)
 }
@@ -0,0 +1,75 @@
+package gcompress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	data := bytes.Repeat([]byte("hello world, "), 10000)
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, algo := range []Comp{CompSnappy, CompS2, CompGzip, CompPgZip, CompZStd, CompZLib, CompFlate} {
+		comp := filepath.Join(dir, "src"+FileExt(algo))
+		dst := filepath.Join(dir, "dst-"+string(algo)+".txt")
+		// Existing output files are truncated.
+		if err := os.WriteFile(comp, bytes.Repeat([]byte{0xff}, len(data)), 0644); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.WriteFile(dst, bytes.Repeat([]byte("x"), len(data)*2), 0644); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := NewFileCompress(src, comp, algo); err != nil {
+			t.Errorf("%s: compress: %v", algo, err)
+			return
+		}
+		if fi, err := os.Stat(comp); err != nil || fi.Size() >= int64(len(data)) {
+			t.Errorf("%s: compressed file %v %v", algo, fi, err)
+			return
+		}
+		if err := NewFileDecompress(comp, dst, algo); err != nil {
+			t.Errorf("%s: decompress: %v", algo, err)
+			return
+		}
+		if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: decompressed %d bytes, expect %d, %v", algo, len(got), len(data), err)
+			return
+		}
+	}
+}
+
+func TestNewFileCompress_Errors(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := NewFileCompress(filepath.Join(dir, "missing"), filepath.Join(dir, "out.gz"), CompGzip); err == nil {
+		t.Error("compress missing file should fail")
+		return
+	}
+	if err := NewFileCompress(src, filepath.Join(dir, "out"), CompNone); err == nil {
+		t.Error("compress with none algo should fail")
+		return
+	}
+	// Input is not gzip data.
+	if err := NewFileDecompress(src, filepath.Join(dir, "out.txt"), CompGzip); err == nil {
+		t.Error("decompress invalid data should fail")
+		return
+	}
+	if ext := FileExt(CompNone); ext != "" {
+		t.Errorf("extension of none is %q", ext)
+	}
+}
@@ -116,3 +116,65 @@ func NewCompReadWriteCloser(compAlgo Comp, param *CompParam, rwc io.ReadWriteClo
 	}
 	return rst, nil
 }
+
+// NewCompWriter create write-only compressor, unlike NewCompReadWriteCloser it never reads from w.
+// Close must be called to flush compressed data, it doesn't close w.
+func NewCompWriter(compAlgo Comp, param *CompParam, w io.Writer) (io.WriteCloser, error) {
+	if param != nil {
+		if err := param.Verify(compAlgo); err != nil {
+			return nil, err
+		}
+	}
+	switch compAlgo {
+	case CompNone:
+		return nil, gerrors.New("can't create writer for 'none' algo")
+	case CompSnappy:
+		return snappy.NewBufferedWriter(w), nil
+	case CompS2:
+		return s2.NewWriter(w), nil
+	case CompGzip:
+		return gzip.NewWriter(w), nil
+	case CompPgZip:
+		return pgzip.NewWriter(w), nil
+	case CompZStd:
+		return zstd.NewWriter(w)
+	case CompZLib:
+		return zlib.NewWriter(w), nil
+	case CompFlate:
+		level := -1 // default level: -1
+		if param != nil {
+			level = param.Level
+		}
+		return flate.NewWriter(w, level)
+	default:
+		return nil, gerrors.New("unsupported compress algorithm %s", compAlgo)
+	}
+}
+
+// NewCompReader create read-only decompressor.
+func NewCompReader(compAlgo Comp, r io.Reader) (io.ReadCloser, error) {
+	switch compAlgo {
+	case CompNone:
+		return nil, gerrors.New("can't create reader for 'none' algo")
+	case CompSnappy:
+		return io.NopCloser(snappy.NewReader(r)), nil
+	case CompS2:
+		return io.NopCloser(s2.NewReader(r)), nil
+	case CompGzip:
+		return gzip.NewReader(r)
+	case CompPgZip:
+		return pgzip.NewReader(r)
+	case CompZStd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case CompZLib:
+		return zlib.NewReader(r)
+	case CompFlate:
+		return flate.NewReader(r), nil
+	default:
+		return nil, gerrors.New("unsupported compress algorithm %s", compAlgo)
+	}
+}
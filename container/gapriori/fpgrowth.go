@@ -0,0 +1,109 @@
+package gapriori
+
+type (
+	fpNode struct {
+		item     int
+		count    int
+		parent   *fpNode
+		children map[int]*fpNode
+		next     *fpNode // Next node of the same item.
+	}
+
+	// fpTree is a prefix tree of transactions whose items are sorted by frequency descending,
+	// so that common prefixes are shared.
+	fpTree struct {
+		root   *fpNode
+		heads  map[int]*fpNode // item -> first node of this item
+		counts map[int]int     // item -> count
+	}
+)
+
+func newFPTree() *fpTree {
+	return &fpTree{root: &fpNode{item: -1}, heads: map[int]*fpNode{}, counts: map[int]int{}}
+}
+
+// add inserts transaction whose items are sorted by rank.
+func (t *fpTree) add(ids []int, count int) {
+	node := t.root
+	for _, id := range ids {
+		child, ok := node.children[id]
+		if !ok {
+			child = &fpNode{item: id, parent: node, next: t.heads[id]}
+			if node.children == nil {
+				node.children = map[int]*fpNode{}
+			}
+			node.children[id] = child
+			t.heads[id] = child
+		}
+		child.count += count
+		t.counts[id] += count
+		node = child
+	}
+}
+
+// MineFPGrowth finds frequent itemsets by FP-Growth, it builds a compressed FP-tree from transactions
+// and mines conditional trees recursively without candidate generation, it is much faster than
+// MineApriori on large or dense datasets.
+func MineFPGrowth(it Iterator, opts Options) (*Result, error) {
+	d, err := load(it, opts)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFPTree()
+	for _, tx := range d.txs {
+		tree.add(tx.ids, tx.count)
+	}
+	var found []idset
+	d.growth(tree, nil, &found)
+	return d.result(found), nil
+}
+
+// growth mines all frequent itemsets which end with suffix from tree.
+func (d *dataset) growth(tree *fpTree, suffix []int, found *[]idset) {
+	for item, count := range tree.counts {
+		if count < d.minCount {
+			continue
+		}
+		set := make([]int, 0, len(suffix)+1)
+		set = append(append(set, item), suffix...)
+		*found = append(*found, idset{ids: set, count: count})
+		if !d.sizeAllowed(len(set) + 1) {
+			continue
+		}
+
+		// Conditional pattern base of item: prefix paths of its nodes, only frequent items are kept.
+		type path struct {
+			ids   []int
+			count int
+		}
+		var paths []path
+		base := map[int]int{}
+		for node := tree.heads[item]; node != nil; node = node.next {
+			var ids []int
+			for p := node.parent; p.parent != nil; p = p.parent {
+				ids = append(ids, p.item)
+				base[p.item] += node.count
+			}
+			if len(ids) > 0 {
+				paths = append(paths, path{ids: ids, count: node.count})
+			}
+		}
+
+		cond := newFPTree()
+		for _, p := range paths {
+			ids := make([]int, 0, len(p.ids))
+			// Paths are collected from leaf to root, reverse them to keep rank order.
+			for i := len(p.ids) - 1; i >= 0; i-- {
+				if base[p.ids[i]] >= d.minCount {
+					ids = append(ids, p.ids[i])
+				}
+			}
+			if len(ids) > 0 {
+				cond.add(ids, p.count)
+			}
+		}
+		if len(cond.counts) > 0 {
+			d.growth(cond, set, found)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gapriori
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+type (
+	// Iterator yields transactions one by one, Next returns io.EOF when there is no more transaction.
+	Iterator interface {
+		Next() ([]string, error)
+	}
+
+	sliceIterator struct {
+		txs [][]string
+		pos int
+	}
+
+	lineIterator struct {
+		scanner *bufio.Scanner
+		sep     string
+	}
+
+	tableIterator struct {
+		scanner *bufio.Scanner
+		header  []string
+	}
+)
+
+// NewSliceIterator iterates transactions in memory.
+func NewSliceIterator(txs [][]string) Iterator {
+	return &sliceIterator{txs: txs}
+}
+
+func (it *sliceIterator) Next() ([]string, error) {
+	if it.pos >= len(it.txs) {
+		return nil, io.EOF
+	}
+	it.pos++
+	return it.txs[it.pos-1], nil
+}
+
+// NewLineIterator reads one transaction per line, items are separated by sep, empty lines are skipped.
+func NewLineIterator(r io.Reader, sep string) Iterator {
+	return &lineIterator{scanner: bufio.NewScanner(r), sep: sep}
+}
+
+func (it *lineIterator) Next() ([]string, error) {
+	for it.scanner.Scan() {
+		line := strings.TrimSpace(it.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var items []string
+		for _, item := range strings.Split(line, it.sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	if err := it.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// NewTableIterator reads table like simple.txt: first line is "TID item1 item2 ...",
+// then every line is a transaction id followed by T/F or YES/NO flags of items.
+func NewTableIterator(r io.Reader) Iterator {
+	return &tableIterator{scanner: bufio.NewScanner(r)}
+}
+
+func (it *tableIterator) Next() ([]string, error) {
+	for it.scanner.Scan() {
+		fields := strings.Fields(it.scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if it.header == nil {
+			it.header = fields
+			continue
+		}
+		var items []string
+		for i := 1; i < len(fields) && i < len(it.header); i++ {
+			if flag := strings.ToUpper(fields[i]); flag == "T" || flag == "YES" {
+				items = append(items, it.header[i])
+			}
+		}
+		return items, nil
+	}
+	if err := it.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
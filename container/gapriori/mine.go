@@ -0,0 +1,303 @@
+package gapriori
+
+import (
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Options of frequent itemsets mining.
+	Options struct {
+		MinSupport float64 // 最小支持度, minimum fraction of transactions which contain an itemset, in (0, 1].
+		MaxSize    int     // Maximum items of an itemset, 0 means no limit.
+	}
+
+	// Itemset is a frequent itemset, Items are sorted.
+	Itemset struct {
+		Items   []string
+		Count   int     // Number of transactions which contain Items.
+		Support float64 // 支持度, Count / number of transactions.
+	}
+
+	// Rule is association rule Antecedent => Consequent.
+	Rule struct {
+		Antecedent []string
+		Consequent []string
+		Support    float64 // 支持度, support of Antecedent ∪ Consequent.
+		Confidence float64 // 置信度, P(Consequent | Antecedent).
+		Lift       float64 // 提升度, Confidence / support of Consequent, > 1 means positive correlation.
+	}
+
+	// Result of frequent itemsets mining.
+	Result struct {
+		Transactions int
+		Itemsets     []Itemset // Sorted by size, then by count descending.
+		counts       map[string]int
+	}
+
+	// dataset is transactions encoded by item ranks, rank 0 is the most frequent item.
+	// Infrequent items are dropped and identical transactions are merged.
+	dataset struct {
+		items    []string // rank -> item
+		counts   []int    // rank -> count
+		txs      []idset
+		total    int
+		minCount int
+		maxSize  int
+	}
+
+	// idset is sorted ranks of items with count.
+	idset struct {
+		ids   []int
+		count int
+	}
+)
+
+func (o Options) verify() error {
+	if o.MinSupport <= 0 || o.MinSupport > 1 {
+		return gerrors.Errorf("min support %v out of range (0, 1]", o.MinSupport)
+	}
+	if o.MaxSize < 0 {
+		return gerrors.Errorf("negative max itemset size %d", o.MaxSize)
+	}
+	return nil
+}
+
+func idsKey(ids []int) string {
+	b := make([]byte, 0, len(ids)*4)
+	for _, id := range ids {
+		b = strconv.AppendInt(b, int64(id), 36)
+		b = append(b, ',')
+	}
+	return string(b)
+}
+
+func itemsKey(items []string) string {
+	return strings.Join(items, "\x00")
+}
+
+// sortUnique sorts ids and removes duplicates in place.
+func sortUnique(ids []int) []int {
+	sort.Ints(ids)
+	n := 0
+	for i, id := range ids {
+		if i == 0 || id != ids[n-1] {
+			ids[n] = id
+			n++
+		}
+	}
+	return ids[:n]
+}
+
+// merge returns grouped transactions in order of their first appearance.
+func merge(txs map[string]*idset, order []string) []idset {
+	res := make([]idset, 0, len(order))
+	for _, key := range order {
+		res = append(res, *txs[key])
+	}
+	return res
+}
+
+// load reads all transactions from it in one pass.
+func load(it Iterator, opts Options) (*dataset, error) {
+	if err := opts.verify(); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var counts []int
+	idOf := map[string]int{}
+	raw := map[string]*idset{}
+	var order []string
+	total := 0
+	for {
+		tx, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		total++
+		ids := make([]int, 0, len(tx))
+		for _, item := range tx {
+			id, ok := idOf[item]
+			if !ok {
+				id = len(names)
+				idOf[item] = id
+				names = append(names, item)
+				counts = append(counts, 0)
+			}
+			ids = append(ids, id)
+		}
+		ids = sortUnique(ids)
+		for _, id := range ids {
+			counts[id]++
+		}
+		key := idsKey(ids)
+		if s, ok := raw[key]; ok {
+			s.count++
+		} else {
+			raw[key] = &idset{ids: ids, count: 1}
+			order = append(order, key)
+		}
+	}
+
+	d := &dataset{total: total, maxSize: opts.MaxSize}
+	d.minCount = int(math.Ceil(opts.MinSupport*float64(total) - 1e-9))
+	if d.minCount < 1 {
+		d.minCount = 1
+	}
+
+	// Rank frequent items by count descending.
+	var frequent []int
+	for id, c := range counts {
+		if c >= d.minCount {
+			frequent = append(frequent, id)
+		}
+	}
+	sort.Slice(frequent, func(i, j int) bool {
+		a, b := frequent[i], frequent[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return names[a] < names[b]
+	})
+	rank := make([]int, len(names))
+	for i := range rank {
+		rank[i] = -1
+	}
+	for r, id := range frequent {
+		rank[id] = r
+		d.items = append(d.items, names[id])
+		d.counts = append(d.counts, counts[id])
+	}
+
+	// Encode transactions by ranks.
+	txs := map[string]*idset{}
+	var txOrder []string
+	for _, s := range merge(raw, order) {
+		ids := make([]int, 0, len(s.ids))
+		for _, id := range s.ids {
+			if rank[id] >= 0 {
+				ids = append(ids, rank[id])
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Ints(ids)
+		key := idsKey(ids)
+		if t, ok := txs[key]; ok {
+			t.count += s.count
+		} else {
+			txs[key] = &idset{ids: ids, count: s.count}
+			txOrder = append(txOrder, key)
+		}
+	}
+	d.txs = merge(txs, txOrder)
+	return d, nil
+}
+
+func (d *dataset) sizeAllowed(n int) bool {
+	return d.maxSize == 0 || n <= d.maxSize
+}
+
+func (d *dataset) result(sets []idset) *Result {
+	r := &Result{Transactions: d.total, counts: map[string]int{}}
+	for _, s := range sets {
+		items := make([]string, len(s.ids))
+		for i, id := range s.ids {
+			items[i] = d.items[id]
+		}
+		sort.Strings(items)
+		r.Itemsets = append(r.Itemsets, Itemset{Items: items, Count: s.count, Support: float64(s.count) / float64(d.total)})
+		r.counts[itemsKey(items)] = s.count
+	}
+	sort.Slice(r.Itemsets, func(i, j int) bool {
+		a, b := r.Itemsets[i], r.Itemsets[j]
+		if len(a.Items) != len(b.Items) {
+			return len(a.Items) < len(b.Items)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return itemsKey(a.Items) < itemsKey(b.Items)
+	})
+	return r
+}
+
+// Count returns number of transactions which contain items, and false if items is not frequent.
+func (r *Result) Count(items ...string) (int, bool) {
+	sorted := append([]string{}, items...)
+	sort.Strings(sorted)
+	c, ok := r.counts[itemsKey(sorted)]
+	return c, ok
+}
+
+// Rules generates association rules whose confidence is at least minConfidence (最小置信度),
+// sorted by confidence, lift and support descending.
+func (r *Result) Rules(minConfidence float64) []Rule {
+	var rules []Rule
+	for _, set := range r.Itemsets {
+		n := len(set.Items)
+		if n < 2 || n >= 63 {
+			continue
+		}
+		// Every non-empty proper subset is an antecedent.
+		for mask := uint64(1); mask < 1<<n-1; mask++ {
+			var ante, cons []string
+			for i, item := range set.Items {
+				if mask&(1<<i) != 0 {
+					ante = append(ante, item)
+				} else {
+					cons = append(cons, item)
+				}
+			}
+			// Subsets of frequent itemset are frequent too, so their counts are known.
+			anteCount, consCount := r.counts[itemsKey(ante)], r.counts[itemsKey(cons)]
+			if anteCount == 0 || consCount == 0 {
+				continue
+			}
+			conf := float64(set.Count) / float64(anteCount)
+			if conf < minConfidence {
+				continue
+			}
+			rules = append(rules, Rule{
+				Antecedent: ante,
+				Consequent: cons,
+				Support:    set.Support,
+				Confidence: conf,
+				Lift:       conf * float64(r.Transactions) / float64(consCount),
+			})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Lift != b.Lift {
+			return a.Lift > b.Lift
+		}
+		if a.Support != b.Support {
+			return a.Support > b.Support
+		}
+		return a.String() < b.String()
+	})
+	return rules
+}
+
+func (s Itemset) String() string {
+	return fmt.Sprintf("{%s} support %.4f", strings.Join(s.Items, ","), s.Support)
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("{%s} => {%s} support %.4f, confidence %.4f, lift %.4f",
+		strings.Join(r.Antecedent, ","), strings.Join(r.Consequent, ","), r.Support, r.Confidence, r.Lift)
+}
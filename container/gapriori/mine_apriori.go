@@ -0,0 +1,160 @@
+package gapriori
+
+// MineApriori finds frequent itemsets level by level: candidates of size k+1 are joined from
+// frequent itemsets of size k, and pruned if any of their k-subsets is not frequent.
+// Transactions are read from it only once and kept in memory compactly.
+func MineApriori(it Iterator, opts Options) (*Result, error) {
+	d, err := load(it, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []idset
+	var level []idset
+	for id, c := range d.counts {
+		level = append(level, idset{ids: []int{id}, count: c})
+	}
+	for k := 1; len(level) > 0; k++ {
+		found = append(found, level...)
+		if !d.sizeAllowed(k + 1) {
+			break
+		}
+		level = d.countCandidates(nextCandidates(level), k+1)
+	}
+	return d.result(found), nil
+}
+
+// nextCandidates joins sorted k-itemsets which share the first k-1 items.
+func nextCandidates(level []idset) []idset {
+	frequent := make(map[string]bool, len(level))
+	for _, s := range level {
+		frequent[idsKey(s.ids)] = true
+	}
+
+	var res []idset
+	for i := 0; i < len(level); i++ {
+		a := level[i].ids
+		k := len(a)
+		for j := i + 1; j < len(level); j++ {
+			b := level[j].ids
+			if !equalInts(a[:k-1], b[:k-1]) {
+				break
+			}
+			cand := make([]int, k+1)
+			copy(cand, a)
+			cand[k] = b[k-1]
+			if allSubsetsFrequent(cand, frequent) {
+				res = append(res, idset{ids: cand})
+			}
+		}
+	}
+	return res
+}
+
+func equalInts(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// allSubsetsFrequent checks subsets which drop one item, the two joined subsets are frequent already.
+func allSubsetsFrequent(cand []int, frequent map[string]bool) bool {
+	sub := make([]int, 0, len(cand)-1)
+	for skip := 0; skip < len(cand)-2; skip++ {
+		sub = append(sub[:0], cand[:skip]...)
+		sub = append(sub, cand[skip+1:]...)
+		if !frequent[idsKey(sub)] {
+			return false
+		}
+	}
+	return true
+}
+
+// countCandidates counts candidates of size k in transactions and returns frequent ones in the same order.
+func (d *dataset) countCandidates(cands []idset, k int) []idset {
+	if len(cands) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(cands))
+	for i, c := range cands {
+		index[idsKey(c.ids)] = i
+	}
+	for _, tx := range d.txs {
+		if len(tx.ids) < k {
+			continue
+		}
+		// Enumerate k-subsets of short transaction, or test every candidate against long transaction.
+		if combinations(len(tx.ids), k) <= len(cands) {
+			forEachSubset(tx.ids, k, func(sub []int) {
+				if i, ok := index[idsKey(sub)]; ok {
+					cands[i].count += tx.count
+				}
+			})
+			continue
+		}
+		for i := range cands {
+			if isSubset(cands[i].ids, tx.ids) {
+				cands[i].count += tx.count
+			}
+		}
+	}
+
+	var res []idset
+	for _, c := range cands {
+		if c.count >= d.minCount {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// combinations returns C(n, k), or a number larger than any slice length if it overflows.
+func combinations(n, k int) int {
+	const limit = 1 << 40
+	if k > n-k {
+		k = n - k
+	}
+	res := 1
+	for i := 1; i <= k; i++ {
+		res = res * (n - k + i) / i
+		if res > limit {
+			return limit
+		}
+	}
+	return res
+}
+
+func forEachSubset(ids []int, k int, fn func(sub []int)) {
+	sub := make([]int, 0, k)
+	var walk func(start int)
+	walk = func(start int) {
+		if len(sub) == k {
+			fn(sub)
+			return
+		}
+		for i := start; i <= len(ids)-(k-len(sub)); i++ {
+			sub = append(sub, ids[i])
+			walk(i + 1)
+			sub = sub[:len(sub)-1]
+		}
+	}
+	walk(0)
+}
+
+// isSubset checks whether sorted a is subset of sorted b.
+func isSubset(a, b []int) bool {
+	j := 0
+	for _, x := range a {
+		for j < len(b) && b[j] < x {
+			j++
+		}
+		if j == len(b) || b[j] != x {
+			return false
+		}
+		j++
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package gapriori
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// bruteForce counts every subset of every transaction.
+func bruteForce(txs [][]string, minSupport float64, maxSize int) map[string]int {
+	counts := map[string]int{}
+	for _, tx := range txs {
+		items := map[string]bool{}
+		for _, item := range tx {
+			items[item] = true
+		}
+		var uniq []string
+		for item := range items {
+			uniq = append(uniq, item)
+		}
+		for n := 1; n <= len(uniq) && (maxSize == 0 || n <= maxSize); n++ {
+			ids := make([]int, len(uniq))
+			for i := range ids {
+				ids[i] = i
+			}
+			forEachSubset(ids, n, func(sub []int) {
+				set := make([]string, len(sub))
+				for i, id := range sub {
+					set[i] = uniq[id]
+				}
+				sort.Strings(set)
+				counts[itemsKey(set)]++
+			})
+		}
+	}
+	min := int(math.Ceil(minSupport*float64(len(txs)) - 1e-9))
+	for k, c := range counts {
+		if c < min {
+			delete(counts, k)
+		}
+	}
+	return counts
+}
+
+func toMap(r *Result) map[string]int {
+	m := map[string]int{}
+	for _, s := range r.Itemsets {
+		m[itemsKey(s.Items)] = s.Count
+	}
+	return m
+}
+
+func TestMine(t *testing.T) {
+	f, err := os.Open("simple.txt")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+	r, err := MineFPGrowth(NewTableIterator(f), Options{MinSupport: 0.2})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r.Transactions != 10 {
+		t.Errorf("expect 10 transactions, got %d", r.Transactions)
+		return
+	}
+	if c, ok := r.Count("I5", "I2", "I1"); !ok || c != 2 {
+		t.Errorf("unexpected count of {I1,I2,I5} %d", c)
+		return
+	}
+	if _, ok := r.Count("I4", "I5"); ok {
+		t.Error("{I4,I5} should not be frequent")
+		return
+	}
+
+	rules := r.Rules(1)
+	found := false
+	for _, rule := range rules {
+		if rule.Confidence != 1 {
+			t.Errorf("unexpected rule %s", rule)
+			return
+		}
+		if reflect.DeepEqual(rule.Antecedent, []string{"I1", "I5"}) && reflect.DeepEqual(rule.Consequent, []string{"I2"}) {
+			found = true
+			// support(I2) = 0.8, lift = 1 / 0.8.
+			if math.Abs(rule.Support-0.2) > 1e-9 || math.Abs(rule.Lift-1.25) > 1e-9 {
+				t.Errorf("unexpected rule %s", rule)
+				return
+			}
+		}
+	}
+	if !found {
+		t.Errorf("rule {I1,I5} => {I2} not found in %v", rules)
+	}
+}
+
+func TestMine_Compare(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var txs [][]string
+	var lines []string
+	for i := 0; i < 300; i++ {
+		var tx []string
+		for item := 0; item < 12; item++ {
+			// Lower items are more frequent.
+			if rnd.Float64() < 0.6/float64(item/3+1) {
+				tx = append(tx, fmt.Sprintf("i%d", item))
+			}
+		}
+		txs = append(txs, tx)
+		lines = append(lines, strings.Join(tx, ", "))
+	}
+
+	for _, opts := range []Options{{MinSupport: 0.05}, {MinSupport: 0.1, MaxSize: 2}, {MinSupport: 0.3}, {MinSupport: 1}} {
+		expect := bruteForce(txs, opts.MinSupport, opts.MaxSize)
+		ap, err := MineApriori(NewSliceIterator(txs), opts)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		fp, err := MineFPGrowth(NewLineIterator(strings.NewReader(strings.Join(lines, "\n")), ","), opts)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(toMap(ap), expect) {
+			t.Errorf("apriori with %+v: expect %d itemsets, got %d", opts, len(expect), len(ap.Itemsets))
+			return
+		}
+		if !reflect.DeepEqual(ap.Itemsets, fp.Itemsets) {
+			t.Errorf("apriori and fp-growth differ with %+v", opts)
+			return
+		}
+		if !reflect.DeepEqual(ap.Rules(0.5), fp.Rules(0.5)) {
+			t.Errorf("rules differ with %+v", opts)
+			return
+		}
+	}
+
+	if _, err := MineFPGrowth(NewSliceIterator(txs), Options{MinSupport: 0}); err == nil {
+		t.Error("zero min support should fail")
+	}
+}
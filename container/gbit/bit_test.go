@@ -7,3 +7,33 @@ import (
 func TestCount1BitsSlow32(t *testing.T) {
 
 }
+
+func TestWriter(t *testing.T) {
+	// IPv4 first byte: version 4, IHL 5, then 3 bits flags and 13 bits fragment offset.
+	var w Writer
+	w.Write(4, 4)
+	w.Write(5, 4)
+	w.Write(0b010, 3)
+	w.Write(0x1abc, 13)
+	if w.Len() != 24 || string(w.Bytes()) != "\x45\x5a\xbc" {
+		t.Errorf("unexpected bits %x", w.Bytes())
+		return
+	}
+
+	r := NewReader(w.Bytes())
+	for _, c := range []struct {
+		n      int
+		expect uint64
+	}{{4, 4}, {4, 5}, {3, 2}, {13, 0x1abc}} {
+		if v, err := r.Read(c.n); err != nil || v != c.expect {
+			t.Errorf("expect %x, got %x %v", c.expect, v, err)
+			return
+		}
+	}
+	if _, err := r.Read(1); err != ErrShortBits {
+		t.Error("read beyond end should fail")
+	}
+	if Mask(3) != 7 || Mask(64) != ^uint64(0) {
+		t.Error("unexpected mask")
+	}
+}
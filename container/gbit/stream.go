@@ -0,0 +1,82 @@
+package gbit
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+)
+
+// ErrShortBits means there are not enough bits to read.
+var ErrShortBits = gerrors.New("not enough bits")
+
+type (
+	// Writer packs values into bytes from the most significant bit, like bit fields in protocol headers.
+	Writer struct {
+		buf   []byte
+		nbits int
+	}
+
+	// Reader reads values packed by Writer.
+	Reader struct {
+		buf []byte
+		pos int // In bits.
+	}
+)
+
+// Mask returns a value whose lowest n bits are 1.
+func Mask(n int) uint64 {
+	if n >= 64 {
+		return ^uint64(0)
+	}
+	return 1<<uint(n) - 1
+}
+
+// Write appends the lowest n bits of v.
+func (w *Writer) Write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v&(1<<uint(i)) != 0 {
+			w.buf[len(w.buf)-1] |= 1 << uint(7-w.nbits%8)
+		}
+		w.nbits++
+	}
+}
+
+// Len returns number of bits written.
+func (w *Writer) Len() int {
+	return w.nbits
+}
+
+// Bytes returns written bits, the last byte is padded with 0 bits.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+func (w *Writer) Reset() {
+	w.buf, w.nbits = w.buf[:0], 0
+}
+
+func NewReader(buf []byte) *Reader {
+	return &Reader{buf: buf}
+}
+
+// Read reads n bits as an unsigned value.
+func (r *Reader) Read(n int) (uint64, error) {
+	if r.pos+n > len(r.buf)*8 {
+		return 0, ErrShortBits
+	}
+	v := uint64(0)
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.buf[r.pos/8]&(1<<uint(7-r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v, nil
+}
+
+// Pos returns number of bits read.
+func (r *Reader) Pos() int {
+	return r.pos
+}
@@ -1,89 +1,176 @@
 package gbloom
 
 import (
-	"bufio"
 	"github.com/cryptowilliam/goutil/basic/gerrors"
-	"github.com/cryptowilliam/goutil/sys/gfs"
-	"github.com/willf/bloom"
 	"os"
+	"sync"
 )
 
+// BloomFilter is a fixed size bloom filter which can be saved to disk automatically.
 type BloomFilter struct {
-	filter        *bloom.BloomFilter
+	mu            sync.RWMutex
+	l             *bitLayer
 	autoSave      bool
 	savePath      string
 	writeDiskFlag int
-	file          *os.File
 }
 
+// New creates bloom filter for maxCount items with maxFp false positive rate.
+// If savePath exists, filter is loaded from it and maxCount/maxFp are ignored.
+// If autoSave is true, filter is saved to savePath every 100 additions.
 func New(maxCount uint, maxFp float64, autoSave bool, savePath string) (*BloomFilter, error) {
-	if maxCount < 2 || maxFp < 0 || maxFp > 1 {
-		return nil, gerrors.New("invalid bloom filter parameters")
-	}
-	var bf BloomFilter
-	bf.autoSave = autoSave
-	bf.savePath = savePath
-	bf.writeDiskFlag = 0
-	var err error
-	bf.file, err = os.Open(savePath)
-	if err != nil {
-		return nil, err
-	}
-
-	pi, err := gfs.GetPathInfo(savePath)
-	if err != nil {
-		return nil, err
-	}
-	fileSize, err := gfs.GetFileByteSize(savePath)
-	if err != nil {
+	if err := checkParams(maxCount, maxFp); err != nil {
 		return nil, err
 	}
-	if pi.Exist {
-		total := int64(0)
-		for total < fileSize {
-			n, err := bf.filter.ReadFrom(bufio.NewReader(bf.file))
-			if err != nil {
+	bf := &BloomFilter{autoSave: autoSave, savePath: savePath}
+	if savePath != "" {
+		data, err := os.ReadFile(savePath)
+		if err == nil {
+			if err := bf.UnmarshalBinary(data); err != nil {
 				return nil, err
 			}
-			total += n
+			return bf, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
 		}
-
-	} else {
-		bf.filter = bloom.NewWithEstimates(maxCount, maxFp)
-	}
-	if bf.filter == nil {
-		return nil, gerrors.New("bloom filter create fail")
 	}
+	bf.l = newBitLayer(estimateParams(maxCount, maxFp))
+	return bf, nil
+}
 
-	return &bf, nil
+// NewWithEstimates creates in-memory bloom filter for maxCount items with maxFp false positive rate.
+func NewWithEstimates(maxCount uint, maxFp float64) (*BloomFilter, error) {
+	return New(maxCount, maxFp, false, "")
 }
 
 func (bf *BloomFilter) Add(data []byte) {
-	bf.filter.Add(data)
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	bf.l.add(data)
 	bf.writeDiskFlag++
 	if bf.autoSave && bf.writeDiskFlag > 100 {
-		bf.filter.WriteTo(bufio.NewWriter(bf.file))
-		bf.writeDiskFlag = 0
+		bf.saveLocked()
 	}
 }
 
 func (bf *BloomFilter) AddStr(str string) {
-	bf.filter.AddString(str)
-	bf.writeDiskFlag++
-	if bf.autoSave && bf.writeDiskFlag > 100 {
-		bf.filter.WriteTo(bufio.NewWriter(bf.file))
-		bf.writeDiskFlag = 0
-	}
+	bf.Add([]byte(str))
 }
 
 func (bf *BloomFilter) MightContain(data []byte) bool {
-	return bf.filter.Test(data)
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.test(data)
 }
 
 func (bf *BloomFilter) MightContainStr(str string) bool {
-	return bf.filter.TestString(str)
+	return bf.MightContain([]byte(str))
+}
+
+func (bf *BloomFilter) Count() uint64 {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.n
+}
+
+// Cap returns size of filter in bits.
+func (bf *BloomFilter) Cap() uint64 {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.m
+}
+
+// K returns number of hash functions.
+func (bf *BloomFilter) K() uint64 {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.k
+}
+
+// FpRate returns current false positive rate estimated from filled bits.
+func (bf *BloomFilter) FpRate() float64 {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.fpRate()
 }
 
+// Union merges other into bf, after it bf contains items of both filters.
+func (bf *BloomFilter) Union(other *BloomFilter) error {
+	ol := other.snapshot()
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	return bf.l.union(ol)
+}
+
+// Intersect keeps only bits set in both filters, after it bf might contain items of both filters.
+// The false positive rate of intersection is higher than a filter built from common items directly.
+func (bf *BloomFilter) Intersect(other *BloomFilter) error {
+	ol := other.snapshot()
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	return bf.l.intersect(ol)
+}
+
+// snapshot copies bits, so two filters are never locked at the same time.
+func (bf *BloomFilter) snapshot() *bitLayer {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.l.clone()
+}
+
+// Save writes filter to savePath.
+func (bf *BloomFilter) Save() error {
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	return bf.saveLocked()
+}
+
+func (bf *BloomFilter) saveLocked() error {
+	if bf.savePath == "" {
+		return gerrors.New("bloom filter save path is empty")
+	}
+	bf.writeDiskFlag = 0
+	return writeFile(bf.savePath, bf.marshalLocked())
+}
+
+// Reset clears filter and removes saved file.
 func (bf *BloomFilter) Reset() error {
-	return os.Remove(bf.savePath)
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	bf.l = newBitLayer(bf.l.m, bf.l.k)
+	bf.writeDiskFlag = 0
+	if bf.savePath == "" {
+		return nil
+	}
+	if err := os.Remove(bf.savePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (bf *BloomFilter) MarshalBinary() ([]byte, error) {
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+	return bf.marshalLocked(), nil
+}
+
+func (bf *BloomFilter) marshalLocked() []byte {
+	e := newEncoder(kindBloom)
+	bf.l.encode(e)
+	return e.Bytes()
+}
+
+func (bf *BloomFilter) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder(data, kindBloom)
+	if err != nil {
+		return err
+	}
+	l := decodeBitLayer(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	bf.l = l
+	return nil
 }
@@ -0,0 +1,219 @@
+package gbloom
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func falsePositives(f Filter, from, to int) int {
+	res := 0
+	for i := from; i < to; i++ {
+		if f.MightContainStr(fmt.Sprintf("url-%d", i)) {
+			res++
+		}
+	}
+	return res
+}
+
+func TestBloomFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.bloom")
+	bf, err := New(1000, 0.01, true, path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 500; i++ {
+		bf.AddStr(fmt.Sprintf("url-%d", i))
+	}
+	if err := bf.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Reload from file.
+	bf2, err := New(1000, 0.01, true, path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 500; i++ {
+		if !bf2.MightContainStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("url-%d not found after reload", i)
+			return
+		}
+	}
+	if fp := falsePositives(bf2, 500, 10500); fp > 200 {
+		t.Errorf("too many false positives %d", fp)
+		return
+	}
+
+	// Union and intersection.
+	other, _ := NewWithEstimates(1000, 0.01)
+	for i := 400; i < 900; i++ {
+		other.AddStr(fmt.Sprintf("url-%d", i))
+	}
+	inter, _ := NewWithEstimates(1000, 0.01)
+	inter.Union(bf)
+	if err := inter.Intersect(other); err != nil {
+		t.Error(err)
+		return
+	}
+	if !inter.MightContainStr("url-450") || falsePositives(inter, 0, 400) > 40 {
+		t.Error("Intersect failed")
+		return
+	}
+	if err := bf.Union(other); err != nil {
+		t.Error(err)
+		return
+	}
+	if !bf.MightContainStr("url-899") || !bf.MightContainStr("url-0") {
+		t.Error("Union failed")
+		return
+	}
+	if c := bf.Count(); c < 850 || c > 950 {
+		t.Errorf("unexpected estimated count %d", c)
+		return
+	}
+	small, _ := NewWithEstimates(10, 0.01)
+	if err := bf.Union(small); err != ErrIncompatible {
+		t.Errorf("expect ErrIncompatible, got %v", err)
+	}
+}
+
+func TestCountingBloomFilter(t *testing.T) {
+	cf, err := NewCounting(1000, 0.01)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 500; i++ {
+		cf.AddStr(fmt.Sprintf("url-%d", i))
+	}
+	for i := 0; i < 250; i++ {
+		if !cf.RemoveStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("remove url-%d failed", i)
+			return
+		}
+	}
+	for i := 250; i < 500; i++ {
+		if !cf.MightContainStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("url-%d not found", i)
+			return
+		}
+	}
+	if fp := falsePositives(cf, 0, 250); fp > 10 || cf.Count() != 250 {
+		t.Errorf("removed items still found %d", fp)
+		return
+	}
+	if !cf.ToBloomFilter().MightContainStr("url-300") {
+		t.Error("ToBloomFilter failed")
+	}
+}
+
+func TestScalableBloomFilter(t *testing.T) {
+	sf, err := NewScalable(1000, 0.01)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 50000; i++ {
+		sf.AddStr(fmt.Sprintf("url-%d", i))
+	}
+	if sf.Layers() < 5 {
+		t.Errorf("expect at least 5 layers, got %d", sf.Layers())
+		return
+	}
+	if fp := falsePositives(sf, 50000, 100000); fp > 500 {
+		t.Errorf("too many false positives %d", fp)
+		return
+	}
+
+	data, _ := sf.MarshalBinary()
+	f, err := Load(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 50000; i += 7 {
+		if !f.MightContainStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("url-%d not found after load", i)
+			return
+		}
+	}
+
+	other, _ := NewScalable(1000, 0.01)
+	other.AddStr("another")
+	if err := other.Union(sf); err != nil || !other.MightContainStr("url-49999") || !other.MightContainStr("another") {
+		t.Error("Union failed", err)
+	}
+}
+
+func TestCuckooFilter(t *testing.T) {
+	cf, err := NewCuckoo(10000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	n := 0
+	for ; ; n++ {
+		if err := cf.AddStr(fmt.Sprintf("url-%d", n)); err == ErrFull {
+			break
+		}
+	}
+	if cf.LoadFactor() < 0.9 {
+		t.Errorf("load factor too low %f", cf.LoadFactor())
+		return
+	}
+	for i := 0; i < n; i++ {
+		if !cf.MightContainStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("url-%d not found", i)
+			return
+		}
+	}
+	if fp := falsePositives(cf, n+1, n+100000); fp > 100 {
+		t.Errorf("too many false positives %d", fp)
+		return
+	}
+	for i := 0; i < n; i += 2 {
+		if !cf.RemoveStr(fmt.Sprintf("url-%d", i)) {
+			t.Errorf("remove url-%d failed", i)
+			return
+		}
+	}
+	if err := cf.AddStr("new"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "cuckoo")
+	if err := WriteFile(path, cf); err != nil {
+		t.Error(err)
+		return
+	}
+	f, err := ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f.Count() != cf.Count() || !f.MightContainStr("new") || !f.MightContainStr("url-1") {
+		t.Error("ReadFile failed")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	bf, _ := NewWithEstimates(100, 0.01)
+	data, _ := bf.MarshalBinary()
+	if _, err := Load(data[:len(data)-1]); err == nil {
+		t.Error("truncated data should fail")
+	}
+	data[4] = formatVersion + 1
+	if _, err := Load(data); err == nil {
+		t.Error("unsupported version should fail")
+	}
+	var cf CountingBloomFilter
+	data[4] = formatVersion
+	if err := cf.UnmarshalBinary(data); err == nil {
+		t.Error("kind mismatch should fail")
+	}
+}
@@ -0,0 +1,175 @@
+package gbloom
+
+import (
+	"math"
+	"sync"
+)
+
+// CountingBloomFilter uses 8-bit counters instead of bits, so items can be removed.
+// A counter which reaches 255 is never decremented, to avoid false negatives.
+type CountingBloomFilter struct {
+	mu       sync.RWMutex
+	m, k     uint64
+	n        uint64
+	counters []uint8
+}
+
+// NewCounting creates counting bloom filter for maxCount items with maxFp false positive rate,
+// it uses 8 times more memory than BloomFilter.
+func NewCounting(maxCount uint, maxFp float64) (*CountingBloomFilter, error) {
+	if err := checkParams(maxCount, maxFp); err != nil {
+		return nil, err
+	}
+	m, k := estimateParams(maxCount, maxFp)
+	return &CountingBloomFilter{m: m, k: k, counters: make([]uint8, m)}, nil
+}
+
+func (cf *CountingBloomFilter) Add(data []byte) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	for _, loc := range locations(data, cf.m, cf.k) {
+		if cf.counters[loc] < math.MaxUint8 {
+			cf.counters[loc]++
+		}
+	}
+	cf.n++
+}
+
+func (cf *CountingBloomFilter) AddStr(str string) {
+	cf.Add([]byte(str))
+}
+
+// Remove removes data and returns true if data might be in filter before,
+// remove data which was never added may cause false negatives.
+func (cf *CountingBloomFilter) Remove(data []byte) bool {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	locs := locations(data, cf.m, cf.k)
+	for _, loc := range locs {
+		if cf.counters[loc] == 0 {
+			return false
+		}
+	}
+	for _, loc := range locs {
+		if cf.counters[loc] < math.MaxUint8 {
+			cf.counters[loc]--
+		}
+	}
+	if cf.n > 0 {
+		cf.n--
+	}
+	return true
+}
+
+func (cf *CountingBloomFilter) RemoveStr(str string) bool {
+	return cf.Remove([]byte(str))
+}
+
+func (cf *CountingBloomFilter) MightContain(data []byte) bool {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	for _, loc := range locations(data, cf.m, cf.k) {
+		if cf.counters[loc] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (cf *CountingBloomFilter) MightContainStr(str string) bool {
+	return cf.MightContain([]byte(str))
+}
+
+func (cf *CountingBloomFilter) Count() uint64 {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	return cf.n
+}
+
+// Union adds items of other into cf.
+func (cf *CountingBloomFilter) Union(other *CountingBloomFilter) error {
+	counters, k, n := other.snapshot()
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	if len(counters) != len(cf.counters) || k != cf.k {
+		return ErrIncompatible
+	}
+	for i, c := range counters {
+		if sum := int(cf.counters[i]) + int(c); sum < math.MaxUint8 {
+			cf.counters[i] = uint8(sum)
+		} else {
+			cf.counters[i] = math.MaxUint8
+		}
+	}
+	cf.n += n
+	return nil
+}
+
+// Intersect keeps the minimum of counters, after it cf might contain items of both filters.
+func (cf *CountingBloomFilter) Intersect(other *CountingBloomFilter) error {
+	counters, k, _ := other.snapshot()
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	if len(counters) != len(cf.counters) || k != cf.k {
+		return ErrIncompatible
+	}
+	ones := uint64(0)
+	for i, c := range counters {
+		if c < cf.counters[i] {
+			cf.counters[i] = c
+		}
+		if cf.counters[i] > 0 {
+			ones++
+		}
+	}
+	cf.n = estimateCount(cf.m, cf.k, ones)
+	return nil
+}
+
+func (cf *CountingBloomFilter) snapshot() ([]uint8, uint64, uint64) {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	return append([]uint8{}, cf.counters...), cf.k, cf.n
+}
+
+// ToBloomFilter converts to a BloomFilter with the same hashing, so it can be united with other BloomFilters.
+func (cf *CountingBloomFilter) ToBloomFilter() *BloomFilter {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	l := newBitLayer(cf.m, cf.k)
+	for i, c := range cf.counters {
+		if c > 0 {
+			l.words[i/64] |= 1 << (uint(i) % 64)
+		}
+	}
+	l.n = cf.n
+	return &BloomFilter{l: l}
+}
+
+func (cf *CountingBloomFilter) MarshalBinary() ([]byte, error) {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	e := newEncoder(kindCounting)
+	e.u64(cf.m, cf.k, cf.n)
+	e.Write(cf.counters)
+	return e.Bytes(), nil
+}
+
+func (cf *CountingBloomFilter) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder(data, kindCounting)
+	if err != nil {
+		return err
+	}
+	m, k, n := d.u64(), d.u64(), d.u64()
+	if d.err == nil && (m == 0 || k == 0) {
+		d.fail()
+	}
+	counters := append([]uint8{}, d.bytes(m)...)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.m, cf.k, cf.n, cf.counters = m, k, n, counters
+	return nil
+}
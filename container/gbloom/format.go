@@ -0,0 +1,194 @@
+package gbloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Binary format of all filters:
+// magic "GBLM" | version (1 byte) | kind (1 byte) | body in big endian.
+// Decoders must accept every version up to formatVersion.
+
+type (
+	// Filter is the common interface of BloomFilter, CountingBloomFilter,
+	// ScalableBloomFilter and CuckooFilter.
+	Filter interface {
+		MightContain(data []byte) bool
+		MightContainStr(str string) bool
+		// Count returns approximate number of added items.
+		Count() uint64
+		MarshalBinary() ([]byte, error)
+		UnmarshalBinary(data []byte) error
+	}
+
+	kind uint8
+
+	encoder struct {
+		bytes.Buffer
+	}
+
+	decoder struct {
+		data []byte
+		err  error
+	}
+)
+
+const (
+	formatMagic   = "GBLM"
+	formatVersion = 1
+
+	kindBloom    kind = 1
+	kindCounting kind = 2
+	kindScalable kind = 3
+	kindCuckoo   kind = 4
+)
+
+var ErrInvalidFormat = gerrors.New("invalid bloom filter data")
+
+func newEncoder(k kind) *encoder {
+	e := &encoder{}
+	e.WriteString(formatMagic)
+	e.WriteByte(formatVersion)
+	e.WriteByte(byte(k))
+	return e
+}
+
+func (e *encoder) u64(vs ...uint64) {
+	var buf [8]byte
+	for _, v := range vs {
+		binary.BigEndian.PutUint64(buf[:], v)
+		e.Write(buf[:])
+	}
+}
+
+func (e *encoder) f64(v float64) {
+	e.u64(math.Float64bits(v))
+}
+
+// readHeader returns kind of filter data.
+func readHeader(data []byte) (kind, error) {
+	if len(data) < len(formatMagic)+2 || string(data[:len(formatMagic)]) != formatMagic {
+		return 0, ErrInvalidFormat
+	}
+	if v := data[len(formatMagic)]; v == 0 || v > formatVersion {
+		return 0, gerrors.Errorf("unsupported bloom filter format version %d", v)
+	}
+	return kind(data[len(formatMagic)+1]), nil
+}
+
+// newDecoder checks header and returns decoder of body.
+func newDecoder(data []byte, expect kind) (*decoder, error) {
+	k, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if k != expect {
+		return nil, gerrors.Errorf("bloom filter data kind %d mismatch, expect %d", k, expect)
+	}
+	return &decoder{data: data[len(formatMagic)+2:]}, nil
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = ErrInvalidFormat
+	}
+}
+
+func (d *decoder) remain() uint64 {
+	return uint64(len(d.data))
+}
+
+func (d *decoder) u64() uint64 {
+	if len(d.data) < 8 {
+		d.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	return v
+}
+
+func (d *decoder) f64() float64 {
+	return math.Float64frombits(d.u64())
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if uint64(len(d.data)) < n {
+		d.fail()
+		return nil
+	}
+	v := d.data[:n]
+	d.data = d.data[n:]
+	return v
+}
+
+// finish returns decode error, trailing bytes are treated as error too.
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.fail()
+	}
+	return d.err
+}
+
+// Load decodes any filter serialized by MarshalBinary.
+func Load(data []byte) (Filter, error) {
+	k, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	var f Filter
+	switch k {
+	case kindBloom:
+		f = &BloomFilter{}
+	case kindCounting:
+		f = &CountingBloomFilter{}
+	case kindScalable:
+		f = &ScalableBloomFilter{}
+	case kindCuckoo:
+		f = &CuckooFilter{}
+	default:
+		return nil, gerrors.Errorf("unknown bloom filter data kind %d", k)
+	}
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadFile loads filter saved by WriteFile.
+func ReadFile(path string) (Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// WriteFile saves filter to path atomically, so a crash never leaves a truncated file.
+func WriteFile(path string, f Filter) error {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
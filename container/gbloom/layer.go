@@ -0,0 +1,139 @@
+package gbloom
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/willf/bloom"
+	"math"
+	"math/bits"
+)
+
+var ErrIncompatible = gerrors.New("bloom filters are incompatible, they must have the same size and hash count")
+
+// bitLayer is a plain bloom filter bit array, it is not concurrency-safe.
+type bitLayer struct {
+	m, k  uint64
+	n     uint64 // number of added items which set at least one new bit
+	words []uint64
+}
+
+func checkParams(maxCount uint, maxFp float64) error {
+	if maxCount < 2 || maxFp <= 0 || maxFp >= 1 {
+		return gerrors.New("invalid bloom filter parameters")
+	}
+	return nil
+}
+
+// estimateParams returns bit count m and hash count k for maxCount items with maxFp false positive rate.
+func estimateParams(maxCount uint, maxFp float64) (uint64, uint64) {
+	m, k := bloom.EstimateParameters(maxCount, maxFp)
+	return uint64(m), uint64(k)
+}
+
+func newBitLayer(m, k uint64) *bitLayer {
+	return &bitLayer{m: m, k: k, words: make([]uint64, (m+63)/64)}
+}
+
+// locations returns k bit positions of data in a filter with m bits,
+// all filters of this package share the same hashing, so layers with the same m and k are compatible.
+func locations(data []byte, m, k uint64) []uint64 {
+	locs := bloom.Locations(data, uint(k))
+	for i := range locs {
+		locs[i] %= m
+	}
+	return locs
+}
+
+func (l *bitLayer) add(data []byte) {
+	changed := false
+	for _, loc := range locations(data, l.m, l.k) {
+		w, b := loc/64, uint64(1)<<(loc%64)
+		if l.words[w]&b == 0 {
+			l.words[w] |= b
+			changed = true
+		}
+	}
+	if changed {
+		l.n++
+	}
+}
+
+func (l *bitLayer) test(data []byte) bool {
+	for _, loc := range locations(data, l.m, l.k) {
+		if l.words[loc/64]&(uint64(1)<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *bitLayer) ones() uint64 {
+	res := 0
+	for _, w := range l.words {
+		res += bits.OnesCount64(w)
+	}
+	return uint64(res)
+}
+
+// fpRate returns current false positive rate estimated from the ratio of set bits.
+func (l *bitLayer) fpRate() float64 {
+	return math.Pow(float64(l.ones())/float64(l.m), float64(l.k))
+}
+
+func (l *bitLayer) compatible(other *bitLayer) bool {
+	return l.m == other.m && l.k == other.k
+}
+
+func (l *bitLayer) clone() *bitLayer {
+	res := *l
+	res.words = append([]uint64{}, l.words...)
+	return &res
+}
+
+func (l *bitLayer) union(other *bitLayer) error {
+	if !l.compatible(other) {
+		return ErrIncompatible
+	}
+	for i := range l.words {
+		l.words[i] |= other.words[i]
+	}
+	l.n = estimateCount(l.m, l.k, l.ones())
+	return nil
+}
+
+func (l *bitLayer) intersect(other *bitLayer) error {
+	if !l.compatible(other) {
+		return ErrIncompatible
+	}
+	for i := range l.words {
+		l.words[i] &= other.words[i]
+	}
+	l.n = estimateCount(l.m, l.k, l.ones())
+	return nil
+}
+
+// estimateCount estimates item count from set bit count, see Swamidass & Baldi (2007).
+func estimateCount(m, k, ones uint64) uint64 {
+	if ones >= m {
+		return m / k
+	}
+	return uint64(math.Round(-float64(m) / float64(k) * math.Log(1-float64(ones)/float64(m))))
+}
+
+func (l *bitLayer) encode(e *encoder) {
+	e.u64(l.m, l.k, l.n)
+	e.u64(l.words...)
+}
+
+func decodeBitLayer(d *decoder) *bitLayer {
+	m, k, n := d.u64(), d.u64(), d.u64()
+	if d.err != nil || m == 0 || k == 0 || m > d.remain()*8 {
+		d.fail()
+		return nil
+	}
+	l := newBitLayer(m, k)
+	l.n = n
+	for i := range l.words {
+		l.words[i] = d.u64()
+	}
+	return l
+}
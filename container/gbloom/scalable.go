@@ -0,0 +1,194 @@
+package gbloom
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"math"
+	"sync"
+)
+
+type (
+	// ScalableBloomFilter adds a new larger layer when current layer is full, so it never outgrows,
+	// and total false positive rate stays below maxFp, see Almeida et al. "Scalable Bloom Filters" (2007).
+	ScalableBloomFilter struct {
+		mu         sync.RWMutex
+		initialCap uint64
+		maxFp      float64
+		growth     uint64
+		tightening float64
+		layers     []*scalableLayer
+	}
+
+	scalableLayer struct {
+		*bitLayer
+		cap uint64
+	}
+)
+
+const (
+	DefaultGrowth     = 2
+	DefaultTightening = 0.8
+)
+
+// NewScalable creates scalable bloom filter whose first layer holds initialCap items,
+// every new layer is DefaultGrowth times larger than the previous one.
+func NewScalable(initialCap uint, maxFp float64) (*ScalableBloomFilter, error) {
+	return NewScalableEx(initialCap, maxFp, DefaultGrowth, DefaultTightening)
+}
+
+// NewScalableEx creates scalable bloom filter with layer growth factor and false positive tightening ratio,
+// the false positive rate of layer i is maxFp * (1 - tightening) * tightening^i.
+func NewScalableEx(initialCap uint, maxFp float64, growth uint, tightening float64) (*ScalableBloomFilter, error) {
+	if err := checkParams(initialCap, maxFp); err != nil {
+		return nil, err
+	}
+	if growth < 1 || tightening <= 0 || tightening >= 1 {
+		return nil, gerrors.New("invalid scalable bloom filter parameters")
+	}
+	sf := &ScalableBloomFilter{initialCap: uint64(initialCap), maxFp: maxFp, growth: uint64(growth), tightening: tightening}
+	sf.addLayer()
+	return sf, nil
+}
+
+func (sf *ScalableBloomFilter) addLayer() {
+	i := len(sf.layers)
+	c := sf.initialCap * uint64(math.Pow(float64(sf.growth), float64(i)))
+	fp := sf.maxFp * (1 - sf.tightening) * math.Pow(sf.tightening, float64(i))
+	sf.layers = append(sf.layers, &scalableLayer{bitLayer: newBitLayer(estimateParams(uint(c), fp)), cap: c})
+}
+
+func (sf *ScalableBloomFilter) testLocked(data []byte) bool {
+	for i := len(sf.layers) - 1; i >= 0; i-- {
+		if sf.layers[i].test(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds data if it is not in filter yet, so duplicated items don't fill layers.
+func (sf *ScalableBloomFilter) Add(data []byte) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.testLocked(data) {
+		return
+	}
+	last := sf.layers[len(sf.layers)-1]
+	if last.n >= last.cap {
+		sf.addLayer()
+		last = sf.layers[len(sf.layers)-1]
+	}
+	last.add(data)
+}
+
+func (sf *ScalableBloomFilter) AddStr(str string) {
+	sf.Add([]byte(str))
+}
+
+func (sf *ScalableBloomFilter) MightContain(data []byte) bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.testLocked(data)
+}
+
+func (sf *ScalableBloomFilter) MightContainStr(str string) bool {
+	return sf.MightContain([]byte(str))
+}
+
+func (sf *ScalableBloomFilter) Count() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	res := uint64(0)
+	for _, l := range sf.layers {
+		res += l.n
+	}
+	return res
+}
+
+// Layers returns number of layers.
+func (sf *ScalableBloomFilter) Layers() int {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return len(sf.layers)
+}
+
+// FpRate returns current false positive rate estimated from filled bits of all layers.
+func (sf *ScalableBloomFilter) FpRate() float64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	pass := 1.0
+	for _, l := range sf.layers {
+		pass *= 1 - l.fpRate()
+	}
+	return 1 - pass
+}
+
+// Union merges other into sf, both filters must be created with the same parameters.
+// Layers are merged one by one, so a merged layer may hold more items than its capacity.
+func (sf *ScalableBloomFilter) Union(other *ScalableBloomFilter) error {
+	o := other.snapshot()
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.initialCap != o.initialCap || sf.maxFp != o.maxFp || sf.growth != o.growth || sf.tightening != o.tightening {
+		return ErrIncompatible
+	}
+	for i, l := range o.layers {
+		if i >= len(sf.layers) {
+			sf.layers = append(sf.layers, l)
+			continue
+		}
+		if err := sf.layers[i].union(l.bitLayer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sf *ScalableBloomFilter) snapshot() *ScalableBloomFilter {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	res := &ScalableBloomFilter{initialCap: sf.initialCap, maxFp: sf.maxFp, growth: sf.growth, tightening: sf.tightening}
+	for _, l := range sf.layers {
+		res.layers = append(res.layers, &scalableLayer{bitLayer: l.clone(), cap: l.cap})
+	}
+	return res
+}
+
+func (sf *ScalableBloomFilter) MarshalBinary() ([]byte, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	e := newEncoder(kindScalable)
+	e.u64(sf.initialCap)
+	e.f64(sf.maxFp)
+	e.u64(sf.growth)
+	e.f64(sf.tightening)
+	e.u64(uint64(len(sf.layers)))
+	for _, l := range sf.layers {
+		e.u64(l.cap)
+		l.encode(e)
+	}
+	return e.Bytes(), nil
+}
+
+func (sf *ScalableBloomFilter) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder(data, kindScalable)
+	if err != nil {
+		return err
+	}
+	res := &ScalableBloomFilter{initialCap: d.u64(), maxFp: d.f64(), growth: d.u64(), tightening: d.f64()}
+	count := d.u64()
+	if d.err == nil && (count == 0 || count > d.remain()) {
+		d.fail()
+	}
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		c := d.u64()
+		l := decodeBitLayer(d)
+		res.layers = append(res.layers, &scalableLayer{bitLayer: l, cap: c})
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.initialCap, sf.maxFp, sf.growth, sf.tightening, sf.layers = res.initialCap, res.maxFp, res.growth, res.tightening, res.layers
+	return nil
+}
@@ -0,0 +1,198 @@
+package gcollection
+
+import (
+	"errors"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[int](true, 2)
+	if err := q.PushNoWait(1); err != nil {
+		t.Error(err)
+		return
+	}
+	q.PushNoWait(2)
+	if err := q.PushNoWait(3); err != ErrFull {
+		t.Errorf("expect ErrFull, got %v", err)
+		return
+	}
+	if err := q.PushWait(3, 20*time.Millisecond); !errors.Is(err, gerrors.ErrTimeout) {
+		t.Errorf("expect timeout, got %v", err)
+		return
+	}
+
+	// Blocked push continues after pop.
+	done := make(chan error)
+	go func() { done <- q.PushWait(3, 0) }()
+	time.Sleep(10 * time.Millisecond)
+	if v, ok := q.PopNoWait(); !ok || v != 1 {
+		t.Errorf("expect 1, got %v", v)
+		return
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+		return
+	}
+	for _, expect := range []int{2, 3} {
+		if v, ok := q.PopWait(time.Second); !ok || v != expect {
+			t.Errorf("expect %d, got %v", expect, v)
+			return
+		}
+	}
+	if _, ok := q.PopWait(10 * time.Millisecond); ok {
+		t.Error("pop from empty queue should timeout")
+		return
+	}
+
+	// Producers and consumers.
+	q = NewQueue[int](true, 4)
+	wg := sync.WaitGroup{}
+	sum := 0
+	mu := sync.Mutex{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(base int) {
+			defer wg.Done()
+			for j := 1; j <= 100; j++ {
+				q.PushWait(base+j, 0)
+			}
+		}(i * 1000)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				v, _ := q.PopWait(0)
+				mu.Lock()
+				sum += v
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if sum != 4*5050+100*(1000+2000+3000) {
+		t.Errorf("unexpected sum %d", sum)
+	}
+}
+
+func TestDeque(t *testing.T) {
+	d := NewDeque[string](false, 0)
+	for i := 0; i < 40; i++ {
+		d.PushBack("b")
+		d.PushFront("f")
+	}
+	if d.Size() != 80 {
+		t.Errorf("expect 80, got %d", d.Size())
+		return
+	}
+	if v, _ := d.PeekFront(); v != "f" {
+		t.Error("front should be f")
+		return
+	}
+	if v, _ := d.Get(-1); v != "b" {
+		t.Error("back should be b")
+		return
+	}
+	for i := 0; i < 40; i++ {
+		if v, _ := d.PopBack(); v != "b" {
+			t.Error("pop back should be b")
+			return
+		}
+		if v, _ := d.PopFront(); v != "f" {
+			t.Error("pop front should be f")
+			return
+		}
+	}
+	if _, ok := d.PopFrontWait(time.Second); ok {
+		t.Error("empty deque which is not concurrency-safe should not wait")
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	pq := NewPriorityQueue(func(a, b int) bool { return a > b }, true, 0)
+	for _, v := range []int{3, 9, 1, 7, 5} {
+		pq.PushNoWait(v)
+	}
+	if v, _ := pq.Peek(); v != 9 {
+		t.Errorf("expect 9, got %d", v)
+		return
+	}
+	var res []int
+	for pq.Size() > 0 {
+		v, _ := pq.PopNoWait()
+		res = append(res, v)
+	}
+	if !reflect.DeepEqual(res, []int{9, 7, 5, 3, 1}) {
+		t.Errorf("unexpected order %v", res)
+	}
+}
+
+func TestSet(t *testing.T) {
+	a := NewSet(true, 1, 2, 3, 4)
+	b := NewSet(false, 3, 4, 5)
+	sorted := func(s *Set[int]) []int {
+		items := s.Items()
+		sort.Ints(items)
+		return items
+	}
+	if !reflect.DeepEqual(sorted(a.Union(b)), []int{1, 2, 3, 4, 5}) {
+		t.Error("Union failed")
+	}
+	if !reflect.DeepEqual(sorted(a.Intersect(b)), []int{3, 4}) {
+		t.Error("Intersect failed")
+	}
+	if !reflect.DeepEqual(sorted(a.Difference(b)), []int{1, 2}) {
+		t.Error("Difference failed")
+	}
+	if !a.Intersect(b).IsSubset(a) || a.IsSubset(b) || !a.Equal(a) {
+		t.Error("IsSubset failed")
+	}
+	a.Remove(1, 2)
+	if a.Contains(1) || !a.Contains(3, 4) || a.Size() != 2 {
+		t.Error("Remove failed")
+	}
+}
+
+func TestSortedList(t *testing.T) {
+	sl := NewSortedList[int64, string](true)
+	sl.Insert(10, "ten")
+	sl.Insert(15, "fifteen")
+	sl.Insert(5, "five")
+	sl.Insert(10, "ten2")
+	sl.Insert(9, "nine")
+
+	var keys []int64
+	for _, e := range sl.Range(6, 15) {
+		keys = append(keys, e.Key)
+	}
+	if !reflect.DeepEqual(keys, []int64{9, 10, 10}) {
+		t.Errorf("unexpected range %v", keys)
+		return
+	}
+	if e, _ := sl.Floor(11); e.Value != "ten2" {
+		t.Errorf("unexpected floor %v", e)
+		return
+	}
+	if e, _ := sl.Ceiling(11); e.Key != 15 {
+		t.Errorf("unexpected ceiling %v", e)
+		return
+	}
+	if v, _ := sl.Get(10); v != "ten" {
+		t.Errorf("unexpected get %v", v)
+		return
+	}
+	if n := sl.Delete(10); n != 2 || sl.Len() != 3 {
+		t.Errorf("unexpected delete %d %d", n, sl.Len())
+		return
+	}
+	if e, _ := sl.PopMin(); e.Key != 5 {
+		t.Error("PopMin failed")
+		return
+	}
+	if e, _ := sl.PopMax(); e.Key != 15 {
+		t.Error("PopMax failed")
+	}
+}
@@ -0,0 +1,93 @@
+// Package gcollection provides generic, type-safe versions of gqueue, gset and glist containers.
+// Every container can be created concurrency-safe or not, and queues can have capacity
+// with PushWait/PopWait timeouts like gqueue.QueueEx.
+package gcollection
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"sync"
+	"time"
+)
+
+type (
+	// Ordered is the constraint of keys which support < operator.
+	Ordered interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+			~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+			~float32 | ~float64 | ~string
+	}
+
+	// guard locks container if it is concurrency-safe, and wakes up waiters when container changes.
+	guard struct {
+		safe    bool
+		mu      sync.RWMutex
+		changed chan struct{}
+	}
+)
+
+var ErrFull = gerrors.New("container is full")
+
+func newGuard(concurrentSafe bool) guard {
+	return guard{safe: concurrentSafe, changed: make(chan struct{})}
+}
+
+func (g *guard) lock() {
+	if g.safe {
+		g.mu.Lock()
+	}
+}
+
+func (g *guard) unlock() {
+	if g.safe {
+		g.mu.Unlock()
+	}
+}
+
+func (g *guard) rlock() {
+	if g.safe {
+		g.mu.RLock()
+	}
+}
+
+func (g *guard) runlock() {
+	if g.safe {
+		g.mu.RUnlock()
+	}
+}
+
+// broadcast wakes up all waiters, it must be called with lock held.
+func (g *guard) broadcast() {
+	if !g.safe {
+		return
+	}
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// waitLocked blocks until ready returns true, and returns with lock held.
+// timeout <= 0 means wait forever. It returns gerrors.ErrTimeout without lock held if timeout.
+func (g *guard) waitLocked(ready func() bool, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		g.lock()
+		if ready() {
+			return nil
+		}
+		changed := g.changed
+		g.unlock()
+		if !g.safe {
+			// Nobody else can change a container which is not concurrency-safe.
+			return gerrors.ErrTimeout
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return gerrors.ErrTimeout
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package gcollection
+
+import (
+	"container/heap"
+	"time"
+)
+
+type (
+	// PriorityQueue pops the smallest element decided by less first.
+	PriorityQueue[T any] struct {
+		g        guard
+		h        *pqHeap[T]
+		capacity int
+	}
+
+	pqHeap[T any] struct {
+		items []T
+		less  func(a, b T) bool
+	}
+)
+
+// NewPriorityQueue creates priority queue, capacity <= 0 means unlimited capacity.
+// Use greater-than as less to make a max priority queue.
+func NewPriorityQueue[T any](less func(a, b T) bool, concurrentSafe bool, capacity int) *PriorityQueue[T] {
+	return &PriorityQueue[T]{g: newGuard(concurrentSafe), h: &pqHeap[T]{less: less}, capacity: capacity}
+}
+
+func (h *pqHeap[T]) Len() int           { return len(h.items) }
+func (h *pqHeap[T]) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *pqHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *pqHeap[T]) Push(x interface{}) { h.items = append(h.items, x.(T)) }
+
+func (h *pqHeap[T]) Pop() interface{} {
+	var zero T
+	x := h.items[len(h.items)-1]
+	h.items[len(h.items)-1] = zero
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+func (pq *PriorityQueue[T]) full() bool {
+	return pq.capacity > 0 && pq.h.Len() >= pq.capacity
+}
+
+// PushNoWait returns ErrFull if queue is full.
+func (pq *PriorityQueue[T]) PushNoWait(x T) error {
+	pq.g.lock()
+	defer pq.g.unlock()
+	if pq.full() {
+		return ErrFull
+	}
+	heap.Push(pq.h, x)
+	pq.g.broadcast()
+	return nil
+}
+
+// PushWait waits until queue is not full, timeout <= 0 means wait forever.
+func (pq *PriorityQueue[T]) PushWait(x T, timeout time.Duration) error {
+	if err := pq.g.waitLocked(func() bool { return !pq.full() }, timeout); err != nil {
+		return err
+	}
+	defer pq.g.unlock()
+	heap.Push(pq.h, x)
+	pq.g.broadcast()
+	return nil
+}
+
+func (pq *PriorityQueue[T]) pop() (T, bool) {
+	if pq.h.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	x := heap.Pop(pq.h).(T)
+	pq.g.broadcast()
+	return x, true
+}
+
+// PopNoWait returns false if queue is empty.
+func (pq *PriorityQueue[T]) PopNoWait() (T, bool) {
+	pq.g.lock()
+	defer pq.g.unlock()
+	return pq.pop()
+}
+
+// PopWait waits until queue is not empty, timeout <= 0 means wait forever.
+func (pq *PriorityQueue[T]) PopWait(timeout time.Duration) (T, bool) {
+	if err := pq.g.waitLocked(func() bool { return pq.h.Len() > 0 }, timeout); err != nil {
+		var zero T
+		return zero, false
+	}
+	defer pq.g.unlock()
+	return pq.pop()
+}
+
+// Peek returns the smallest element without removing it.
+func (pq *PriorityQueue[T]) Peek() (T, bool) {
+	pq.g.rlock()
+	defer pq.g.runlock()
+	if pq.h.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return pq.h.items[0], true
+}
+
+func (pq *PriorityQueue[T]) Size() int {
+	pq.g.rlock()
+	defer pq.g.runlock()
+	return pq.h.Len()
+}
+
+func (pq *PriorityQueue[T]) Clear() {
+	pq.g.lock()
+	defer pq.g.unlock()
+	pq.h.items = nil
+	pq.g.broadcast()
+}
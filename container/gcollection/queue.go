@@ -0,0 +1,186 @@
+package gcollection
+
+import "time"
+
+type (
+	// Deque is a double-ended queue, it is the generic version of gqueue.QueueEx with both ends usable.
+	Deque[T any] struct {
+		g        guard
+		r        ring[T]
+		capacity int
+	}
+
+	// Queue is a FIFO queue, it is the generic version of gqueue.QueueEx.
+	Queue[T any] struct {
+		d *Deque[T]
+	}
+)
+
+// NewDeque creates deque, capacity <= 0 means unlimited capacity.
+func NewDeque[T any](concurrentSafe bool, capacity int) *Deque[T] {
+	return &Deque[T]{g: newGuard(concurrentSafe), r: newRing[T](), capacity: capacity}
+}
+
+func (d *Deque[T]) full() bool {
+	return d.capacity > 0 && d.r.len() >= d.capacity
+}
+
+func (d *Deque[T]) push(x T, front bool) {
+	if front {
+		d.r.pushFront(x)
+	} else {
+		d.r.pushBack(x)
+	}
+	d.g.broadcast()
+}
+
+func (d *Deque[T]) pushNoWait(x T, front bool) error {
+	d.g.lock()
+	defer d.g.unlock()
+	if d.full() {
+		return ErrFull
+	}
+	d.push(x, front)
+	return nil
+}
+
+func (d *Deque[T]) pushWait(x T, front bool, timeout time.Duration) error {
+	if err := d.g.waitLocked(func() bool { return !d.full() }, timeout); err != nil {
+		return err
+	}
+	defer d.g.unlock()
+	d.push(x, front)
+	return nil
+}
+
+func (d *Deque[T]) pop(front bool) (T, bool) {
+	var x T
+	var ok bool
+	if front {
+		x, ok = d.r.popFront()
+	} else {
+		x, ok = d.r.popBack()
+	}
+	if ok {
+		d.g.broadcast()
+	}
+	return x, ok
+}
+
+func (d *Deque[T]) popNoWait(front bool) (T, bool) {
+	d.g.lock()
+	defer d.g.unlock()
+	return d.pop(front)
+}
+
+func (d *Deque[T]) popWait(front bool, timeout time.Duration) (T, bool) {
+	if err := d.g.waitLocked(func() bool { return d.r.len() > 0 }, timeout); err != nil {
+		var zero T
+		return zero, false
+	}
+	defer d.g.unlock()
+	return d.pop(front)
+}
+
+// PushFront returns ErrFull if deque is full.
+func (d *Deque[T]) PushFront(x T) error {
+	return d.pushNoWait(x, true)
+}
+
+// PushBack returns ErrFull if deque is full.
+func (d *Deque[T]) PushBack(x T) error {
+	return d.pushNoWait(x, false)
+}
+
+// PushFrontWait waits until deque is not full, timeout <= 0 means wait forever.
+func (d *Deque[T]) PushFrontWait(x T, timeout time.Duration) error {
+	return d.pushWait(x, true, timeout)
+}
+
+// PushBackWait waits until deque is not full, timeout <= 0 means wait forever.
+func (d *Deque[T]) PushBackWait(x T, timeout time.Duration) error {
+	return d.pushWait(x, false, timeout)
+}
+
+func (d *Deque[T]) PopFront() (T, bool) {
+	return d.popNoWait(true)
+}
+
+func (d *Deque[T]) PopBack() (T, bool) {
+	return d.popNoWait(false)
+}
+
+// PopFrontWait waits until deque is not empty, timeout <= 0 means wait forever.
+func (d *Deque[T]) PopFrontWait(timeout time.Duration) (T, bool) {
+	return d.popWait(true, timeout)
+}
+
+// PopBackWait waits until deque is not empty, timeout <= 0 means wait forever.
+func (d *Deque[T]) PopBackWait(timeout time.Duration) (T, bool) {
+	return d.popWait(false, timeout)
+}
+
+// Get returns element at index i, negative index counts from back, -1 refers to the last element.
+func (d *Deque[T]) Get(i int) (T, bool) {
+	d.g.rlock()
+	defer d.g.runlock()
+	return d.r.get(i)
+}
+
+func (d *Deque[T]) PeekFront() (T, bool) {
+	return d.Get(0)
+}
+
+func (d *Deque[T]) PeekBack() (T, bool) {
+	return d.Get(-1)
+}
+
+func (d *Deque[T]) Size() int {
+	d.g.rlock()
+	defer d.g.runlock()
+	return d.r.len()
+}
+
+func (d *Deque[T]) Clear() {
+	d.g.lock()
+	defer d.g.unlock()
+	d.r.clear()
+	d.g.broadcast()
+}
+
+// NewQueue creates queue, capacity <= 0 means unlimited capacity.
+func NewQueue[T any](concurrentSafe bool, capacity int) *Queue[T] {
+	return &Queue[T]{d: NewDeque[T](concurrentSafe, capacity)}
+}
+
+// PushNoWait returns ErrFull if queue is full.
+func (q *Queue[T]) PushNoWait(x T) error {
+	return q.d.PushBack(x)
+}
+
+// PushWait waits until queue is not full, timeout <= 0 means wait forever.
+func (q *Queue[T]) PushWait(x T, timeout time.Duration) error {
+	return q.d.PushBackWait(x, timeout)
+}
+
+// PopNoWait returns false if queue is empty.
+func (q *Queue[T]) PopNoWait() (T, bool) {
+	return q.d.PopFront()
+}
+
+// PopWait waits until queue is not empty, timeout <= 0 means wait forever.
+func (q *Queue[T]) PopWait(timeout time.Duration) (T, bool) {
+	return q.d.PopFrontWait(timeout)
+}
+
+func (q *Queue[T]) Peek() (T, bool) {
+	return q.d.PeekFront()
+}
+
+func (q *Queue[T]) Size() int {
+	return q.d.Size()
+}
+
+func (q *Queue[T]) Clear() {
+	q.d.Clear()
+}
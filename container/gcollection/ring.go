@@ -0,0 +1,102 @@
+package gcollection
+
+// minRingLen must be power of 2 for bitwise modulus, like gqueue.rawQueue.
+const minRingLen = 16
+
+// ring is a growable ring buffer, it is not concurrency-safe.
+type ring[T any] struct {
+	buf               []T
+	head, tail, count int
+}
+
+func newRing[T any]() ring[T] {
+	return ring[T]{buf: make([]T, minRingLen)}
+}
+
+func (r *ring[T]) len() int {
+	return r.count
+}
+
+// resize makes buffer fit twice of its contents, it can shrink too.
+func (r *ring[T]) resize() {
+	n := r.count << 1
+	if n < minRingLen {
+		n = minRingLen
+	}
+	newBuf := make([]T, n)
+	if r.tail > r.head {
+		copy(newBuf, r.buf[r.head:r.tail])
+	} else if r.count > 0 {
+		m := copy(newBuf, r.buf[r.head:])
+		copy(newBuf[m:], r.buf[:r.tail])
+	}
+	r.head = 0
+	r.tail = r.count
+	r.buf = newBuf
+}
+
+func (r *ring[T]) pushBack(x T) {
+	if r.count == len(r.buf) {
+		r.resize()
+	}
+	r.buf[r.tail] = x
+	r.tail = (r.tail + 1) & (len(r.buf) - 1)
+	r.count++
+}
+
+func (r *ring[T]) pushFront(x T) {
+	if r.count == len(r.buf) {
+		r.resize()
+	}
+	r.head = (r.head - 1) & (len(r.buf) - 1)
+	r.buf[r.head] = x
+	r.count++
+}
+
+func (r *ring[T]) shrink() {
+	if len(r.buf) > minRingLen && (r.count<<2) == len(r.buf) {
+		r.resize()
+	}
+}
+
+func (r *ring[T]) popFront() (T, bool) {
+	var zero T
+	if r.count <= 0 {
+		return zero, false
+	}
+	x := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) & (len(r.buf) - 1)
+	r.count--
+	r.shrink()
+	return x, true
+}
+
+func (r *ring[T]) popBack() (T, bool) {
+	var zero T
+	if r.count <= 0 {
+		return zero, false
+	}
+	r.tail = (r.tail - 1) & (len(r.buf) - 1)
+	x := r.buf[r.tail]
+	r.buf[r.tail] = zero
+	r.count--
+	r.shrink()
+	return x, true
+}
+
+// get supports negative index, -1 refers to the last element.
+func (r *ring[T]) get(i int) (T, bool) {
+	var zero T
+	if i < 0 {
+		i += r.count
+	}
+	if i < 0 || i >= r.count {
+		return zero, false
+	}
+	return r.buf[(r.head+i)&(len(r.buf)-1)], true
+}
+
+func (r *ring[T]) clear() {
+	*r = newRing[T]()
+}
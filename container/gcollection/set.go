@@ -0,0 +1,116 @@
+package gcollection
+
+// Set is the generic version of gset.HashSet.
+type Set[T comparable] struct {
+	g     guard
+	items map[T]struct{}
+}
+
+func NewSet[T comparable](concurrentSafe bool, items ...T) *Set[T] {
+	s := &Set[T]{g: newGuard(concurrentSafe), items: make(map[T]struct{}, len(items))}
+	for _, v := range items {
+		s.items[v] = struct{}{}
+	}
+	return s
+}
+
+func (s *Set[T]) Add(items ...T) {
+	s.g.lock()
+	defer s.g.unlock()
+	for _, v := range items {
+		s.items[v] = struct{}{}
+	}
+}
+
+func (s *Set[T]) Remove(items ...T) {
+	s.g.lock()
+	defer s.g.unlock()
+	for _, v := range items {
+		delete(s.items, v)
+	}
+}
+
+// Contains returns true if all items are in set.
+func (s *Set[T]) Contains(items ...T) bool {
+	s.g.rlock()
+	defer s.g.runlock()
+	for _, v := range items {
+		if _, ok := s.items[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Set[T]) Size() int {
+	s.g.rlock()
+	defer s.g.runlock()
+	return len(s.items)
+}
+
+func (s *Set[T]) Clear() {
+	s.g.lock()
+	defer s.g.unlock()
+	s.items = map[T]struct{}{}
+}
+
+// Items returns all items in random order.
+func (s *Set[T]) Items() []T {
+	s.g.rlock()
+	defer s.g.runlock()
+	res := make([]T, 0, len(s.items))
+	for v := range s.items {
+		res = append(res, v)
+	}
+	return res
+}
+
+// Each calls fn for every item until fn returns false, set must not be modified in fn.
+func (s *Set[T]) Each(fn func(item T) bool) {
+	s.g.rlock()
+	defer s.g.runlock()
+	for v := range s.items {
+		if !fn(v) {
+			return
+		}
+	}
+}
+
+// IsSubset reports whether all items of s are in other.
+func (s *Set[T]) IsSubset(other *Set[T]) bool {
+	// Copy items first, so s and other can be the same set without locking it twice.
+	return other.Contains(s.Items()...)
+}
+
+func (s *Set[T]) Equal(other *Set[T]) bool {
+	return s.Size() == other.Size() && s.IsSubset(other)
+}
+
+// Union returns new set with items in s or other, it is concurrency-safe if s is.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	res := NewSet[T](s.g.safe, s.Items()...)
+	res.Add(other.Items()...)
+	return res
+}
+
+// Intersect returns new set with items in both s and other, it is concurrency-safe if s is.
+func (s *Set[T]) Intersect(other *Set[T]) *Set[T] {
+	res := NewSet[T](s.g.safe)
+	for _, v := range s.Items() {
+		if other.Contains(v) {
+			res.items[v] = struct{}{}
+		}
+	}
+	return res
+}
+
+// Difference returns new set with items in s but not in other, it is concurrency-safe if s is.
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	res := NewSet[T](s.g.safe)
+	for _, v := range s.Items() {
+		if !other.Contains(v) {
+			res.items[v] = struct{}{}
+		}
+	}
+	return res
+}
@@ -0,0 +1,155 @@
+package gcollection
+
+import "sort"
+
+type (
+	Entry[K Ordered, V any] struct {
+		Key   K
+		Value V
+	}
+
+	// SortedList keeps entries sorted by key, duplicated keys are allowed,
+	// it is the generic version of glist.SortedList with range queries.
+	SortedList[K Ordered, V any] struct {
+		g       guard
+		entries []Entry[K, V]
+	}
+)
+
+func NewSortedList[K Ordered, V any](concurrentSafe bool) *SortedList[K, V] {
+	return &SortedList[K, V]{g: newGuard(concurrentSafe)}
+}
+
+// lowerBound returns index of the first entry with key >= k.
+func (sl *SortedList[K, V]) lowerBound(k K) int {
+	return sort.Search(len(sl.entries), func(i int) bool { return sl.entries[i].Key >= k })
+}
+
+// upperBound returns index of the first entry with key > k.
+func (sl *SortedList[K, V]) upperBound(k K) int {
+	return sort.Search(len(sl.entries), func(i int) bool { return sl.entries[i].Key > k })
+}
+
+// Insert adds entry after existing entries with the same key.
+func (sl *SortedList[K, V]) Insert(k K, v V) {
+	sl.g.lock()
+	defer sl.g.unlock()
+	i := sl.upperBound(k)
+	sl.entries = append(sl.entries, Entry[K, V]{})
+	copy(sl.entries[i+1:], sl.entries[i:])
+	sl.entries[i] = Entry[K, V]{Key: k, Value: v}
+}
+
+// Get returns value of the first entry with key k.
+func (sl *SortedList[K, V]) Get(k K) (V, bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	if i := sl.lowerBound(k); i < len(sl.entries) && sl.entries[i].Key == k {
+		return sl.entries[i].Value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete removes all entries with key k and returns the number of removed entries.
+func (sl *SortedList[K, V]) Delete(k K) int {
+	sl.g.lock()
+	defer sl.g.unlock()
+	from, to := sl.lowerBound(k), sl.upperBound(k)
+	sl.entries = append(sl.entries[:from], sl.entries[to:]...)
+	return to - from
+}
+
+func (sl *SortedList[K, V]) Len() int {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return len(sl.entries)
+}
+
+func (sl *SortedList[K, V]) Clear() {
+	sl.g.lock()
+	defer sl.g.unlock()
+	sl.entries = nil
+}
+
+func (sl *SortedList[K, V]) at(i int) (Entry[K, V], bool) {
+	if i < 0 || i >= len(sl.entries) {
+		return Entry[K, V]{}, false
+	}
+	return sl.entries[i], true
+}
+
+func (sl *SortedList[K, V]) Min() (Entry[K, V], bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return sl.at(0)
+}
+
+func (sl *SortedList[K, V]) Max() (Entry[K, V], bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return sl.at(len(sl.entries) - 1)
+}
+
+func (sl *SortedList[K, V]) PopMin() (Entry[K, V], bool) {
+	sl.g.lock()
+	defer sl.g.unlock()
+	e, ok := sl.at(0)
+	if ok {
+		sl.entries = sl.entries[1:]
+	}
+	return e, ok
+}
+
+func (sl *SortedList[K, V]) PopMax() (Entry[K, V], bool) {
+	sl.g.lock()
+	defer sl.g.unlock()
+	e, ok := sl.at(len(sl.entries) - 1)
+	if ok {
+		sl.entries = sl.entries[:len(sl.entries)-1]
+	}
+	return e, ok
+}
+
+// Floor returns the last entry with key <= k.
+func (sl *SortedList[K, V]) Floor(k K) (Entry[K, V], bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return sl.at(sl.upperBound(k) - 1)
+}
+
+// Ceiling returns the first entry with key >= k.
+func (sl *SortedList[K, V]) Ceiling(k K) (Entry[K, V], bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return sl.at(sl.lowerBound(k))
+}
+
+// Range returns entries with from <= key < to in order.
+func (sl *SortedList[K, V]) Range(from, to K) []Entry[K, V] {
+	var res []Entry[K, V]
+	sl.RangeFunc(from, to, func(e Entry[K, V]) bool {
+		res = append(res, e)
+		return true
+	})
+	return res
+}
+
+// RangeFunc calls fn for entries with from <= key < to in order until fn returns false,
+// list must not be modified in fn.
+func (sl *SortedList[K, V]) RangeFunc(from, to K, fn func(e Entry[K, V]) bool) {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	for i := sl.lowerBound(from); i < len(sl.entries) && sl.entries[i].Key < to; i++ {
+		if !fn(sl.entries[i]) {
+			return
+		}
+	}
+}
+
+// Entries returns all entries in order.
+func (sl *SortedList[K, V]) Entries() []Entry[K, V] {
+	sl.g.rlock()
+	defer sl.g.runlock()
+	return append([]Entry[K, V]{}, sl.entries...)
+}
@@ -0,0 +1,204 @@
+package gdecimal
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/shopspring/decimal"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+/**
+Exact or arbitrary precision math functions, prec is the number of digits after decimal point of result,
+results are rounded by RoundHalfEven. Intermediate results use extra guard digits.
+*/
+
+const guardDigits = 10
+
+// Mod returns remainder of d / d2, its sign is the same as d.
+func (d Decimal) Mod(d2 Decimal) Decimal {
+	return Decimal(d.raw().Mod(d2.raw()))
+}
+
+// PowInt returns d^n rounded to prec. Intermediate results are rounded to as many significant digits
+// as the result needs, so the cost doesn't grow with digits of the exact power.
+func (d Decimal) PowInt(n int, prec int) (Decimal, error) {
+	if n == 0 {
+		return One, nil
+	}
+	if d.IsZero() {
+		if n < 0 {
+			return Zero, gerrors.New("zero base with negative exponent")
+		}
+		return Zero, nil
+	}
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	// Digits of integer part of result or its reciprocal, and error which grows with n.
+	mag := math.Abs(math.Log10(math.Abs(d.Float64())) * float64(n))
+	if math.IsInf(mag, 0) || math.IsNaN(mag) {
+		return Zero, gerrors.Errorf("%s^%d is out of range", d, n)
+	}
+	digits := prec + guardDigits + len(strconv.Itoa(n)) + int(math.Ceil(mag))
+
+	res := decimal.New(1, 0)
+	base := d.raw()
+	for n > 0 {
+		if n&1 == 1 {
+			res = roundSignificant(res.Mul(base), digits)
+		}
+		n >>= 1
+		if n > 0 {
+			base = roundSignificant(base.Mul(base), digits)
+		}
+	}
+	if neg {
+		res = decimal.New(1, 0).DivRound(res, int32(prec+guardDigits))
+	}
+	return Decimal(res).Round(prec, RoundHalfEven), nil
+}
+
+// roundSignificant rounds x to at most digits significant digits.
+func roundSignificant(x decimal.Decimal, digits int) decimal.Decimal {
+	extra := len(new(big.Int).Abs(x.Coefficient()).String()) - digits
+	if extra <= 0 {
+		return x
+	}
+	return x.Round(-x.Exponent() - int32(extra))
+}
+
+// Pow returns d^exp rounded to prec, exp can be fractional if d is not negative.
+func (d Decimal) Pow(exp Decimal, prec int) (Decimal, error) {
+	if d.IsZero() {
+		switch {
+		case exp.IsNegative():
+			return Zero, gerrors.New("zero base with negative exponent")
+		case exp.IsZero():
+			return One, nil
+		}
+		return Zero, nil
+	}
+	if exp.Equal(Decimal(exp.raw().Truncate(0))) && exp.raw().Abs().LessThan(decimal.New(math.MaxInt32, 0)) {
+		return d.PowInt(exp.IntPart(), prec)
+	}
+	if d.IsNegative() {
+		return Zero, gerrors.Errorf("negative base %s with fractional exponent %s", d, exp)
+	}
+	// d^exp = e^(exp*ln(d)), ln needs more digits because exp enlarges its error.
+	extra := len(exp.raw().Abs().Truncate(0).String())
+	ln, err := d.Ln(prec + guardDigits + extra)
+	if err != nil {
+		return Zero, err
+	}
+	return exp.Mul(ln).Exp(prec), nil
+}
+
+// Sqrt returns square root of d rounded to prec.
+func (d Decimal) Sqrt(prec int) (Decimal, error) {
+	if d.IsNegative() {
+		return Zero, gerrors.Errorf("square root of negative number %s", d)
+	}
+	if d.IsZero() {
+		return Zero, nil
+	}
+	work := int32(prec + guardDigits)
+	eps := decimal.New(1, -work)
+	two := decimal.New(2, 0)
+	x := d.raw()
+
+	// Initial guess from float64, it is good enough unless d is out of float64 range.
+	guess := math.Sqrt(d.Float64())
+	var r decimal.Decimal
+	if math.IsInf(guess, 0) || guess == 0 || math.IsNaN(guess) {
+		r = decimal.New(1, int32(len(x.Coefficient().String())+int(x.Exponent()))/2)
+	} else {
+		r = decimal.NewFromFloat(guess)
+	}
+	// Newton's method: r = (r + x/r) / 2.
+	for i := 0; i < 1000; i++ {
+		next := r.Add(x.DivRound(r, work)).DivRound(two, work)
+		if next.Sub(r).Abs().LessThanOrEqual(eps) {
+			r = next
+			break
+		}
+		r = next
+	}
+	return Decimal(r).Round(prec, RoundHalfEven), nil
+}
+
+// Exp returns e^d rounded to prec.
+func (d Decimal) Exp(prec int) Decimal {
+	x := d.raw()
+	if x.IsZero() {
+		return One
+	}
+	if x.IsNegative() {
+		// e^-x is tiny, compute it by 1/e^x to keep significant digits.
+		ex := d.Abs().Exp(prec + guardDigits)
+		return Decimal(decimal.New(1, 0).DivRound(ex.raw(), int32(prec+guardDigits))).Round(prec, RoundHalfEven)
+	}
+	// Reduce x by 2^k until |x| < 1, compute Taylor series, then square the result k times.
+	// Every squaring doubles relative error, so add digits for it.
+	k := 0
+	for r := x.Abs(); r.GreaterThanOrEqual(decimal.New(1, 0)); r = r.Div(decimal.New(2, 0)) {
+		k++
+	}
+	intDigits := int(math.Ceil(d.Float64() / math.Ln10))
+	work := int32(prec + guardDigits + k/3 + 1 + intDigits)
+	eps := decimal.New(1, -work)
+	r := x.DivRound(decimal.New(2, 0).Pow(decimal.New(int64(k), 0)), work)
+
+	sum, term := decimal.New(1, 0), decimal.New(1, 0)
+	for n := int64(1); ; n++ {
+		term = term.Mul(r).DivRound(decimal.New(n, 0), work)
+		sum = sum.Add(term)
+		if term.Abs().LessThan(eps) {
+			break
+		}
+	}
+	for i := 0; i < k; i++ {
+		sum = sum.Mul(sum).Round(work)
+	}
+	return Decimal(sum).Round(prec, RoundHalfEven)
+}
+
+// Ln returns natural logarithm of d rounded to prec.
+func (d Decimal) Ln(prec int) (Decimal, error) {
+	if !d.IsPositive() {
+		return Zero, gerrors.Errorf("logarithm of non-positive number %s", d)
+	}
+	work := prec + guardDigits
+	x := d.raw()
+
+	// Scale x to [0.1, 1) by power of 10, so ln(x) = ln(m) + e*ln(10).
+	e := len(x.Coefficient().String()) + int(x.Exponent())
+	m := Decimal(x.Shift(int32(-e)))
+	res := lnNear(m, work)
+	if e != 0 {
+		res = res.Add(lnNear(NewFromInt(10), work+len(NewFromInt(e).String())).MulInt(e))
+	}
+	return res.Round(prec, RoundHalfEven), nil
+}
+
+// lnNear computes ln(x) for x in float64 range: a = float64 ln(x), y = x/e^a is very close to 1,
+// so ln(x) = a + ln(y) = a + 2*atanh((y-1)/(y+1)) converges fast.
+func lnNear(x Decimal, work int) Decimal {
+	a := NewFromFloat64(math.Log(x.Float64()))
+	w := int32(work)
+	y := x.raw().Mul(a.TurnPositiveNegative().Exp(work + 2).raw()).Round(w + 2)
+	z := y.Sub(decimal.New(1, 0)).DivRound(y.Add(decimal.New(1, 0)), w+2)
+	z2 := z.Mul(z).Round(w + 2)
+	eps := decimal.New(1, -w-2)
+	sum, term := z, z
+	for n := int64(3); ; n += 2 {
+		term = term.Mul(z2).Round(w + 2)
+		t := term.DivRound(decimal.New(n, 0), w+2)
+		sum = sum.Add(t)
+		if t.Abs().LessThan(eps) {
+			break
+		}
+	}
+	return a.Add(Decimal(sum.Mul(decimal.New(2, 0))))
+}
@@ -0,0 +1,148 @@
+package gdecimal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDecimal_Round(t *testing.T) {
+	cases := []struct {
+		in     string
+		places int
+		mode   RoundingMode
+		expect string
+	}{
+		{"2.5", 0, RoundHalfUp, "3"},
+		{"-2.5", 0, RoundHalfUp, "-3"},
+		{"2.5", 0, RoundHalfDown, "2"},
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"2.51", 0, RoundHalfEven, "3"},
+		{"-2.1", 0, RoundCeiling, "-2"},
+		{"2.1", 0, RoundCeiling, "3"},
+		{"-2.1", 0, RoundFloor, "-3"},
+		{"-2.9", 0, RoundTowardZero, "-2"},
+		{"-2.1", 0, RoundAwayFromZero, "-3"},
+		{"1.005", 2, RoundHalfUp, "1.01"},
+		{"1.015", 2, RoundHalfEven, "1.02"},
+		{"1.025", 2, RoundHalfEven, "1.02"},
+		{"1250", -2, RoundHalfUp, "1300"},
+		{"1250", -2, RoundHalfEven, "1200"},
+		{"1.2", 3, RoundCeiling, "1.2"},
+	}
+	for _, c := range cases {
+		if got := mustDecimal(t, c.in).Round(c.places, c.mode).String(); got != c.expect {
+			t.Errorf("Round(%s, %d, %s): expect %s, got %s", c.in, c.places, c.mode, c.expect, got)
+		}
+	}
+}
+
+func TestDecimal_Format(t *testing.T) {
+	if s := mustDecimal(t, "1234567.125").Format(2, RoundHalfEven, ",", "."); s != "1,234,567.12" {
+		t.Errorf("unexpected format %s", s)
+	}
+	if s := mustDecimal(t, "-1234.5").Format(2, RoundHalfUp, ".", ","); s != "-1.234,50" {
+		t.Errorf("unexpected format %s", s)
+	}
+	if s := mustDecimal(t, "999.5").Format(0, RoundHalfUp, ",", "."); s != "1,000" {
+		t.Errorf("unexpected format %s", s)
+	}
+	if s := mustDecimal(t, "12").StringFixed(3, RoundHalfUp); s != "12.000" {
+		t.Errorf("unexpected StringFixed %s", s)
+	}
+}
+
+func TestDecimal_Math(t *testing.T) {
+	two := NewFromInt(2)
+	if s, _ := two.Sqrt(30); s.StringFixed(30, RoundHalfEven) != "1.414213562373095048801688724210" {
+		t.Errorf("unexpected sqrt %s", s)
+	}
+	if s, _ := mustDecimal(t, "1e40").Sqrt(0); s.String() != "100000000000000000000" {
+		t.Errorf("unexpected sqrt %s", s)
+	}
+	if e := One.Exp(30); e.String() != "2.718281828459045235360287471353" {
+		t.Errorf("unexpected exp %s", e)
+	}
+	if e := NewFromInt(-10).Exp(20); e.String() != "0.00004539992976248485" {
+		t.Errorf("unexpected exp %s", e)
+	}
+	if l, _ := two.Ln(30); l.String() != "0.693147180559945309417232121458" {
+		t.Errorf("unexpected ln %s", l)
+	}
+	if l, _ := mustDecimal(t, "1e-50").Ln(20); l.StringFixed(20, RoundHalfEven) != "-115.12925464970228420090" {
+		t.Errorf("unexpected ln %s", l)
+	}
+	if _, err := Zero.Ln(10); err == nil {
+		t.Error("ln of zero should fail")
+	}
+
+	// Compound interest factor 1.05^10.
+	if p, _ := mustDecimal(t, "1.05").Pow(NewFromInt(10), 10); p.String() != "1.6288946268" {
+		t.Errorf("unexpected pow %s", p)
+	}
+	if p, _ := two.Pow(mustDecimal(t, "0.5"), 20); p.StringFixed(20, RoundHalfEven) != "1.41421356237309504880" {
+		t.Errorf("unexpected pow %s", p)
+	}
+	if p, _ := two.Pow(NewFromInt(-2), 5); p.String() != "0.25" {
+		t.Errorf("unexpected pow %s", p)
+	}
+	if _, err := NewFromInt(-2).Pow(mustDecimal(t, "0.5"), 5); err == nil {
+		t.Error("fractional power of negative number should fail")
+	}
+	for _, exp := range []string{"-1", "-0.5"} {
+		if _, err := Zero.Pow(mustDecimal(t, exp), 10); err == nil {
+			t.Errorf("zero to the power of %s should fail", exp)
+		}
+	}
+	if p, _ := NewFromInt(3).Pow(NewFromInt(100), 0); p.String() != "515377520732011331036461129765621272702107522001" {
+		t.Errorf("unexpected pow %s", p)
+	}
+	// Intermediate results are rounded, so large exponent is fast.
+	start := time.Now()
+	if p, _ := mustDecimal(t, "1.00001").Pow(NewFromInt(200000), 10); p.String() != "7.3889822092" {
+		t.Errorf("unexpected pow %s", p)
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("pow with large exponent takes %s", d)
+	}
+	if m := mustDecimal(t, "-7.5").Mod(two); m.String() != "-1.5" {
+		t.Errorf("unexpected mod %s", m)
+	}
+}
+
+func TestDecimal_SQL(t *testing.T) {
+	var _ driver.Valuer = Decimal{}
+	var _ sql.Scanner = &Decimal{}
+
+	v, _ := mustDecimal(t, "12.340").Value()
+	var d Decimal
+	if err := d.Scan([]byte(v.(string))); err != nil || d.String() != "12.34" {
+		t.Errorf("scan failed %s %v", d, err)
+		return
+	}
+	if err := d.Scan(int64(5)); err != nil || d.String() != "5" {
+		t.Errorf("scan failed %s %v", d, err)
+		return
+	}
+	var n NullDecimal
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Error("scan NULL failed")
+		return
+	}
+	if v, _ := n.Value(); v != nil {
+		t.Error("NULL value should be nil")
+	}
+	if err := n.Scan("1.5"); err != nil || !n.Valid || n.Decimal.String() != "1.5" {
+		t.Error("scan NullDecimal failed")
+	}
+}
@@ -0,0 +1,115 @@
+package gdecimal
+
+import (
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds half away from zero, 2.5 -> 3, -2.5 -> -3.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfDown rounds half toward zero, 2.5 -> 2, -2.5 -> -2.
+	RoundHalfDown
+	// RoundHalfEven is banker's rounding, it rounds half to even digit, 2.5 -> 2, 3.5 -> 4.
+	RoundHalfEven
+	// RoundCeiling rounds toward positive infinity, 2.1 -> 3, -2.9 -> -2.
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity, 2.9 -> 2, -2.1 -> -3.
+	RoundFloor
+	// RoundTowardZero truncates, 2.9 -> 2, -2.9 -> -2.
+	RoundTowardZero
+	// RoundAwayFromZero rounds up magnitude, 2.1 -> 3, -2.1 -> -3.
+	RoundAwayFromZero
+)
+
+var half = NewFromDecimal(decimal.New(5, -1))
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfUp:
+		return "HalfUp"
+	case RoundHalfDown:
+		return "HalfDown"
+	case RoundHalfEven:
+		return "HalfEven"
+	case RoundCeiling:
+		return "Ceiling"
+	case RoundFloor:
+		return "Floor"
+	case RoundTowardZero:
+		return "TowardZero"
+	case RoundAwayFromZero:
+		return "AwayFromZero"
+	default:
+		return "Unknown"
+	}
+}
+
+// Round rounds d to places digits after decimal point with mode,
+// negative places rounds integer part, Round(-2, RoundHalfUp) of 1250 is 1300.
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	s := d.raw().Shift(int32(places))
+	t := s.Truncate(0)
+	rem := Decimal(s.Sub(t)).Abs()
+	if rem.IsZero() {
+		return d
+	}
+
+	away := false
+	switch mode {
+	case RoundHalfUp:
+		away = rem.GreaterThanOrEqual(half)
+	case RoundHalfDown:
+		away = rem.GreaterThan(half)
+	case RoundHalfEven:
+		away = rem.GreaterThan(half) || (rem.Equal(half) && !t.Mod(decimal.New(2, 0)).IsZero())
+	case RoundCeiling:
+		away = d.IsPositive()
+	case RoundFloor:
+		away = d.IsNegative()
+	case RoundAwayFromZero:
+		away = true
+	}
+	if away {
+		t = t.Add(decimal.New(int64(d.raw().Sign()), 0))
+	}
+	return Decimal(t.Shift(int32(-places)))
+}
+
+// StringFixed returns d rounded to places with mode, and pads zeros to places digits after decimal point.
+func (d Decimal) StringFixed(places int, mode RoundingMode) string {
+	if places < 0 {
+		return d.Round(places, mode).raw().StringFixed(0)
+	}
+	return d.Round(places, mode).raw().StringFixed(int32(places))
+}
+
+// Format returns d rounded to places with mode, whose integer part is grouped every 3 digits by groupSep,
+// and decimal point is pointSep, Format(2, RoundHalfEven, ",", ".") of 1234567.125 is "1,234,567.12".
+func (d Decimal) Format(places int, mode RoundingMode, groupSep, pointSep string) string {
+	s := d.StringFixed(places, mode)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(groupSep)
+		}
+		sb.WriteRune(c)
+	}
+	if fracPart != "" {
+		sb.WriteString(pointSep)
+		sb.WriteString(fracPart)
+	}
+	return sb.String()
+}
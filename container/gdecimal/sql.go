@@ -0,0 +1,39 @@
+package gdecimal
+
+import (
+	"database/sql/driver"
+	"github.com/shopspring/decimal"
+)
+
+// NullDecimal represents a Decimal that may be NULL in database.
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+// Value implements driver.Valuer, Decimal is stored as string to keep all digits,
+// so it works with DECIMAL/NUMERIC and text columns.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner, it accepts string, []byte, int64, float32 and float64 values.
+func (d *Decimal) Scan(value interface{}) error {
+	return (*decimal.Decimal)(d).Scan(value)
+}
+
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+func (n *NullDecimal) Scan(value interface{}) error {
+	if value == nil {
+		n.Decimal, n.Valid = Zero, false
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.Scan(value)
+}
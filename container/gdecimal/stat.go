@@ -0,0 +1,115 @@
+package gdecimal
+
+import (
+	"github.com/cryptowilliam/goutil/container/gnum"
+	"sort"
+)
+
+/**
+statistic functions of Decimal, the same as gnum statistic functions of float64.
+Sum, Mode, Median and Quantile are exact, Mean and Variance are rounded by the final division
+to decimal.DivisionPrecision digits after decimal point,
+statistics which need roots or powers are calculated by gnum with float64.
+*/
+
+func Sum(values []Decimal) Decimal {
+	ret := Zero
+	for _, v := range values {
+		ret = ret.Add(v)
+	}
+	return ret
+}
+
+// Mean returns zero if values is empty.
+func Mean(values []Decimal) Decimal {
+	if len(values) == 0 {
+		return Zero
+	}
+	return Sum(values).DivInt(len(values))
+}
+
+func sorted(values []Decimal) []Decimal {
+	res := append([]Decimal{}, values...)
+	sort.Slice(res, func(i, j int) bool { return res[i].LessThan(res[j]) })
+	return res
+}
+
+// Median returns zero if values is empty.
+func Median(values []Decimal) Decimal {
+	return Quantile(values, 0.5)
+}
+
+// Quantile is linear interpolated between closest ranks, the same as gnum.Quantile.
+// It returns zero if values is empty or q is not in [0, 1].
+func Quantile(values []Decimal, q float64) Decimal {
+	if len(values) == 0 || q < 0 || q > 1 {
+		return Zero
+	}
+	s := sorted(values)
+	pos := NewFromFloat64(q).MulInt(len(s) - 1)
+	lo := pos.IntPart()
+	if lo == len(s)-1 {
+		return s[lo]
+	}
+	return s[lo].Add(s[lo+1].Sub(s[lo]).Mul(pos.SubInt(lo)))
+}
+
+// Mode returns all values with the highest frequency in ascending order.
+func Mode(values []Decimal) []Decimal {
+	counts := make(map[string]int)
+	maxCount := 0
+	for _, v := range values {
+		// Normalize by String, so 1.0 and 1 are the same.
+		k := v.String()
+		counts[k]++
+		if counts[k] > maxCount {
+			maxCount = counts[k]
+		}
+	}
+	var ret []Decimal
+	for _, v := range sorted(values) {
+		if k := v.String(); counts[k] == maxCount {
+			ret = append(ret, v)
+			delete(counts, k)
+		}
+	}
+	return ret
+}
+
+// Variance returns zero if len(values) <= ddof, ddof is the same as gnum.Std.
+func Variance(values []Decimal, ddof int) Decimal {
+	if len(values) <= ddof {
+		return Zero
+	}
+	m := Mean(values)
+	ss := Zero
+	for _, v := range values {
+		d := v.Sub(m)
+		ss = ss.Add(d.Mul(d))
+	}
+	return ss.DivInt(len(values) - ddof)
+}
+
+func Std(values []Decimal, ddof int) float64 {
+	return gnum.Std(ToFloat64s(values), ddof)
+}
+
+func Skewness(values []Decimal) float64 {
+	return gnum.Skewness(ToFloat64s(values))
+}
+
+func Kurtosis(values []Decimal) float64 {
+	return gnum.Kurtosis(ToFloat64s(values))
+}
+
+func Describe(values []Decimal) gnum.Summary {
+	return gnum.Describe(ToFloat64s(values))
+}
+
+func Correlation(x, y []Decimal) float64 {
+	return gnum.Correlation(ToFloat64s(x), ToFloat64s(y))
+}
+
+func LinearRegression(x, y []Decimal) (*gnum.Regression, error) {
+	return gnum.LinearRegression(ToFloat64s(x), ToFloat64s(y))
+}
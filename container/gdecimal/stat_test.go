@@ -0,0 +1,28 @@
+package gdecimal
+
+import (
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	var values []Decimal
+	for _, s := range []string{"0.1", "0.2", "0.2", "0.4", "1.0"} {
+		d, _ := NewFromString(s)
+		values = append(values, d)
+	}
+	if Sum(values).String() != "1.9" || Mean(values).String() != "0.38" {
+		t.Errorf("unexpected sum %s mean %s", Sum(values), Mean(values))
+		return
+	}
+	if Median(values).String() != "0.2" || Quantile(values, 0.875).String() != "0.7" {
+		t.Errorf("unexpected median %s quantile %s", Median(values), Quantile(values, 0.875))
+		return
+	}
+	if m := Mode(values); len(m) != 1 || m[0].String() != "0.2" {
+		t.Errorf("unexpected mode %v", m)
+		return
+	}
+	if Variance(values, 0).String() != "0.1056" {
+		t.Errorf("unexpected variance %s", Variance(values, 0))
+	}
+}
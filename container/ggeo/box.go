@@ -0,0 +1,61 @@
+package ggeo
+
+import (
+	"github.com/cryptowilliam/goutil/container/ggeometry"
+	"math"
+)
+
+// Box is a bounding box in degrees, MinLon > MaxLon means it crosses the 180th meridian.
+type Box struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Center returns center point of box.
+func (b Box) Center() Point {
+	maxLon := b.MaxLon
+	if b.MinLon > maxLon {
+		maxLon += 360
+	}
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lon: normalizeLon((b.MinLon + maxLon) / 2)}
+}
+
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+	}
+	return p.Lon >= b.MinLon || p.Lon <= b.MaxLon
+}
+
+// BoundingBox returns the smallest box which contains all points within radius kilometers of center,
+// it covers all longitudes if the circle contains a pole.
+// It is useful for filtering candidates by an index of lat/lon before computing exact distances.
+func BoundingBox(center Point, radius float64) Box {
+	d := radius / EarthRadius
+	lat := ggeometry.AngleToRadian(center.Lat)
+	minLat, maxLat := lat-d, lat+d
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		return Box{
+			MinLat: ggeometry.RadianToAngle(math.Max(minLat, -math.Pi/2)),
+			MinLon: -180,
+			MaxLat: ggeometry.RadianToAngle(math.Min(maxLat, math.Pi/2)),
+			MaxLon: 180,
+		}
+	}
+	ratio := math.Sin(d) / math.Cos(lat)
+	if ratio >= 1 || d >= math.Pi/2 {
+		return Box{MinLat: ggeometry.RadianToAngle(minLat), MinLon: -180, MaxLat: ggeometry.RadianToAngle(maxLat), MaxLon: 180}
+	}
+	dLon := ggeometry.RadianToAngle(math.Asin(ratio))
+	return Box{
+		MinLat: ggeometry.RadianToAngle(minLat),
+		MinLon: normalizeLon(center.Lon - dLon),
+		MaxLat: ggeometry.RadianToAngle(maxLat),
+		MaxLon: normalizeLon(center.Lon + dLon),
+	}
+}
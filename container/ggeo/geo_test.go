@@ -0,0 +1,175 @@
+package ggeo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var (
+	beijing  = Point{Lat: 39.9042, Lon: 116.4074}
+	shanghai = Point{Lat: 31.2304, Lon: 121.4737}
+)
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestDistance(t *testing.T) {
+	d := Distance(beijing, shanghai)
+	if !near(d, 1068.5, 2) || !near(d, GeoDistance(beijing.Lat, beijing.Lon, shanghai.Lat, shanghai.Lon), 1e-6) {
+		t.Errorf("unexpected distance %f", d)
+		return
+	}
+	if b := Bearing(Point{0, 0}, Point{0, 10}); !near(b, 90, 1e-9) {
+		t.Errorf("unexpected bearing %f", b)
+		return
+	}
+	dst := Destination(beijing, Bearing(beijing, shanghai), d)
+	if Distance(dst, shanghai) > 0.001 {
+		t.Errorf("unexpected destination %s", dst)
+		return
+	}
+	if dst := Destination(Point{0, 179}, 90, Distance(Point{0, 0}, Point{0, 2})); !near(dst.Lon, -179, 1e-9) {
+		t.Errorf("destination should wrap around, got %s", dst)
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	b := BoundingBox(beijing, 100)
+	for bearing := 0.0; bearing < 360; bearing += 15 {
+		if p := Destination(beijing, bearing, 99.9); !b.Contains(p) {
+			t.Errorf("%s should be in %+v", p, b)
+			return
+		}
+	}
+	if b.Contains(Destination(beijing, 0, 101)) {
+		t.Error("point out of radius is in box")
+		return
+	}
+
+	b = BoundingBox(Point{Lat: 0, Lon: 179.9}, 100)
+	if b.MinLon < b.MaxLon || !b.Contains(Point{0, -179.5}) || b.Contains(Point{0, 0}) {
+		t.Errorf("box should cross the 180th meridian, got %+v", b)
+		return
+	}
+	if b = BoundingBox(Point{Lat: 89.5, Lon: 0}, 100); b.MaxLat != 90 || b.MinLon != -180 || b.MaxLon != 180 {
+		t.Errorf("box should contain north pole, got %+v", b)
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	p := Point{Lat: 57.64911, Lon: 10.40744}
+	if h := EncodeGeohash(p, 11); h != "u4pruydqqvj" {
+		t.Errorf("unexpected geohash %s", h)
+		return
+	}
+	b, err := DecodeGeohash("U4PRUYDQQVJ")
+	if err != nil || !b.Contains(p) || !near(b.Center().Lat, p.Lat, 1e-5) {
+		t.Errorf("unexpected decoded box %+v %v", b, err)
+		return
+	}
+	if _, err := DecodeGeohash("u4a"); err == nil {
+		t.Error("invalid geohash should fail")
+		return
+	}
+
+	ns, err := GeohashNeighbors("u4pru")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ns[East] != "u4prv" || ns[West] != "u4prg" || ns[South] != "u4prs" {
+		t.Errorf("unexpected neighbors %v", ns)
+		return
+	}
+	// Every neighbor touches the box of hash.
+	center, _ := DecodeGeohash("u4pru")
+	for dir, n := range ns {
+		b, err := DecodeGeohash(n)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		touch := func(a1, a2, b1, b2 float64) bool { return near(a1, b2, 1e-9) || near(a2, b1, 1e-9) || near(a1, b1, 1e-9) }
+		if !touch(b.MinLat, b.MaxLat, center.MinLat, center.MaxLat) || !touch(b.MinLon, b.MaxLon, center.MinLon, center.MaxLon) {
+			t.Errorf("neighbor %d %s doesn't touch u4pru", dir, n)
+			return
+		}
+	}
+	if n, _ := GeohashNeighbor("b", North); n != "" {
+		t.Errorf("there is no neighbor beyond north pole, got %s", n)
+		return
+	}
+	if n, _ := GeohashNeighbor("b", West); n != "z" {
+		t.Errorf("neighbor should wrap around, got %s", n)
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	pg := Polygon{{0, 0}, {0, 10}, {10, 10}, {10, 5}, {5, 5}, {5, 0}}
+	for p, expect := range map[Point]bool{
+		{2, 2}: true, {7, 7}: true, {7, 2}: false, {-1, 5}: false, {5.1, 9}: true,
+	} {
+		if pg.Contains(p) != expect {
+			t.Errorf("Contains(%s) should be %v", p, expect)
+			return
+		}
+	}
+
+	pl := Polyline{{0, 0}, {0, 0.5}, {0.001, 1}, {0, 1.5}, {0, 2}, {1, 2}}
+	if l := pl.Length(); !near(l, Distance(Point{0, 0}, Point{0, 2})+Distance(Point{0, 2}, Point{1, 2}), 0.01) {
+		t.Errorf("unexpected length %f", l)
+		return
+	}
+	if s := pl.Simplify(1); len(s) != 3 || s[1] != (Point{0, 2}) {
+		t.Errorf("unexpected simplified polyline %v", s)
+		return
+	}
+	if s := pl.Simplify(0.06); len(s) != 4 || s[1] != (Point{0.001, 1}) {
+		t.Errorf("unexpected simplified polyline %v", s)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	idx := NewIndex[int]()
+	var points []Point
+	for i := 0; i < 2000; i++ {
+		p := Point{Lat: math.Asin(rnd.Float64()*2-1) * 180 / math.Pi, Lon: rnd.Float64()*360 - 180}
+		points = append(points, p)
+		idx.Insert(p, i)
+	}
+
+	for _, q := range []Point{beijing, {0, 180}, {-89.9, 10}, {10, -179.99}} {
+		dists := make([]float64, len(points))
+		for i, p := range points {
+			dists[i] = Distance(q, p)
+		}
+		sorted := append([]float64{}, dists...)
+		sort.Float64s(sorted)
+
+		got := idx.Nearest(q, 5)
+		if len(got) != 5 {
+			t.Errorf("expect 5 neighbors, got %d", len(got))
+			return
+		}
+		for i, n := range got {
+			if !near(n.Distance, sorted[i], 1e-6) || !near(dists[n.Value], n.Distance, 1e-6) {
+				t.Errorf("nearest %d of %s: expect %f, got %f", i, q, sorted[i], n.Distance)
+				return
+			}
+		}
+
+		within := idx.Within(q, 1000)
+		count := sort.SearchFloat64s(sorted, 1000+1e-9)
+		if len(within) != count {
+			t.Errorf("within 1000km of %s: expect %d, got %d", q, count, len(within))
+			return
+		}
+	}
+	if got := idx.Within(beijing, 1e6); len(got) != len(points) {
+		t.Errorf("whole earth should contain all points, got %d", len(got))
+	}
+}
@@ -0,0 +1,154 @@
+package ggeo
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"strings"
+)
+
+// Direction of geohash neighbor.
+type Direction int
+
+const (
+	North Direction = iota
+	NorthEast
+	East
+	SouthEast
+	South
+	SouthWest
+	West
+	NorthWest
+)
+
+// MaxGeohashPrecision is the max length of geohash, 12 characters is about 3.7cm x 1.9cm.
+const MaxGeohashPrecision = 12
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+var geohashIndex = func() [256]int8 {
+	var idx [256]int8
+	for i := range idx {
+		idx[i] = -1
+	}
+	for i := 0; i < len(geohashBase32); i++ {
+		idx[geohashBase32[i]] = int8(i)
+	}
+	return idx
+}()
+
+// EncodeGeohash returns geohash of p with precision characters.
+func EncodeGeohash(p Point, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxGeohashPrecision {
+		precision = MaxGeohashPrecision
+	}
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	lon := normalizeLon(p.Lon)
+
+	var sb strings.Builder
+	even := true // Bits interleave from longitude.
+	for sb.Len() < precision {
+		ch := 0
+		for bit := 0; bit < 5; bit++ {
+			ch <<= 1
+			if even {
+				mid := (minLon + maxLon) / 2
+				if lon >= mid {
+					ch |= 1
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if p.Lat >= mid {
+					ch |= 1
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+		sb.WriteByte(geohashBase32[ch])
+	}
+	return sb.String()
+}
+
+// DecodeGeohash returns the box which geohash represents, use Box.Center to get the point.
+func DecodeGeohash(hash string) (Box, error) {
+	if hash == "" {
+		return Box{}, gerrors.New("empty geohash")
+	}
+	b := Box{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	even := true
+	lower := strings.ToLower(hash)
+	for i := 0; i < len(lower); i++ {
+		ch := geohashIndex[lower[i]]
+		if ch < 0 {
+			return Box{}, gerrors.Errorf("invalid geohash %s", hash)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			on := ch&(1<<bit) != 0
+			if even {
+				mid := (b.MinLon + b.MaxLon) / 2
+				if on {
+					b.MinLon = mid
+				} else {
+					b.MaxLon = mid
+				}
+			} else {
+				mid := (b.MinLat + b.MaxLat) / 2
+				if on {
+					b.MinLat = mid
+				} else {
+					b.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return b, nil
+}
+
+// GeohashNeighbor returns adjacent geohash of the same precision in direction,
+// longitude wraps around the 180th meridian, and it returns "" beyond the poles.
+func GeohashNeighbor(hash string, dir Direction) (string, error) {
+	b, err := DecodeGeohash(hash)
+	if err != nil {
+		return "", err
+	}
+	c := b.Center()
+	dLat, dLon := b.MaxLat-b.MinLat, b.MaxLon-b.MinLon
+	switch dir {
+	case North, NorthEast, NorthWest:
+		c.Lat += dLat
+	case South, SouthEast, SouthWest:
+		c.Lat -= dLat
+	}
+	switch dir {
+	case East, NorthEast, SouthEast:
+		c.Lon += dLon
+	case West, NorthWest, SouthWest:
+		c.Lon -= dLon
+	}
+	if c.Lat > 90 || c.Lat < -90 {
+		return "", nil
+	}
+	return EncodeGeohash(c, len(hash)), nil
+}
+
+// GeohashNeighbors returns 8 adjacent geohashes indexed by Direction.
+func GeohashNeighbors(hash string) ([8]string, error) {
+	var res [8]string
+	for dir := North; dir <= NorthWest; dir++ {
+		n, err := GeohashNeighbor(hash, dir)
+		if err != nil {
+			return res, err
+		}
+		res[dir] = n
+	}
+	return res, nil
+}
@@ -0,0 +1,181 @@
+package ggeo
+
+import (
+	"github.com/cryptowilliam/goutil/container/ggeometry"
+	"math"
+	"sort"
+	"sync"
+)
+
+type (
+	// Neighbor is a point found in Index with its value and distance in kilometers to the query point.
+	Neighbor[V any] struct {
+		Point    Point
+		Value    V
+		Distance float64
+	}
+
+	// Index is a spatial index for nearest neighbor lookup of many points, like finding the closest servers
+	// of a user. Points are stored in a k-d tree of 3D unit vectors, so it works across the 180th meridian
+	// and near the poles. Index is rebuilt lazily on the first query after Insert, it is safe for concurrent use.
+	Index[V any] struct {
+		mu      sync.RWMutex
+		entries []indexEntry[V]
+		dirty   bool
+	}
+
+	indexEntry[V any] struct {
+		point Point
+		value V
+		xyz   [3]float64
+	}
+)
+
+func NewIndex[V any]() *Index[V] {
+	return &Index[V]{}
+}
+
+func toXYZ(p Point) [3]float64 {
+	lat, lon := ggeometry.AngleToRadian(p.Lat), ggeometry.AngleToRadian(p.Lon)
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+// chord2 returns squared straight-line distance between unit vectors, it grows with great-circle distance.
+func chord2(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+func chordToDistance(c2 float64) float64 {
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(c2)/2))
+}
+
+func (idx *Index[V]) Insert(p Point, value V) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries = append(idx.entries, indexEntry[V]{point: p, value: value, xyz: toXYZ(p)})
+	idx.dirty = true
+}
+
+func (idx *Index[V]) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// build arranges entries as implicit k-d tree: median of every range is the node, split axis cycles by depth.
+func (idx *Index[V]) build(lo, hi, depth int) {
+	if hi-lo <= 1 {
+		return
+	}
+	axis := depth % 3
+	sub := idx.entries[lo:hi]
+	sort.Slice(sub, func(i, j int) bool { return sub[i].xyz[axis] < sub[j].xyz[axis] })
+	mid := (lo + hi) / 2
+	idx.build(lo, mid, depth+1)
+	idx.build(mid+1, hi, depth+1)
+}
+
+// rlock read locks idx and rebuilds it first if needed.
+func (idx *Index[V]) rlock() {
+	idx.mu.RLock()
+	if !idx.dirty {
+		return
+	}
+	idx.mu.RUnlock()
+	idx.mu.Lock()
+	if idx.dirty {
+		idx.build(0, len(idx.entries), 0)
+		idx.dirty = false
+	}
+	idx.mu.Unlock()
+	idx.mu.RLock()
+}
+
+type candidate struct {
+	i  int
+	c2 float64
+}
+
+// Nearest returns at most k nearest points to p, sorted by distance.
+func (idx *Index[V]) Nearest(p Point, k int) []Neighbor[V] {
+	if k <= 0 {
+		return nil
+	}
+	idx.rlock()
+	defer idx.mu.RUnlock()
+
+	q := toXYZ(p)
+	var best []candidate // Sorted by distance, at most k.
+	var search func(lo, hi, depth int)
+	search = func(lo, hi, depth int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		e := &idx.entries[mid]
+		if c2 := chord2(q, e.xyz); len(best) < k || c2 < best[len(best)-1].c2 {
+			pos := sort.Search(len(best), func(i int) bool { return best[i].c2 > c2 })
+			if len(best) < k {
+				best = append(best, candidate{})
+			}
+			copy(best[pos+1:], best[pos:])
+			best[pos] = candidate{i: mid, c2: c2}
+		}
+		diff := q[depth%3] - e.xyz[depth%3]
+		near, far := [2]int{lo, mid}, [2]int{mid + 1, hi}
+		if diff > 0 {
+			near, far = far, near
+		}
+		search(near[0], near[1], depth+1)
+		if len(best) < k || diff*diff < best[len(best)-1].c2 {
+			search(far[0], far[1], depth+1)
+		}
+	}
+	search(0, len(idx.entries), 0)
+	return idx.neighbors(best)
+}
+
+// Within returns all points within radius kilometers of p, sorted by distance.
+func (idx *Index[V]) Within(p Point, radius float64) []Neighbor[V] {
+	idx.rlock()
+	defer idx.mu.RUnlock()
+
+	q := toXYZ(p)
+	limit := 4.0 // Whole sphere.
+	if radius < math.Pi*EarthRadius {
+		c := 2 * math.Sin(radius/EarthRadius/2)
+		limit = c * c
+	}
+	var found []candidate
+	var search func(lo, hi, depth int)
+	search = func(lo, hi, depth int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		e := &idx.entries[mid]
+		if c2 := chord2(q, e.xyz); c2 <= limit {
+			found = append(found, candidate{i: mid, c2: c2})
+		}
+		diff := q[depth%3] - e.xyz[depth%3]
+		if diff <= 0 || diff*diff <= limit {
+			search(lo, mid, depth+1)
+		}
+		if diff >= 0 || diff*diff <= limit {
+			search(mid+1, hi, depth+1)
+		}
+	}
+	search(0, len(idx.entries), 0)
+	sort.Slice(found, func(i, j int) bool { return found[i].c2 < found[j].c2 })
+	return idx.neighbors(found)
+}
+
+func (idx *Index[V]) neighbors(cands []candidate) []Neighbor[V] {
+	res := make([]Neighbor[V], len(cands))
+	for i, c := range cands {
+		e := idx.entries[c.i]
+		res[i] = Neighbor[V]{Point: e.point, Value: e.value, Distance: chordToDistance(c.c2)}
+	}
+	return res
+}
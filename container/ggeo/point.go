@@ -0,0 +1,58 @@
+package ggeo
+
+import (
+	"fmt"
+	"github.com/cryptowilliam/goutil/container/ggeometry"
+	"math"
+)
+
+// EarthRadius is equatorial radius of earth in kilometers, all distances in this package are in kilometers.
+const EarthRadius = 6378.137
+
+// Point is a location in degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%g,%g)", p.Lat, p.Lon)
+}
+
+// normalizeLon wraps longitude into [-180, 180).
+func normalizeLon(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// Distance returns great-circle distance between p and q by haversine formula,
+// it is accurate for small distances too, unlike GeoDistance.
+func Distance(p, q Point) float64 {
+	lat1, lat2 := ggeometry.AngleToRadian(p.Lat), ggeometry.AngleToRadian(q.Lat)
+	dLat := lat2 - lat1
+	dLon := ggeometry.AngleToRadian(q.Lon - p.Lon)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing returns initial bearing from p to q in degrees [0, 360), 0 is north and 90 is east.
+func Bearing(p, q Point) float64 {
+	lat1, lat2 := ggeometry.AngleToRadian(p.Lat), ggeometry.AngleToRadian(q.Lat)
+	dLon := ggeometry.AngleToRadian(q.Lon - p.Lon)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(ggeometry.RadianToAngle(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns point reached by travelling distance kilometers from p along great circle with initial bearing.
+func Destination(p Point, bearing, distance float64) Point {
+	lat1, lon1 := ggeometry.AngleToRadian(p.Lat), ggeometry.AngleToRadian(p.Lon)
+	brng := ggeometry.AngleToRadian(bearing)
+	d := distance / EarthRadius
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Lat: ggeometry.RadianToAngle(lat2), Lon: normalizeLon(ggeometry.RadianToAngle(lon2))}
+}
@@ -0,0 +1,109 @@
+package ggeo
+
+import (
+	"github.com/cryptowilliam/goutil/container/ggeometry"
+	"math"
+)
+
+type (
+	// Polygon is a closed ring of points, the last point doesn't need to repeat the first one.
+	Polygon []Point
+
+	// Polyline is a path of points.
+	Polyline []Point
+)
+
+// Contains checks whether p is inside polygon by ray casting, points on edges may be inside or outside.
+// Edges are straight lines in lat/lon plane, which is fine for polygons much smaller than a hemisphere.
+func (pg Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(pg)-1; i < len(pg); j, i = i, i+1 {
+		a, b := pg[i], pg[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Box returns bounding box of polygon.
+func (pg Polygon) Box() Box {
+	return Polyline(pg).Box()
+}
+
+// Box returns bounding box of polyline, it doesn't handle the 180th meridian.
+func (pl Polyline) Box() Box {
+	if len(pl) == 0 {
+		return Box{}
+	}
+	b := Box{MinLat: pl[0].Lat, MinLon: pl[0].Lon, MaxLat: pl[0].Lat, MaxLon: pl[0].Lon}
+	for _, p := range pl[1:] {
+		b.MinLat, b.MaxLat = math.Min(b.MinLat, p.Lat), math.Max(b.MaxLat, p.Lat)
+		b.MinLon, b.MaxLon = math.Min(b.MinLon, p.Lon), math.Max(b.MaxLon, p.Lon)
+	}
+	return b
+}
+
+// Length returns total great-circle length of polyline in kilometers.
+func (pl Polyline) Length() float64 {
+	total := 0.0
+	for i := 1; i < len(pl); i++ {
+		total += Distance(pl[i-1], pl[i])
+	}
+	return total
+}
+
+// Simplify removes points by Douglas-Peucker algorithm, every removed point is within tolerance kilometers
+// of the simplified polyline. The first and last points are always kept.
+func (pl Polyline) Simplify(tolerance float64) Polyline {
+	if len(pl) < 3 {
+		return append(Polyline{}, pl...)
+	}
+	keep := make([]bool, len(pl))
+	keep[0], keep[len(pl)-1] = true, true
+
+	type span struct{ first, last int }
+	stack := []span{{0, len(pl) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		maxDist, maxIdx := 0.0, -1
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(pl[i], pl[s.first], pl[s.last]); d > maxDist {
+				maxDist, maxIdx = d, i
+			}
+		}
+		if maxIdx >= 0 && maxDist > tolerance {
+			keep[maxIdx] = true
+			stack = append(stack, span{s.first, maxIdx}, span{maxIdx, s.last})
+		}
+	}
+
+	var res Polyline
+	for i, p := range pl {
+		if keep[i] {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// segmentDistance returns distance in kilometers from p to segment ab,
+// points are projected to a local plane around a, which is accurate for short segments.
+func segmentDistance(p, a, b Point) float64 {
+	cosLat := math.Cos(ggeometry.AngleToRadian(a.Lat))
+	project := func(q Point) (float64, float64) {
+		x := ggeometry.AngleToRadian(normalizeLon(q.Lon-a.Lon)) * cosLat * EarthRadius
+		y := ggeometry.AngleToRadian(q.Lat-a.Lat) * EarthRadius
+		return x, y
+	}
+	px, py := project(p)
+	bx, by := project(b)
+	l2 := bx*bx + by*by
+	if l2 == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/l2))
+	return math.Hypot(px-t*bx, py-t*by)
+}
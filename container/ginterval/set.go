@@ -0,0 +1,238 @@
+package ginterval
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"sort"
+	"strings"
+)
+
+type (
+	// IntervalSet is a union of intervals, it is always normalized:
+	// intervals are sorted, non-empty, and neither overlapping nor adjacent,
+	// so [1,2)∪[2,3] is stored as [1,3] but (1,2)∪(2,3) is kept.
+	IntervalSet struct {
+		intervals []Interval
+	}
+)
+
+const (
+	unionSep = "∪"
+	emptySet = "∅"
+)
+
+// NewSet creates normalized set of intervals.
+func NewSet(intervals ...Interval) *IntervalSet {
+	return &IntervalSet{intervals: normalize(intervals)}
+}
+
+// ParseSet parses set string like "[1,2]∪(3,+∞)", "∅" is the empty set.
+func ParseSet(s string) (*IntervalSet, error) {
+	s = strings.TrimSpace(s)
+	if s == emptySet || s == "" {
+		return NewSet(), nil
+	}
+	var intervals []Interval
+	for _, part := range strings.Split(s, unionSep) {
+		i, err := Parse(part)
+		if err != nil {
+			return nil, gerrors.New("invalid interval set string %s", s)
+		}
+		intervals = append(intervals, *i)
+	}
+	return NewSet(intervals...), nil
+}
+
+func cmpDecimal(a, b gdecimal.Decimal) int {
+	if a.LessThan(b) {
+		return -1
+	}
+	if a.GreaterThan(b) {
+		return 1
+	}
+	return 0
+}
+
+// cmpLower compares lower bounds, -∞ is the smallest, and "[x" is smaller than "(x".
+func cmpLower(a, b Interval) int {
+	switch {
+	case a.Min == nil && b.Min == nil:
+		return 0
+	case a.Min == nil:
+		return -1
+	case b.Min == nil:
+		return 1
+	}
+	if c := cmpDecimal(*a.Min, *b.Min); c != 0 || a.IncludeMin == b.IncludeMin {
+		return c
+	}
+	if a.IncludeMin {
+		return -1
+	}
+	return 1
+}
+
+// cmpUpper compares upper bounds, +∞ is the largest, and "x]" is larger than "x)".
+func cmpUpper(a, b Interval) int {
+	switch {
+	case a.Max == nil && b.Max == nil:
+		return 0
+	case a.Max == nil:
+		return 1
+	case b.Max == nil:
+		return -1
+	}
+	if c := cmpDecimal(*a.Max, *b.Max); c != 0 || a.IncludeMax == b.IncludeMax {
+		return c
+	}
+	if a.IncludeMax {
+		return 1
+	}
+	return -1
+}
+
+// IsEmpty reports whether interval contains no number, like (1,1) or [2,1].
+func (i *Interval) IsEmpty() bool {
+	if i.Min == nil || i.Max == nil {
+		return false
+	}
+	c := cmpDecimal(*i.Min, *i.Max)
+	return c > 0 || (c == 0 && !(i.IncludeMin && i.IncludeMax))
+}
+
+// Intersect returns common part of two intervals, and false if they don't overlap.
+func (i *Interval) Intersect(cmp Interval) (Interval, bool) {
+	res := *i
+	if cmpLower(cmp, res) > 0 {
+		res.Min, res.IncludeMin = cmp.Min, cmp.IncludeMin
+	}
+	if cmpUpper(cmp, res) < 0 {
+		res.Max, res.IncludeMax = cmp.Max, cmp.IncludeMax
+	}
+	return res, !res.IsEmpty()
+}
+
+// connected reports whether a and b can be merged into one interval, lower bound of a must not be larger than b.
+func connected(a, b Interval) bool {
+	if a.Max == nil || b.Min == nil {
+		return true
+	}
+	c := cmpDecimal(*a.Max, *b.Min)
+	return c > 0 || (c == 0 && (a.IncludeMax || b.IncludeMin))
+}
+
+func normalize(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, i := range intervals {
+		if !i.IsEmpty() {
+			sorted = append(sorted, i)
+		}
+	}
+	sort.Slice(sorted, func(a, b int) bool { return cmpLower(sorted[a], sorted[b]) < 0 })
+
+	var res []Interval
+	for _, i := range sorted {
+		if n := len(res); n > 0 && connected(res[n-1], i) {
+			if cmpUpper(i, res[n-1]) > 0 {
+				res[n-1].Max, res[n-1].IncludeMax = i.Max, i.IncludeMax
+			}
+			continue
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
+// Intervals returns a copy of normalized intervals.
+func (s *IntervalSet) Intervals() []Interval {
+	return append([]Interval{}, s.intervals...)
+}
+
+func (s *IntervalSet) IsEmpty() bool {
+	return len(s.intervals) == 0
+}
+
+// Contains checks whether n is in any interval, it costs O(log n).
+func (s *IntervalSet) Contains(n gdecimal.Decimal) bool {
+	// Find the first interval which doesn't end before n.
+	i := sort.Search(len(s.intervals), func(i int) bool {
+		iv := s.intervals[i]
+		if iv.Max == nil {
+			return true
+		}
+		c := cmpDecimal(*iv.Max, n)
+		return c > 0 || (c == 0 && iv.IncludeMax)
+	})
+	return i < len(s.intervals) && s.intervals[i].Contains(n)
+}
+
+func (s *IntervalSet) Union(others ...*IntervalSet) *IntervalSet {
+	all := s.Intervals()
+	for _, o := range others {
+		all = append(all, o.intervals...)
+	}
+	return NewSet(all...)
+}
+
+func (s *IntervalSet) Intersect(other *IntervalSet) *IntervalSet {
+	var res []Interval
+	a, b := s.intervals, other.intervals
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if iv, ok := a[i].Intersect(b[j]); ok {
+			res = append(res, iv)
+		}
+		// Drop the interval which ends first, it can't overlap others.
+		if cmpUpper(a[i], b[j]) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return &IntervalSet{intervals: normalize(res)}
+}
+
+// Complement returns all numbers not in s.
+func (s *IntervalSet) Complement() *IntervalSet {
+	var res []Interval
+	prev := Interval{} // (-∞, ...
+	for _, iv := range s.intervals {
+		if iv.Min != nil {
+			res = append(res, Interval{Min: prev.Min, IncludeMin: prev.IncludeMin, Max: iv.Min, IncludeMax: !iv.IncludeMin})
+		}
+		if iv.Max == nil {
+			return &IntervalSet{intervals: normalize(res)}
+		}
+		prev = Interval{Min: iv.Max, IncludeMin: !iv.IncludeMax}
+	}
+	res = append(res, prev)
+	return &IntervalSet{intervals: normalize(res)}
+}
+
+// Difference returns numbers in s but not in other.
+func (s *IntervalSet) Difference(other *IntervalSet) *IntervalSet {
+	return s.Intersect(other.Complement())
+}
+
+func (s *IntervalSet) Equal(other *IntervalSet) bool {
+	if len(s.intervals) != len(other.intervals) {
+		return false
+	}
+	for i := range s.intervals {
+		if cmpLower(s.intervals[i], other.intervals[i]) != 0 || cmpUpper(s.intervals[i], other.intervals[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats set like "[1,2]∪(3,+∞)".
+func (s *IntervalSet) String() string {
+	if len(s.intervals) == 0 {
+		return emptySet
+	}
+	ss := make([]string, len(s.intervals))
+	for i := range s.intervals {
+		ss[i] = s.intervals[i].String()
+	}
+	return strings.Join(ss, unionSep)
+}
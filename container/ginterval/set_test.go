@@ -0,0 +1,131 @@
+package ginterval
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gtest"
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"testing"
+)
+
+func mustSet(t *testing.T, s string) *IntervalSet {
+	set, err := ParseSet(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func mustDecimal(t *testing.T, s string) gdecimal.Decimal {
+	d, err := gdecimal.NewFromString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestIntervalSet_Normalize(t *testing.T) {
+	cl := gtest.NewCaseList()
+
+	cl.New().Input("[1,2)∪[2,3]").Expect("[1,3]")
+	cl.New().Input("(1,2)∪(2,3)").Expect("(1,2)∪(2,3)")
+	cl.New().Input("(1,2]∪(2,3)").Expect("(1,3)")
+	cl.New().Input("[5,6]∪[1,3]∪[2,4]").Expect("[1,4]∪[5,6]")
+	cl.New().Input("(-∞,1)∪[0,+∞)").Expect("(-∞,+∞)")
+	cl.New().Input("[1,10]∪(2,3)").Expect("[1,10]")
+	cl.New().Input("∅").Expect("∅")
+
+	for _, v := range cl.Get() {
+		if got := mustSet(t, v.Inputs[0].(string)).String(); got != v.Expects[0].(string) {
+			gtest.PrintlnExit(t, "normalize %s: expect %s, got %s", v.Inputs[0], v.Expects[0], got)
+		}
+	}
+}
+
+func TestIntervalSet_Algebra(t *testing.T) {
+	cl := gtest.NewCaseList()
+
+	// Inputs: a, b. Expects: union, intersection, difference a-b.
+	cl.New().Input("[1,5]").Input("[3,8)").Expect("[1,8)").Expect("[3,5]").Expect("[1,3)")
+	cl.New().Input("[1,5]").Input("(5,8)").Expect("[1,8)").Expect("∅").Expect("[1,5]")
+	cl.New().Input("[1,5)").Input("(5,8)").Expect("[1,5)∪(5,8)").Expect("∅").Expect("[1,5)")
+	cl.New().Input("(-∞,0]∪[10,20]").Input("[5,15]").Expect("(-∞,0]∪[5,20]").Expect("[10,15]").Expect("(-∞,0]∪(15,20]")
+	cl.New().Input("[0,10]").Input("[2,3]∪(5,6)").Expect("[0,10]").Expect("[2,3]∪(5,6)").Expect("[0,2)∪(3,5]∪[6,10]")
+	cl.New().Input("(-∞,+∞)").Input("[1,1]").Expect("(-∞,+∞)").Expect("[1,1]").Expect("(-∞,1)∪(1,+∞)")
+
+	for _, v := range cl.Get() {
+		a, b := mustSet(t, v.Inputs[0].(string)), mustSet(t, v.Inputs[1].(string))
+		if got := a.Union(b).String(); got != v.Expects[0].(string) {
+			gtest.PrintlnExit(t, "%s ∪ %s: expect %s, got %s", a, b, v.Expects[0], got)
+		}
+		if got := a.Intersect(b).String(); got != v.Expects[1].(string) {
+			gtest.PrintlnExit(t, "%s ∩ %s: expect %s, got %s", a, b, v.Expects[1], got)
+		}
+		if got := a.Difference(b).String(); got != v.Expects[2].(string) {
+			gtest.PrintlnExit(t, "%s - %s: expect %s, got %s", a, b, v.Expects[2], got)
+		}
+		// Complement of complement is itself.
+		if !a.Complement().Complement().Equal(a) {
+			gtest.PrintlnExit(t, "complement of complement of %s is %s", a, a.Complement().Complement())
+		}
+	}
+
+	if s := NewSet().Complement().String(); s != "(-∞,+∞)" {
+		t.Errorf("complement of empty set is %s", s)
+		return
+	}
+	if s := mustSet(t, "(-∞,1)∪[3,+∞)").Complement().String(); s != "[1,3)" {
+		t.Errorf("unexpected complement %s", s)
+	}
+}
+
+func TestIntervalSet_Contains(t *testing.T) {
+	set := mustSet(t, "(-∞,-10)∪[0,1)∪(1,2]∪(5,+∞)")
+	for s, expect := range map[string]bool{
+		"-100": true, "-10": false, "0": true, "0.5": true, "1": false,
+		"2": true, "3": false, "5": false, "5.01": true,
+	} {
+		if got := set.Contains(mustDecimal(t, s)); got != expect {
+			t.Errorf("Contains(%s): expect %v, got %v", s, expect, got)
+		}
+	}
+}
+
+func TestTree_Stab(t *testing.T) {
+	tree := NewTree[string]()
+	for s, name := range map[string]string{
+		"[0,100)":  "small",
+		"[50,500]": "medium",
+		"(400,+∞)": "large",
+		"(-∞,0)":   "negative",
+		"[80,80]":  "exact",
+	} {
+		i, err := Parse(s)
+		gtest.Assert(t, err)
+		tree.Insert(*i, name)
+	}
+
+	cl := gtest.NewCaseList()
+	cl.New().Input("-1").Expect("negative")
+	cl.New().Input("0").Expect("small")
+	cl.New().Input("80").Expect("small,medium,exact")
+	cl.New().Input("100").Expect("medium")
+	cl.New().Input("450").Expect("medium,large")
+	cl.New().Input("500.1").Expect("large")
+
+	for _, v := range cl.Get() {
+		got := ""
+		for i, name := range tree.Stab(mustDecimal(t, v.Inputs[0].(string))) {
+			if i > 0 {
+				got += ","
+			}
+			got += name
+		}
+		if got != v.Expects[0].(string) {
+			gtest.PrintlnExit(t, "Stab(%s): expect %s, got %s", v.Inputs[0], v.Expects[0], got)
+		}
+	}
+
+	i, _ := Parse("(100,400]")
+	if got := tree.Overlaps(*i); len(got) != 1 || got[0] != "medium" {
+		t.Errorf("unexpected overlaps %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package ginterval
+
+import (
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"sort"
+	"sync"
+)
+
+type (
+	// Tree is an interval tree of possibly overlapping intervals with values,
+	// it answers stabbing queries in O(log n + k) instead of checking every interval.
+	// Intervals are kept sorted by lower bound as an implicit balanced tree,
+	// every node records the largest upper bound of its subtree.
+	// Tree is rebuilt lazily on the first query after Insert, it is safe for concurrent use.
+	Tree[V any] struct {
+		mu       sync.RWMutex
+		entries  []treeEntry[V]
+		maxUpper []Interval
+		dirty    bool
+	}
+
+	treeEntry[V any] struct {
+		interval Interval
+		value    V
+	}
+)
+
+func NewTree[V any]() *Tree[V] {
+	return &Tree[V]{}
+}
+
+// Insert adds interval with its value, empty interval is ignored.
+func (t *Tree[V]) Insert(interval Interval, value V) {
+	if interval.IsEmpty() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, treeEntry[V]{interval: interval, value: value})
+	t.dirty = true
+}
+
+func (t *Tree[V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.entries)
+}
+
+func (t *Tree[V]) build() {
+	sort.SliceStable(t.entries, func(i, j int) bool {
+		return cmpLower(t.entries[i].interval, t.entries[j].interval) < 0
+	})
+	t.maxUpper = make([]Interval, len(t.entries))
+	t.buildRange(0, len(t.entries))
+	t.dirty = false
+}
+
+func (t *Tree[V]) buildRange(lo, hi int) (Interval, bool) {
+	if lo >= hi {
+		return Interval{}, false
+	}
+	mid := (lo + hi) / 2
+	up := t.entries[mid].interval
+	for _, r := range [][2]int{{lo, mid}, {mid + 1, hi}} {
+		if child, ok := t.buildRange(r[0], r[1]); ok && cmpUpper(child, up) > 0 {
+			up = child
+		}
+	}
+	t.maxUpper[mid] = up
+	return up, true
+}
+
+// rlock read locks t and rebuilds it first if needed.
+func (t *Tree[V]) rlock() {
+	t.mu.RLock()
+	if !t.dirty {
+		return
+	}
+	t.mu.RUnlock()
+	t.mu.Lock()
+	if t.dirty {
+		t.build()
+	}
+	t.mu.Unlock()
+	t.mu.RLock()
+}
+
+// Stab returns values of all intervals which contain n, in order of their lower bounds.
+func (t *Tree[V]) Stab(n gdecimal.Decimal) []V {
+	return t.Overlaps(Interval{Min: &n, IncludeMin: true, Max: &n, IncludeMax: true})
+}
+
+// Overlaps returns values of all intervals which overlap interval, in order of their lower bounds.
+func (t *Tree[V]) Overlaps(interval Interval) []V {
+	t.rlock()
+	defer t.mu.RUnlock()
+	var res []V
+	t.query(0, len(t.entries), interval, &res)
+	return res
+}
+
+func (t *Tree[V]) query(lo, hi int, q Interval, res *[]V) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	// Every interval in this subtree ends before q.
+	up := t.maxUpper[mid]
+	if !(&Interval{Max: up.Max, IncludeMax: up.IncludeMax}).IsOverlap(q) {
+		return
+	}
+	t.query(lo, mid, q, res)
+	// Intervals on the right start no earlier than mid, skip them if mid starts after q.
+	e := t.entries[mid]
+	if !(&Interval{Min: e.interval.Min, IncludeMin: e.interval.IncludeMin}).IsOverlap(q) {
+		return
+	}
+	if e.interval.IsOverlap(q) {
+		*res = append(*res, e.value)
+	}
+	t.query(mid+1, hi, q, res)
+}
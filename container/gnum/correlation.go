@@ -0,0 +1,47 @@
+package gnum
+
+import "math"
+
+// Covariance (协方差)
+// ddof is the same as Std, x and y must have the same length.
+func Covariance(x, y []float64, ddof int) float64 {
+	if len(x) != len(y) || len(x) <= ddof {
+		return math.NaN()
+	}
+	mx, my := Mean(x), Mean(y)
+	ret := 0.
+	for i := range x {
+		ret += (x[i] - mx) * (y[i] - my)
+	}
+	return ret / float64(len(x)-ddof)
+}
+
+// Correlation (皮尔逊相关系数)
+// Pearson correlation coefficient in [-1, 1], NaN if x or y is constant.
+func Correlation(x, y []float64) float64 {
+	return Covariance(x, y, 0) / (Std(x, 0) * Std(y, 0))
+}
+
+// CovarianceMatrix returns covariance of every two variables, every element of vars is all samples of a variable.
+func CovarianceMatrix(vars [][]float64, ddof int) [][]float64 {
+	return pairMatrix(vars, func(x, y []float64) float64 { return Covariance(x, y, ddof) })
+}
+
+// CorrelationMatrix returns correlation of every two variables, every element of vars is all samples of a variable.
+func CorrelationMatrix(vars [][]float64) [][]float64 {
+	return pairMatrix(vars, Correlation)
+}
+
+func pairMatrix(vars [][]float64, fn func(x, y []float64) float64) [][]float64 {
+	ret := make([][]float64, len(vars))
+	for i := range ret {
+		ret[i] = make([]float64, len(vars))
+	}
+	for i := range vars {
+		for j := i; j < len(vars); j++ {
+			ret[i][j] = fn(vars[i], vars[j])
+			ret[j][i] = ret[i][j]
+		}
+	}
+	return ret
+}
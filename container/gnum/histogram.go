@@ -0,0 +1,59 @@
+package gnum
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"math"
+	"sort"
+)
+
+// Histogram (直方图)
+// Counts[i] is number of values in [Edges[i], Edges[i+1]), the last bin includes its right edge.
+type Histogram struct {
+	Edges  []float64
+	Counts []int
+}
+
+// NewHistogram splits [min, max] of values into bins equal width bins.
+func NewHistogram(values []float64, bins int) (*Histogram, error) {
+	if bins <= 0 || len(values) == 0 {
+		return nil, gerrors.New("bins and values must not be empty")
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = lo + (hi-lo)*float64(i)/float64(bins)
+	}
+	edges[bins] = hi
+	return NewHistogramWithEdges(values, edges)
+}
+
+// NewHistogramWithEdges counts values by ascending edges, values out of [edges[0], edges[len-1]] are ignored.
+func NewHistogramWithEdges(values []float64, edges []float64) (*Histogram, error) {
+	if len(edges) < 2 || !sort.Float64sAreSorted(edges) {
+		return nil, gerrors.New("edges must be ascending and have at least 2 elements")
+	}
+	h := &Histogram{Edges: append([]float64{}, edges...), Counts: make([]int, len(edges)-1)}
+	for _, v := range values {
+		if i := h.Bin(v); i >= 0 {
+			h.Counts[i]++
+		}
+	}
+	return h, nil
+}
+
+// Bin returns index of bin which v belongs to, or -1 if v is out of range.
+func (h *Histogram) Bin(v float64) int {
+	last := len(h.Edges) - 1
+	if math.IsNaN(v) || v < h.Edges[0] || v > h.Edges[last] {
+		return -1
+	}
+	if v == h.Edges[last] {
+		return last - 1
+	}
+	return sort.Search(last, func(i int) bool { return h.Edges[i+1] > v })
+}
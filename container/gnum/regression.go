@@ -0,0 +1,127 @@
+package gnum
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"math"
+)
+
+// Regression is the result of least-squares regression y = c0 + c1*x1 + c2*x2 + ...
+type Regression struct {
+	// Coefficients[0] is intercept, Coefficients[i] is coefficient of the i-th variable.
+	Coefficients []float64
+	R2           float64
+	AdjustedR2   float64
+	N            int
+}
+
+// LinearRegression fits y = intercept + slope*x.
+func LinearRegression(x, y []float64) (*Regression, error) {
+	rows := make([][]float64, len(x))
+	for i, v := range x {
+		rows[i] = []float64{v}
+	}
+	return MultipleRegression(rows, y)
+}
+
+// MultipleRegression fits y by multiple variables, every element of rows is variables of a sample.
+func MultipleRegression(rows [][]float64, y []float64) (*Regression, error) {
+	if len(rows) != len(y) || len(rows) == 0 {
+		return nil, gerrors.New("rows and y must have the same non-zero length")
+	}
+	k := len(rows[0]) + 1
+	if len(rows) < k {
+		return nil, gerrors.Errorf("at least %d samples are required", k)
+	}
+
+	// Normal equations (X'X)c = X'y, X has a leading column of 1 for intercept.
+	a := make([][]float64, k)
+	b := make([]float64, k)
+	for i := range a {
+		a[i] = make([]float64, k)
+	}
+	x := make([]float64, k)
+	for r, row := range rows {
+		if len(row) != k-1 {
+			return nil, gerrors.Errorf("row %d has %d variables, expect %d", r, len(row), k-1)
+		}
+		x[0] = 1
+		copy(x[1:], row)
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				a[i][j] += x[i] * x[j]
+			}
+			b[i] += x[i] * y[r]
+		}
+	}
+	coef, err := SolveLinear(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Regression{Coefficients: coef, N: len(y)}
+	my := Mean(y)
+	ssr, sst := 0., 0.
+	for r, row := range rows {
+		e := y[r] - res.Predict(row...)
+		ssr += e * e
+		sst += (y[r] - my) * (y[r] - my)
+	}
+	res.R2 = 1 - ssr/sst
+	if n := len(y); n > k {
+		res.AdjustedR2 = 1 - (1-res.R2)*float64(n-1)/float64(n-k)
+	} else {
+		res.AdjustedR2 = math.NaN()
+	}
+	return res, nil
+}
+
+// Predict returns fitted y of variables.
+func (r *Regression) Predict(vars ...float64) float64 {
+	ret := r.Coefficients[0]
+	for i, v := range vars {
+		ret += r.Coefficients[i+1] * v
+	}
+	return ret
+}
+
+// SolveLinear solves a*x = b by Gaussian elimination with partial pivoting, a and b are not modified.
+func SolveLinear(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	if len(a) != n {
+		return nil, gerrors.New("matrix has %d rows but vector has %d values", len(a), n)
+	}
+	m := make([][]float64, n)
+	for i := range m {
+		if len(a[i]) != n {
+			return nil, gerrors.New("matrix must be square")
+		}
+		m[i] = append(append(make([]float64, 0, n+1), a[i]...), b[i])
+	}
+	for c := 0; c < n; c++ {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if math.Abs(m[p][c]) < 1e-12 {
+			return nil, gerrors.New("singular matrix")
+		}
+		m[c], m[p] = m[p], m[c]
+		for r := c + 1; r < n; r++ {
+			f := m[r][c] / m[c][c]
+			for j := c; j <= n; j++ {
+				m[r][j] -= f * m[c][j]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := m[i][n]
+		for j := i + 1; j < n; j++ {
+			s -= m[i][j] * x[j]
+		}
+		x[i] = s / m[i][i]
+	}
+	return x, nil
+}
@@ -4,7 +4,10 @@ package gnum
 statistic functions
 */
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 // Sum (和)
 func Sum(values []float64) float64 {
@@ -40,4 +43,126 @@ func Std(values []float64, ddof int) float64 {
 }
 
 // Variance (方差)
-// func Var(sample []float64, wholePop bool) float64
+// ddof is the same as Std.
+func Variance(values []float64, ddof int) float64 {
+	if len(values) <= ddof {
+		return math.NaN()
+	}
+	m := Mean(values)
+	ss := 0.
+	for _, v := range values {
+		d := v - m
+		ss += d * d
+	}
+	return ss / float64(len(values)-ddof)
+}
+
+// Median (中位数)
+func Median(values []float64) float64 {
+	return Quantile(values, 0.5)
+}
+
+// Quantile (分位数)
+// q is in [0, 1], result is linear interpolated between closest ranks, the same as numpy.quantile.
+func Quantile(values []float64, q float64) float64 {
+	return Quantiles(values, q)[0]
+}
+
+// Quantiles returns multiple quantiles with only one sorting.
+func Quantiles(values []float64, qs ...float64) []float64 {
+	ret := make([]float64, len(qs))
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	for i, q := range qs {
+		ret[i] = quantileSorted(sorted, q)
+	}
+	return ret
+}
+
+func quantileSorted(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo == len(sorted)-1 {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(pos-float64(lo))
+}
+
+// Mode (众数)
+// All values with the highest frequency are returned in ascending order.
+func Mode(values []float64) []float64 {
+	counts := make(map[float64]int)
+	maxCount := 0
+	for _, v := range values {
+		counts[v]++
+		if counts[v] > maxCount {
+			maxCount = counts[v]
+		}
+	}
+	var ret []float64
+	for v, c := range counts {
+		if c == maxCount {
+			ret = append(ret, v)
+		}
+	}
+	sort.Float64s(ret)
+	return ret
+}
+
+// centralMoment returns the k-th central moment.
+func centralMoment(values []float64, k float64) float64 {
+	m := Mean(values)
+	ret := 0.
+	for _, v := range values {
+		ret += math.Pow(v-m, k)
+	}
+	return ret / float64(len(values))
+}
+
+// Skewness (偏度)
+// Population skewness g1 = m3 / m2^1.5, positive means long right tail.
+func Skewness(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return centralMoment(values, 3) / math.Pow(centralMoment(values, 2), 1.5)
+}
+
+// Kurtosis (峰度)
+// Population excess kurtosis g2 = m4 / m2^2 - 3, normal distribution is 0.
+func Kurtosis(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	m2 := centralMoment(values, 2)
+	return centralMoment(values, 4)/(m2*m2) - 3
+}
+
+type Summary struct {
+	Count  int
+	Mean   float64
+	Std    float64 // sample standard deviation
+	Min    float64
+	Q1     float64
+	Median float64
+	Q3     float64
+	Max    float64
+}
+
+// Describe returns summary statistics like pandas.DataFrame.describe.
+func Describe(values []float64) Summary {
+	qs := Quantiles(values, 0, 0.25, 0.5, 0.75, 1)
+	return Summary{
+		Count:  len(values),
+		Mean:   Mean(values),
+		Std:    Std(values, 1),
+		Min:    qs[0],
+		Q1:     qs[1],
+		Median: qs[2],
+		Q3:     qs[3],
+		Max:    qs[4],
+	}
+}
@@ -0,0 +1,245 @@
+package gnum
+
+import (
+	"math"
+	"sort"
+)
+
+/**
+streaming statistic, values are added one by one and never stored
+*/
+
+// OnlineStats updates count, mean, variance, skewness, kurtosis, min and max incrementally,
+// see Welford's and Terriberry's algorithms. It is not concurrency-safe.
+type OnlineStats struct {
+	n          int
+	mean       float64
+	m2, m3, m4 float64
+	min, max   float64
+}
+
+func (s *OnlineStats) Add(values ...float64) {
+	for _, x := range values {
+		n1 := float64(s.n)
+		s.n++
+		n := float64(s.n)
+		delta := x - s.mean
+		deltaN := delta / n
+		deltaN2 := deltaN * deltaN
+		term1 := delta * deltaN * n1
+		s.mean += deltaN
+		s.m4 += term1*deltaN2*(n*n-3*n+3) + 6*deltaN2*s.m2 - 4*deltaN*s.m3
+		s.m3 += term1*deltaN*(n-2) - 3*deltaN*s.m2
+		s.m2 += term1
+		if s.n == 1 || x < s.min {
+			s.min = x
+		}
+		if s.n == 1 || x > s.max {
+			s.max = x
+		}
+	}
+}
+
+// Merge adds all values of other into s, so stats of shards can be computed in parallel.
+func (s *OnlineStats) Merge(other *OnlineStats) {
+	if other.n == 0 {
+		return
+	}
+	if s.n == 0 {
+		*s = *other
+		return
+	}
+	na, nb := float64(s.n), float64(other.n)
+	n := na + nb
+	delta := other.mean - s.mean
+	delta2 := delta * delta
+	m2 := s.m2 + other.m2 + delta2*na*nb/n
+	m3 := s.m3 + other.m3 + delta*delta2*na*nb*(na-nb)/(n*n) +
+		3*delta*(na*other.m2-nb*s.m2)/n
+	m4 := s.m4 + other.m4 + delta2*delta2*na*nb*(na*na-na*nb+nb*nb)/(n*n*n) +
+		6*delta2*(na*na*other.m2+nb*nb*s.m2)/(n*n) + 4*delta*(na*other.m3-nb*s.m3)/n
+	s.mean += delta * nb / n
+	s.m2, s.m3, s.m4 = m2, m3, m4
+	s.n += other.n
+	s.min = math.Min(s.min, other.min)
+	s.max = math.Max(s.max, other.max)
+}
+
+func (s *OnlineStats) Count() int {
+	return s.n
+}
+
+func (s *OnlineStats) Mean() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.mean
+}
+
+// Variance returns variance, ddof is the same as Std.
+func (s *OnlineStats) Variance(ddof int) float64 {
+	if s.n <= ddof {
+		return math.NaN()
+	}
+	return s.m2 / float64(s.n-ddof)
+}
+
+func (s *OnlineStats) Std(ddof int) float64 {
+	return math.Sqrt(s.Variance(ddof))
+}
+
+// Skewness returns population skewness, the same as Skewness.
+func (s *OnlineStats) Skewness() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(float64(s.n)) * s.m3 / math.Pow(s.m2, 1.5)
+}
+
+// Kurtosis returns population excess kurtosis, the same as Kurtosis.
+func (s *OnlineStats) Kurtosis() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return float64(s.n)*s.m4/(s.m2*s.m2) - 3
+}
+
+func (s *OnlineStats) Min() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.min
+}
+
+func (s *OnlineStats) Max() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.max
+}
+
+// OnlineRegression updates covariance, correlation and simple linear regression of (x, y) pairs incrementally.
+type OnlineRegression struct {
+	x, y OnlineStats
+	cxy  float64 // sum of (x - mean x) * (y - mean y)
+}
+
+func (r *OnlineRegression) Add(x, y float64) {
+	dx := x - r.x.mean
+	r.x.Add(x)
+	r.y.Add(y)
+	r.cxy += dx * (y - r.y.mean)
+}
+
+func (r *OnlineRegression) Count() int {
+	return r.x.n
+}
+
+// Covariance returns covariance, ddof is the same as Std.
+func (r *OnlineRegression) Covariance(ddof int) float64 {
+	if r.x.n <= ddof {
+		return math.NaN()
+	}
+	return r.cxy / float64(r.x.n-ddof)
+}
+
+func (r *OnlineRegression) Correlation() float64 {
+	return r.cxy / math.Sqrt(r.x.m2*r.y.m2)
+}
+
+func (r *OnlineRegression) Slope() float64 {
+	return r.cxy / r.x.m2
+}
+
+func (r *OnlineRegression) Intercept() float64 {
+	return r.y.Mean() - r.Slope()*r.x.Mean()
+}
+
+// R2 returns coefficient of determination, it is square of correlation for simple linear regression.
+func (r *OnlineRegression) R2() float64 {
+	c := r.Correlation()
+	return c * c
+}
+
+// OnlineQuantile estimates a quantile with constant memory by P-square algorithm,
+// see Jain & Chlamtac (1985). The estimation is exact until more than 5 values are added.
+type OnlineQuantile struct {
+	q       float64
+	heights []float64  // marker heights
+	pos     [5]float64 // actual marker positions
+	desired [5]float64 // desired marker positions
+	inc     [5]float64 // increments of desired positions
+}
+
+// NewOnlineQuantile creates estimator of quantile q in (0, 1).
+func NewOnlineQuantile(q float64) *OnlineQuantile {
+	return &OnlineQuantile{
+		q:       q,
+		pos:     [5]float64{1, 2, 3, 4, 5},
+		desired: [5]float64{1, 1 + 2*q, 1 + 4*q, 3 + 2*q, 5},
+		inc:     [5]float64{0, q / 2, q, (1 + q) / 2, 1},
+	}
+}
+
+func (oq *OnlineQuantile) Add(values ...float64) {
+	for _, x := range values {
+		oq.add(x)
+	}
+}
+
+func (oq *OnlineQuantile) add(x float64) {
+	h := oq.heights
+	if len(h) < 5 {
+		oq.heights = append(h, x)
+		sort.Float64s(oq.heights)
+		return
+	}
+
+	// Find cell k of x and update extreme markers.
+	var k int
+	switch {
+	case x < h[0]:
+		h[0], k = x, 0
+	case x >= h[4]:
+		h[4], k = x, 3
+	default:
+		for k = 0; k < 3 && x >= h[k+1]; k++ {
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		oq.pos[i]++
+	}
+	for i := range oq.desired {
+		oq.desired[i] += oq.inc[i]
+	}
+
+	// Adjust heights of middle markers if necessary.
+	for i := 1; i < 4; i++ {
+		d := oq.desired[i] - oq.pos[i]
+		if (d >= 1 && oq.pos[i+1]-oq.pos[i] > 1) || (d <= -1 && oq.pos[i-1]-oq.pos[i] < -1) {
+			sign := math.Copysign(1, d)
+			hp := oq.parabolic(i, sign)
+			if h[i-1] < hp && hp < h[i+1] {
+				h[i] = hp
+			} else {
+				j := i + int(sign)
+				h[i] += sign * (h[j] - h[i]) / (oq.pos[j] - oq.pos[i])
+			}
+			oq.pos[i] += sign
+		}
+	}
+}
+
+func (oq *OnlineQuantile) parabolic(i int, d float64) float64 {
+	h, n := oq.heights, oq.pos
+	return h[i] + d/(n[i+1]-n[i-1])*((n[i]-n[i-1]+d)*(h[i+1]-h[i])/(n[i+1]-n[i])+
+		(n[i+1]-n[i]-d)*(h[i]-h[i-1])/(n[i]-n[i-1]))
+}
+
+// Value returns current estimation, NaN if no value is added.
+func (oq *OnlineQuantile) Value() float64 {
+	if len(oq.heights) < 5 || oq.pos[4] == 5 {
+		return quantileSorted(oq.heights, oq.q)
+	}
+	return oq.heights[2]
+}
@@ -0,0 +1,165 @@
+package gnum
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestDescriptive(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	if Variance(values, 0) != 4 || Std(values, 0) != 2 {
+		t.Errorf("unexpected variance %f", Variance(values, 0))
+		return
+	}
+	if Median(values) != 4.5 || Quantile(values, 0.25) != 4 || Quantile(values, 1) != 9 {
+		t.Errorf("unexpected quantiles %v", Quantiles(values, 0.25, 0.5, 1))
+		return
+	}
+	if !reflect.DeepEqual(Mode(values), []float64{4}) || !reflect.DeepEqual(Mode([]float64{1, 2, 2, 1}), []float64{1, 2}) {
+		t.Errorf("unexpected mode %v", Mode(values))
+		return
+	}
+	if !almostEqual(Skewness(values), 0.65625, 1e-9) || !almostEqual(Kurtosis(values), -0.21875, 1e-9) {
+		t.Errorf("unexpected skewness %f kurtosis %f", Skewness(values), Kurtosis(values))
+		return
+	}
+	if s := Describe(values); s.Count != 8 || s.Min != 2 || s.Max != 9 || s.Q3 != 5.5 {
+		t.Errorf("unexpected summary %+v", s)
+		return
+	}
+	if !math.IsNaN(Median(nil)) {
+		t.Error("median of empty values should be NaN")
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{2, 4, 6, 8, 10}
+	z := []float64{5, 3, 4, 1, 2}
+	if Covariance(x, y, 1) != 5 || !almostEqual(Correlation(x, y), 1, 1e-12) {
+		t.Errorf("unexpected covariance %f", Covariance(x, y, 1))
+		return
+	}
+	m := CorrelationMatrix([][]float64{x, y, z})
+	if !almostEqual(m[0][2], -0.8, 1e-12) || m[2][0] != m[0][2] || !almostEqual(m[1][1], 1, 1e-12) {
+		t.Errorf("unexpected correlation matrix %v", m)
+		return
+	}
+	if c := CovarianceMatrix([][]float64{x, y}, 0); c[0][0] != 2 || c[0][1] != 4 {
+		t.Errorf("unexpected covariance matrix %v", c)
+	}
+}
+
+func TestRegression(t *testing.T) {
+	r, err := LinearRegression([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !almostEqual(r.Coefficients[0], 1, 1e-9) || !almostEqual(r.Coefficients[1], 2, 1e-9) || !almostEqual(r.R2, 1, 1e-9) {
+		t.Errorf("unexpected regression %+v", r)
+		return
+	}
+
+	// y = 1 + 2a - 3b + noise
+	rnd := rand.New(rand.NewSource(1))
+	var rows [][]float64
+	var y []float64
+	for i := 0; i < 200; i++ {
+		a, b := rnd.Float64()*10, rnd.Float64()*10
+		rows = append(rows, []float64{a, b})
+		y = append(y, 1+2*a-3*b+rnd.NormFloat64()*0.1)
+	}
+	r, err = MultipleRegression(rows, y)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !almostEqual(r.Coefficients[1], 2, 0.01) || !almostEqual(r.Coefficients[2], -3, 0.01) || r.R2 < 0.99 || r.AdjustedR2 > r.R2 {
+		t.Errorf("unexpected regression %+v", r)
+		return
+	}
+	if !almostEqual(r.Predict(1, 1), 0, 0.05) {
+		t.Errorf("unexpected prediction %f", r.Predict(1, 1))
+		return
+	}
+	if _, err := LinearRegression([]float64{1, 1, 1}, []float64{1, 2, 3}); err == nil {
+		t.Error("constant x should fail")
+		return
+	}
+	if _, err := SolveLinear([][]float64{{1, 0}}, []float64{1, 2}); err == nil {
+		t.Error("too few rows should fail")
+		return
+	}
+	if x, err := SolveLinear([][]float64{{2, 1}, {1, 3}}, []float64{3, 5}); err != nil || !almostEqual(x[0], 0.8, 1e-9) || !almostEqual(x[1], 1.4, 1e-9) {
+		t.Errorf("unexpected solution %v %v", x, err)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h, err := NewHistogram([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 10}, 5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(h.Edges, []float64{0, 2, 4, 6, 8, 10}) || !reflect.DeepEqual(h.Counts, []int{2, 2, 2, 2, 2}) {
+		t.Errorf("unexpected histogram %+v", h)
+		return
+	}
+	if h.Bin(-1) != -1 || h.Bin(10) != 4 || h.Bin(math.NaN()) != -1 {
+		t.Error("unexpected bin")
+	}
+}
+
+func TestOnline(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	values := make([]float64, 10000)
+	var all, a, b OnlineStats
+	oq := NewOnlineQuantile(0.9)
+	var reg OnlineRegression
+	for i := range values {
+		values[i] = rnd.ExpFloat64()
+		all.Add(values[i])
+		if i%3 == 0 {
+			a.Add(values[i])
+		} else {
+			b.Add(values[i])
+		}
+		oq.Add(values[i])
+		reg.Add(float64(i), 3*float64(i)+values[i])
+	}
+	a.Merge(&b)
+	for _, s := range []*OnlineStats{&all, &a} {
+		if s.Count() != len(values) || s.Min() != minOf(values) ||
+			!almostEqual(s.Mean(), Mean(values), 1e-9) ||
+			!almostEqual(s.Variance(1), Variance(values, 1), 1e-9) ||
+			!almostEqual(s.Skewness(), Skewness(values), 1e-9) ||
+			!almostEqual(s.Kurtosis(), Kurtosis(values), 1e-9) {
+			t.Errorf("online stats mismatch: mean %f skewness %f kurtosis %f", s.Mean(), s.Skewness(), s.Kurtosis())
+			return
+		}
+	}
+	if !almostEqual(oq.Value(), Quantile(values, 0.9), 0.05) {
+		t.Errorf("online quantile %f, exact %f", oq.Value(), Quantile(values, 0.9))
+		return
+	}
+	if !almostEqual(reg.Slope(), 3, 0.001) || reg.R2() < 0.99 {
+		t.Errorf("unexpected online regression slope %f r2 %f", reg.Slope(), reg.R2())
+		return
+	}
+	small := NewOnlineQuantile(0.5)
+	small.Add(1, 3, 2)
+	if small.Value() != 2 {
+		t.Errorf("online quantile of few values should be exact, got %f", small.Value())
+	}
+}
+
+func minOf(values []float64) float64 {
+	return Quantile(values, 0)
+}
@@ -1,28 +1,44 @@
 package gset
 
+import "sync"
+
 type HashSet struct {
-	set mapset.Set
+	set map[interface{}]struct{}
+	mu  sync.RWMutex
 }
 
 func NewHashSet() *HashSet {
 	var result HashSet
-	result.set = mapset.NewSet()
+	result.set = map[interface{}]struct{}{}
 	return &result
 }
 
 func (s *HashSet) Add(item interface{}) {
-	s.set.Add(item)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set[item] = struct{}{}
 }
 
 func (s *HashSet) Contains(items ...interface{}) bool {
-	return s.set.Contains(items...) //<-- Notice the 3 dot added after items
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, v := range items {
+		if _, ok := s.set[v]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func (s *HashSet) Remove(item interface{}) {
-	s.set.Remove(item)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.set, item)
 }
 
 // Clear clears all values in the set.
 func (s *HashSet) Clear() {
-	s.set.Clear()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = map[interface{}]struct{}{}
 }
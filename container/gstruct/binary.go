@@ -0,0 +1,888 @@
+package gstruct
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gbit"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/**
+Binary codec of structures driven by `bin` tags, options are separated by comma:
+
+	u8 u16 u32 u64 i8 i16 i32 i64 f32 f64 bool   wire type of number, default is decided by Go type, int and uint are 64 bits
+	be / le                                      byte order of number, length prefix and elements, default is be
+	bits=N                                       bit field of N bits, adjacent bit fields are packed from most significant bit
+	                                             and must end at byte boundary
+	len=u8|u16|u32|u64                           string, []byte or slice is prefixed by its length
+	len=Field                                    length is value of an earlier integer field
+	size=N                                       string or []byte is padded with zeros to N bytes, slice has N elements,
+	                                             trailing zeros of string are trimmed when decoding
+	rest                                         string, []byte or slice takes all remaining bytes, must be the last field
+	optional                                     a byte 0/1 tells whether value follows, field must be a pointer
+	if=Field                                     value exists only if an earlier field is not zero
+	-                                            field is skipped
+
+Arrays have fixed number of elements, nested structures are encoded in place, unexported fields are skipped.
+*/
+
+const binTag = "bin"
+
+type (
+	// FieldError is the error of encoding or decoding a field, Offset is -1 if the error is about tag.
+	FieldError struct {
+		Path   string
+		Offset int
+		Err    error
+	}
+
+	numType struct {
+		name   string
+		size   int
+		signed bool
+		float  bool
+		bool   bool
+	}
+
+	binField struct {
+		index    int
+		name     string
+		typ      reflect.Type
+		num      *numType // Type of number or elements, nil means default.
+		order    binary.ByteOrder
+		bits     int
+		lenNum   *numType
+		lenField int // Index of length field in fields, -1 means none.
+		lenName  string
+		size     int
+		rest     bool
+		optional bool
+		ifField  int // Index of condition field in fields, -1 means none.
+	}
+)
+
+var (
+	numTypes = map[string]*numType{
+		"u8": {"u8", 1, false, false, false}, "u16": {"u16", 2, false, false, false},
+		"u32": {"u32", 4, false, false, false}, "u64": {"u64", 8, false, false, false},
+		"i8": {"i8", 1, true, false, false}, "i16": {"i16", 2, true, false, false},
+		"i32": {"i32", 4, true, false, false}, "i64": {"i64", 8, true, false, false},
+		"f32": {"f32", 4, true, true, false}, "f64": {"f64", 8, true, true, false},
+		"bool": {"bool", 1, false, false, true},
+	}
+
+	binFieldsCache sync.Map // reflect.Type -> []binField
+)
+
+func (e *FieldError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("field %s: %s", e.Path, e.Err)
+	}
+	return fmt.Sprintf("field %s at offset %d: %s", e.Path, e.Offset, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func isByteSeq(t reflect.Type) bool {
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// defaultNum returns wire type of number kind, or nil if k is not a number.
+func defaultNum(k reflect.Kind) *numType {
+	switch k {
+	case reflect.Bool:
+		return numTypes["bool"]
+	case reflect.Int8:
+		return numTypes["i8"]
+	case reflect.Int16:
+		return numTypes["i16"]
+	case reflect.Int32:
+		return numTypes["i32"]
+	case reflect.Int, reflect.Int64:
+		return numTypes["i64"]
+	case reflect.Uint8:
+		return numTypes["u8"]
+	case reflect.Uint16:
+		return numTypes["u16"]
+	case reflect.Uint32:
+		return numTypes["u32"]
+	case reflect.Uint, reflect.Uint64:
+		return numTypes["u64"]
+	case reflect.Float32:
+		return numTypes["f32"]
+	case reflect.Float64:
+		return numTypes["f64"]
+	}
+	return nil
+}
+
+// binFields parses and checks `bin` tags of structure type.
+func binFields(t reflect.Type) ([]binField, error) {
+	return parseBinFields(t, map[reflect.Type]bool{})
+}
+
+// parseBinFields is binFields, visiting holds types being parsed in the call chain, to stop at recursive layouts.
+func parseBinFields(t reflect.Type, visiting map[reflect.Type]bool) ([]binField, error) {
+	if cached, ok := binFieldsCache.Load(t); ok {
+		return cached.([]binField), nil
+	}
+	if visiting[t] {
+		return nil, &FieldError{Path: t.Name(), Offset: -1, Err: gerrors.Errorf("recursive layout of %s", t)}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var fields []binField
+	byName := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup(binTag)
+		if sf.PkgPath != "" || tag == "-" {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return &FieldError{Path: t.Name() + "." + sf.Name, Offset: -1, Err: gerrors.Errorf(format, args...)}
+		}
+
+		f := binField{index: i, name: sf.Name, typ: sf.Type, order: binary.BigEndian, lenField: -1, ifField: -1}
+		if hasTag {
+			for _, opt := range strings.Split(tag, ",") {
+				opt = strings.TrimSpace(opt)
+				key, val := opt, ""
+				if n := strings.IndexByte(opt, '='); n >= 0 {
+					key, val = opt[:n], opt[n+1:]
+				}
+				var err error
+				switch {
+				case numTypes[opt] != nil:
+					f.num = numTypes[opt]
+				case opt == "be":
+					f.order = binary.BigEndian
+				case opt == "le":
+					f.order = binary.LittleEndian
+				case opt == "rest":
+					f.rest = true
+				case opt == "optional":
+					f.optional = true
+				case key == "bits":
+					f.bits, err = strconv.Atoi(val)
+					if err != nil || f.bits < 1 || f.bits > 64 {
+						return nil, fail("invalid bits %s", val)
+					}
+				case key == "size":
+					f.size, err = strconv.Atoi(val)
+					if err != nil || f.size < 1 {
+						return nil, fail("invalid size %s", val)
+					}
+				case key == "len":
+					if n := numTypes[val]; n != nil && !n.signed && !n.bool {
+						f.lenNum = n
+					} else if idx, ok := byName[val]; ok && isInteger(fields[idx].typ.Kind()) {
+						f.lenField, f.lenName = idx, val
+					} else {
+						return nil, fail("len %s is neither unsigned type nor earlier integer field", val)
+					}
+				case key == "if":
+					idx, ok := byName[val]
+					if !ok {
+						return nil, fail("if %s is not an earlier field", val)
+					}
+					f.ifField = idx
+				default:
+					return nil, fail("unknown option %s", opt)
+				}
+			}
+		}
+
+		// Check options against field type.
+		typ := f.typ
+		if f.optional {
+			if typ.Kind() != reflect.Ptr {
+				return nil, fail("optional field must be a pointer")
+			}
+		}
+		if typ.Kind() == reflect.Ptr {
+			if !f.optional && f.ifField < 0 {
+				return nil, fail("pointer field must be optional or conditional")
+			}
+			typ = typ.Elem()
+		}
+		lengthOpts := 0
+		for _, set := range []bool{f.lenNum != nil, f.lenField >= 0, f.size > 0, f.rest} {
+			if set {
+				lengthOpts++
+			}
+		}
+		switch {
+		case f.bits > 0:
+			if !isInteger(typ.Kind()) && typ.Kind() != reflect.Bool {
+				return nil, fail("bit field must be integer or bool")
+			}
+			if f.num != nil || lengthOpts > 0 || f.optional || f.ifField >= 0 || f.typ.Kind() == reflect.Ptr {
+				return nil, fail("bit field can't have other options")
+			}
+		case typ.Kind() == reflect.String || typ.Kind() == reflect.Slice:
+			if lengthOpts != 1 {
+				return nil, fail("%s needs exactly one of len, size and rest", typ.Kind())
+			}
+			if isByteSeq(typ) && f.num != nil {
+				return nil, fail("%s can't have number type", typ)
+			}
+		case lengthOpts > 0:
+			return nil, fail("only string and slice can have len, size or rest")
+		}
+		if f.num != nil {
+			elem := typ
+			if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+				elem = elem.Elem()
+			}
+			if defaultNum(elem.Kind()) == nil {
+				return nil, fail("number type %s on %s", f.num.name, elem)
+			}
+			if f.num.bool != (elem.Kind() == reflect.Bool) || (f.num.float && isInteger(elem.Kind())) {
+				return nil, fail("number type %s doesn't match %s", f.num.name, elem)
+			}
+		}
+		if f.rest && i != lastExported(t) {
+			return nil, fail("rest must be the last field")
+		}
+		// Nested rest field takes all remaining bytes too.
+		if inner := innerStruct(typ); inner != nil && containsRest(inner, map[reflect.Type]bool{}) {
+			if i != lastExported(t) {
+				return nil, fail("%s contains rest field, it must be the last field", inner)
+			}
+			if typ.Kind() == reflect.Array || typ.Kind() == reflect.Slice {
+				return nil, fail("elements of %s contain rest field", typ)
+			}
+		}
+		if f.rest && typ.Kind() == reflect.Slice && !isByteSeq(typ) {
+			// Decoding never ends if elements take no bytes.
+			n, err := minWireSize(typ.Elem(), &f, visiting)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return nil, fail("rest elements of %s may take no bytes", typ.Elem())
+			}
+		}
+
+		byName[sf.Name] = len(fields)
+		fields = append(fields, f)
+	}
+
+	binFieldsCache.Store(t, fields)
+	return fields, nil
+}
+
+// minWireSize returns the least number of bytes value of type t takes, f is the field holding it.
+func minWireSize(t reflect.Type, f *binField, visiting map[reflect.Type]bool) (int, error) {
+	switch t.Kind() {
+	case reflect.Struct:
+		fields, err := parseBinFields(t, visiting)
+		if err != nil {
+			return 0, err
+		}
+		if visiting[t] {
+			return 0, &FieldError{Path: t.Name(), Offset: -1, Err: gerrors.Errorf("recursive layout of %s", t)}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		size, bits := 0, 0
+		for i := range fields {
+			sf := &fields[i]
+			switch {
+			case sf.ifField >= 0 || sf.lenField >= 0 || sf.rest:
+			case sf.optional:
+				size++
+			case sf.bits > 0:
+				bits += sf.bits
+			case sf.lenNum != nil:
+				size += sf.lenNum.size
+			case sf.size > 0 && isByteSeq(sf.typ):
+				size += sf.size
+			case sf.size > 0:
+				n, err := minWireSize(sf.typ.Elem(), sf, visiting)
+				if err != nil {
+					return 0, err
+				}
+				size += sf.size * n
+			default:
+				n, err := minWireSize(sf.typ, sf, visiting)
+				if err != nil {
+					return 0, err
+				}
+				size += n
+			}
+		}
+		return size + bits/8, nil
+	case reflect.Array:
+		n, err := minWireSize(t.Elem(), f, visiting)
+		return t.Len() * n, err
+	}
+	if n := f.numTypeOf(t); n != nil {
+		return n.size, nil
+	}
+	return 0, nil
+}
+
+// innerStruct returns structure type encoded by value of type t, its elements or value it points to, nil if none.
+func innerStruct(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Array || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// containsRest reports whether structure type t has rest field at any depth.
+func containsRest(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get(binTag)
+		if sf.PkgPath != "" || tag == "-" {
+			continue
+		}
+		for _, opt := range strings.Split(tag, ",") {
+			if strings.TrimSpace(opt) == "rest" {
+				return true
+			}
+		}
+		if inner := innerStruct(sf.Type); inner != nil && containsRest(inner, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastExported(t reflect.Type) int {
+	for i := t.NumField() - 1; i >= 0; i-- {
+		if t.Field(i).PkgPath == "" && t.Field(i).Tag.Get(binTag) != "-" {
+			return i
+		}
+	}
+	return -1
+}
+
+// numTypeOf returns wire type of field or its elements.
+func (f *binField) numTypeOf(t reflect.Type) *numType {
+	if f.num != nil {
+		return f.num
+	}
+	return defaultNum(t.Kind())
+}
+
+// cond checks whether conditional field exists.
+func cond(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		return !v.IsNil()
+	}
+	return !v.IsZero()
+}
+
+// intValue returns integer field value.
+func intValue(v reflect.Value) uint64 {
+	if v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 {
+		return uint64(v.Int())
+	}
+	return v.Uint()
+}
+
+// Marshal encodes structure, v must be a structure or pointer to it.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, gerrors.Errorf("Marshal needs a structure, but received %T", v)
+	}
+	e := &binEncoder{}
+	if err := e.encodeStruct(rv, rv.Type().Name()); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type binEncoder struct {
+	buf  []byte
+	bits gbit.Writer
+}
+
+func (e *binEncoder) fail(path string, format string, args ...interface{}) error {
+	return &FieldError{Path: path, Offset: len(e.buf), Err: gerrors.Errorf(format, args...)}
+}
+
+func (e *binEncoder) encodeStruct(v reflect.Value, path string) error {
+	fields, err := binFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := range fields {
+		f := &fields[i]
+		fv := v.Field(f.index)
+		fpath := joinPath(path, f.name)
+
+		if f.bits > 0 {
+			var x uint64
+			if fv.Kind() == reflect.Bool {
+				if fv.Bool() {
+					x = 1
+				}
+			} else {
+				x = intValue(fv)
+				if fv.Kind() >= reflect.Int && fv.Kind() <= reflect.Int64 {
+					// Signed bit field is two's complement.
+					if n := fv.Int(); n < -(1<<uint(f.bits-1)) || (f.bits < 64 && n >= 1<<uint(f.bits-1)) {
+						return e.fail(fpath, "value %d overflows %d bits", n, f.bits)
+					}
+					x &= gbit.Mask(f.bits)
+				} else if x > gbit.Mask(f.bits) {
+					return e.fail(fpath, "value %d overflows %d bits", x, f.bits)
+				}
+			}
+			e.bits.Write(x, f.bits)
+			if i+1 == len(fields) || fields[i+1].bits == 0 {
+				if e.bits.Len()%8 != 0 {
+					return e.fail(fpath, "bit fields end at bit %d, not byte boundary", e.bits.Len())
+				}
+				e.buf = append(e.buf, e.bits.Bytes()...)
+				e.bits.Reset()
+			}
+			continue
+		}
+
+		if f.ifField >= 0 && !cond(v.Field(fields[f.ifField].index)) {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if f.optional {
+				if fv.IsNil() {
+					e.buf = append(e.buf, 0)
+					continue
+				}
+				e.buf = append(e.buf, 1)
+			} else if fv.IsNil() {
+				return e.fail(fpath, "nil pointer but %s is not zero", fields[f.ifField].name)
+			}
+			fv = fv.Elem()
+		}
+		if f.lenField >= 0 {
+			lv := v.Field(fields[f.lenField].index)
+			if n := intValue(lv); n != uint64(fv.Len()) {
+				return e.fail(fpath, "length is %d but %s is %d", fv.Len(), fields[f.lenField].name, n)
+			}
+		}
+		if err := e.encodeValue(fv, f, fpath, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *binEncoder) putNum(n *numType, order binary.ByteOrder, v reflect.Value, path string) error {
+	var x uint64
+	switch {
+	case n.bool:
+		if v.Bool() {
+			x = 1
+		}
+	case n.float:
+		f := v.Float()
+		if n.size == 4 {
+			if !math.IsInf(f, 0) && !math.IsNaN(f) && math.Abs(f) > math.MaxFloat32 {
+				return e.fail(path, "value %v overflows %s", f, n.name)
+			}
+			x = uint64(math.Float32bits(float32(f)))
+		} else {
+			x = math.Float64bits(f)
+		}
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return e.fail(path, "float can't be encoded as %s", n.name)
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		i := v.Int()
+		bits := uint(n.size * 8)
+		if n.signed && bits < 64 && (i < -(1<<(bits-1)) || i >= 1<<(bits-1)) ||
+			!n.signed && (i < 0 || bits < 64 && uint64(i) >= 1<<bits) {
+			return e.fail(path, "value %d overflows %s", i, n.name)
+		}
+		x = uint64(i)
+	default:
+		u := v.Uint()
+		bits := uint(n.size * 8)
+		if n.signed && u >= 1<<(bits-1) || !n.signed && bits < 64 && u >= 1<<bits {
+			return e.fail(path, "value %d overflows %s", u, n.name)
+		}
+		x = u
+	}
+	e.putUint(x, n.size, order)
+	return nil
+}
+
+func (e *binEncoder) putUint(x uint64, size int, order binary.ByteOrder) {
+	var b [8]byte
+	switch size {
+	case 1:
+		b[0] = byte(x)
+	case 2:
+		order.PutUint16(b[:], uint16(x))
+	case 4:
+		order.PutUint32(b[:], uint32(x))
+	case 8:
+		order.PutUint64(b[:], x)
+	}
+	e.buf = append(e.buf, b[:size]...)
+}
+
+// encodeValue encodes field value, top is false for elements of slice or array which have no length options.
+func (e *binEncoder) encodeValue(v reflect.Value, f *binField, path string, top bool) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return e.encodeStruct(v, path)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encodeValue(v.Index(i), f, path+"["+strconv.Itoa(i)+"]", false); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String, reflect.Slice:
+		if !top {
+			return e.fail(path, "nested %s is not supported", v.Kind())
+		}
+		n := v.Len()
+		if f.lenNum != nil {
+			if f.lenNum.size < 8 && uint64(n) > gbit.Mask(f.lenNum.size*8) {
+				return e.fail(path, "length %d overflows %s", n, f.lenNum.name)
+			}
+			e.putUint(uint64(n), f.lenNum.size, f.order)
+		}
+		if f.size > 0 && n > f.size {
+			return e.fail(path, "length %d exceeds size %d", n, f.size)
+		}
+		if isByteSeq(v.Type()) {
+			if v.Kind() == reflect.String {
+				e.buf = append(e.buf, v.String()...)
+			} else {
+				e.buf = append(e.buf, v.Bytes()...)
+			}
+			if f.size > n {
+				e.buf = append(e.buf, make([]byte, f.size-n)...)
+			}
+			return nil
+		}
+		if f.size > 0 && n != f.size {
+			return e.fail(path, "slice has %d elements, but size is %d", n, f.size)
+		}
+		for i := 0; i < n; i++ {
+			if err := e.encodeValue(v.Index(i), f, path+"["+strconv.Itoa(i)+"]", false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	n := f.numTypeOf(v.Type())
+	if n == nil {
+		return e.fail(path, "unsupported type %s", v.Type())
+	}
+	return e.putNum(n, f.order, v, path)
+}
+
+// Unmarshal decodes data into structure pointed by v, all bytes must be consumed.
+func Unmarshal(data []byte, v interface{}) error {
+	n, err := Decode(data, v)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return gerrors.Errorf("%d trailing bytes after offset %d", len(data)-n, n)
+	}
+	return nil
+}
+
+// Decode decodes the beginning of data into structure pointed by v, and returns number of bytes consumed,
+// it is useful for headers followed by payload.
+func Decode(data []byte, v interface{}) (int, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return 0, gerrors.Errorf("Decode needs a pointer to structure, but received %T", v)
+	}
+	d := &binDecoder{data: data}
+	if err := d.decodeStruct(rv.Elem(), rv.Elem().Type().Name()); err != nil {
+		return d.off, err
+	}
+	return d.off, nil
+}
+
+type binDecoder struct {
+	data []byte
+	off  int
+}
+
+func (d *binDecoder) fail(path string, format string, args ...interface{}) error {
+	return &FieldError{Path: path, Offset: d.off, Err: gerrors.Errorf(format, args...)}
+}
+
+func (d *binDecoder) take(n int, path string) ([]byte, error) {
+	if n < 0 || d.off+n > len(d.data) {
+		return nil, d.fail(path, "need %d bytes, but only %d left", n, len(d.data)-d.off)
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *binDecoder) decodeStruct(v reflect.Value, path string) error {
+	fields, err := binFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i++ {
+		f := &fields[i]
+		fpath := joinPath(path, f.name)
+
+		if f.bits > 0 {
+			// Read the whole run of bit fields.
+			j, total := i, 0
+			for ; j < len(fields) && fields[j].bits > 0; j++ {
+				total += fields[j].bits
+			}
+			if total%8 != 0 {
+				return d.fail(joinPath(path, fields[j-1].name), "bit fields end at bit %d, not byte boundary", total)
+			}
+			b, err := d.take(total/8, fpath)
+			if err != nil {
+				return err
+			}
+			r := gbit.NewReader(b)
+			for ; i < j; i++ {
+				bf := &fields[i]
+				x, _ := r.Read(bf.bits)
+				bv := v.Field(bf.index)
+				switch {
+				case bv.Kind() == reflect.Bool:
+					bv.SetBool(x != 0)
+				case bv.Kind() >= reflect.Int && bv.Kind() <= reflect.Int64:
+					// Sign extend.
+					shift := uint(64 - bf.bits)
+					n := int64(x<<shift) >> shift
+					if bv.OverflowInt(n) {
+						return d.fail(joinPath(path, bf.name), "value %d overflows %s", n, bv.Type())
+					}
+					bv.SetInt(n)
+				default:
+					if bv.OverflowUint(x) {
+						return d.fail(joinPath(path, bf.name), "value %d overflows %s", x, bv.Type())
+					}
+					bv.SetUint(x)
+				}
+			}
+			i--
+			continue
+		}
+
+		fv := v.Field(f.index)
+		if f.ifField >= 0 && !cond(v.Field(fields[f.ifField].index)) {
+			fv.Set(reflect.Zero(fv.Type()))
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if f.optional {
+				b, err := d.take(1, fpath)
+				if err != nil {
+					return err
+				}
+				if b[0] > 1 {
+					return d.fail(fpath, "invalid optional flag %d", b[0])
+				}
+				if b[0] == 0 {
+					fv.Set(reflect.Zero(fv.Type()))
+					continue
+				}
+			}
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		length := -1
+		if f.lenField >= 0 {
+			n := intValue(v.Field(fields[f.lenField].index))
+			if n > uint64(len(d.data)) {
+				return d.fail(fpath, "length %d from %s exceeds data", n, fields[f.lenField].name)
+			}
+			length = int(n)
+		}
+		if err := d.decodeValue(fv, f, fpath, length, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *binDecoder) getUint(size int, order binary.ByteOrder, path string) (uint64, error) {
+	b, err := d.take(size, path)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(order.Uint16(b)), nil
+	case 4:
+		return uint64(order.Uint32(b)), nil
+	default:
+		return order.Uint64(b), nil
+	}
+}
+
+func (d *binDecoder) getNum(n *numType, order binary.ByteOrder, v reflect.Value, path string) error {
+	off := d.off
+	x, err := d.getUint(n.size, order, path)
+	if err != nil {
+		return err
+	}
+	overflow := func(val interface{}) error {
+		return &FieldError{Path: path, Offset: off, Err: gerrors.Errorf("value %v overflows %s", val, v.Type())}
+	}
+	switch {
+	case n.bool:
+		if x > 1 {
+			return &FieldError{Path: path, Offset: off, Err: gerrors.Errorf("invalid bool %d", x)}
+		}
+		v.SetBool(x == 1)
+	case n.float:
+		f := math.Float64frombits(x)
+		if n.size == 4 {
+			f = float64(math.Float32frombits(uint32(x)))
+		}
+		v.SetFloat(f)
+	case n.signed:
+		shift := uint(64 - n.size*8)
+		i := int64(x<<shift) >> shift
+		if v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 {
+			if v.OverflowInt(i) {
+				return overflow(i)
+			}
+			v.SetInt(i)
+		} else {
+			if i < 0 || v.OverflowUint(uint64(i)) {
+				return overflow(i)
+			}
+			v.SetUint(uint64(i))
+		}
+	default:
+		if v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 {
+			if x > math.MaxInt64 || v.OverflowInt(int64(x)) {
+				return overflow(x)
+			}
+			v.SetInt(int64(x))
+		} else {
+			if v.OverflowUint(x) {
+				return overflow(x)
+			}
+			v.SetUint(x)
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes field value, length is element count from length field or -1.
+func (d *binDecoder) decodeValue(v reflect.Value, f *binField, path string, length int, top bool) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return d.decodeStruct(v, path)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decodeValue(v.Index(i), f, path+"["+strconv.Itoa(i)+"]", -1, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String, reflect.Slice:
+		if !top {
+			return d.fail(path, "nested %s is not supported", v.Kind())
+		}
+		if f.lenNum != nil {
+			n, err := d.getUint(f.lenNum.size, f.order, path)
+			if err != nil {
+				return err
+			}
+			if n > uint64(len(d.data)) {
+				return d.fail(path, "length %d exceeds data", n)
+			}
+			length = int(n)
+		}
+		if f.size > 0 {
+			length = f.size
+		}
+		if isByteSeq(v.Type()) {
+			if f.rest {
+				length = len(d.data) - d.off
+			}
+			b, err := d.take(length, path)
+			if err != nil {
+				return err
+			}
+			if v.Kind() == reflect.String {
+				if f.size > 0 {
+					// Zero padding is not part of string.
+					b = []byte(strings.TrimRight(string(b), "\x00"))
+				}
+				v.SetString(string(b))
+			} else {
+				v.SetBytes(append([]byte{}, b...))
+			}
+			return nil
+		}
+		if f.rest {
+			s := reflect.MakeSlice(v.Type(), 0, 0)
+			for i := 0; d.off < len(d.data); i++ {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := d.decodeValue(elem, f, path+"["+strconv.Itoa(i)+"]", -1, false); err != nil {
+					return err
+				}
+				s = reflect.Append(s, elem)
+			}
+			v.Set(s)
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), length, length)
+		for i := 0; i < length; i++ {
+			if err := d.decodeValue(s.Index(i), f, path+"["+strconv.Itoa(i)+"]", -1, false); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	n := f.numTypeOf(v.Type())
+	if n == nil {
+		return d.fail(path, "unsupported type %s", v.Type())
+	}
+	return d.getNum(n, f.order, v, path)
+}
@@ -0,0 +1,194 @@
+package gstruct
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type (
+	// LayoutField describes where a field is in binary encoding of a structure.
+	LayoutField struct {
+		Path   string
+		Offset int    // Offset in bytes, -1 if it follows a variable length or optional field.
+		Bit    int    // Bit offset from Offset of bit field, counted from the most significant bit.
+		Bits   int    // Size in bits, -1 if size is variable.
+		Type   string // Wire type like "u16 be", "bits", "string len=u8".
+	}
+
+	Layout []LayoutField
+)
+
+// DescribeLayout describes binary layout of structure v for documentation, v can be a structure or pointer to it.
+func DescribeLayout(v interface{}) (Layout, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, gerrors.Errorf("DescribeLayout needs a structure, but received %T", v)
+	}
+	var l Layout
+	if _, _, err := describeStruct(t, t.Name(), 0, &l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// describeStruct appends fields of t to out if out is not nil, base is offset of t or -1 if unknown,
+// it returns size of t and whether the size is fixed.
+func describeStruct(t reflect.Type, path string, base int, out *Layout) (int, bool, error) {
+	fields, err := binFields(t)
+	if err != nil {
+		return 0, false, err
+	}
+	off, fixed := 0, true
+	abs := func() int {
+		if base < 0 || !fixed {
+			return -1
+		}
+		return base + off
+	}
+	for i := 0; i < len(fields); i++ {
+		f := &fields[i]
+		fpath := joinPath(path, f.name)
+
+		if f.bits > 0 {
+			start, bit := abs(), 0
+			for ; i < len(fields) && fields[i].bits > 0; i++ {
+				if out != nil {
+					*out = append(*out, LayoutField{Path: joinPath(path, fields[i].name), Offset: start, Bit: bit, Bits: fields[i].bits, Type: "bits"})
+				}
+				bit += fields[i].bits
+			}
+			i--
+			off += bit / 8
+			continue
+		}
+
+		typ := f.typ
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if f.optional {
+			if out != nil {
+				*out = append(*out, LayoutField{Path: fpath + "?", Offset: abs(), Bits: 8, Type: "u8 optional flag"})
+			}
+			off++
+			fixed = false
+		}
+		if f.ifField >= 0 {
+			fixed = false
+		}
+		n, ok, err := describeValue(typ, f, fpath, abs(), out)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			off += n
+		} else {
+			fixed = false
+		}
+		if f.ifField >= 0 && out != nil {
+			(*out)[len(*out)-1].Type += " if " + fields[f.ifField].name
+		}
+	}
+	return off, fixed, nil
+}
+
+func bitsOf(size int, fixed bool) int {
+	if !fixed {
+		return -1
+	}
+	return size * 8
+}
+
+func numDesc(n *numType, f *binField) string {
+	switch {
+	case n.size == 1:
+		return n.name
+	case f.order == binary.LittleEndian:
+		return n.name + " le"
+	default:
+		return n.name + " be"
+	}
+}
+
+func describeValue(t reflect.Type, f *binField, path string, base int, out *Layout) (int, bool, error) {
+	add := func(size int, fixed bool, typ string) {
+		if out != nil {
+			*out = append(*out, LayoutField{Path: path, Offset: base, Bits: bitsOf(size, fixed), Type: typ})
+		}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return describeStruct(t, path, base, out)
+	case reflect.Array:
+		size, fixed, err := describeValue(t.Elem(), f, path, -1, nil)
+		if err != nil {
+			return 0, false, err
+		}
+		elem := t.Elem().Name()
+		if n := f.numTypeOf(t.Elem()); n != nil {
+			elem = numDesc(n, f)
+		}
+		add(size*t.Len(), fixed, "["+strconv.Itoa(t.Len())+"]"+elem)
+		return size * t.Len(), fixed, nil
+	case reflect.String, reflect.Slice:
+		var length string
+		switch {
+		case f.lenNum != nil:
+			length = "len=" + numDesc(f.lenNum, f)
+		case f.lenField >= 0:
+			length = "len=" + f.lenName
+		case f.size > 0:
+			length = "size=" + strconv.Itoa(f.size)
+		default:
+			length = "rest"
+		}
+		if isByteSeq(t) {
+			add(f.size, f.size > 0, t.String()+" "+length)
+			return f.size, f.size > 0, nil
+		}
+		size, fixed, err := describeValue(t.Elem(), f, path, -1, nil)
+		if err != nil {
+			return 0, false, err
+		}
+		elem := t.Elem().Name()
+		if n := f.numTypeOf(t.Elem()); n != nil {
+			elem = numDesc(n, f)
+		}
+		fixed = fixed && f.size > 0
+		add(size*f.size, fixed, "[]"+elem+" "+length)
+		return size * f.size, fixed, nil
+	}
+	n := f.numTypeOf(t)
+	if n == nil {
+		return 0, false, &FieldError{Path: path, Offset: -1, Err: gerrors.Errorf("unsupported type %s", t)}
+	}
+	add(n.size, true, numDesc(n, f))
+	return n.size, true, nil
+}
+
+// String formats layout as a table.
+func (l Layout) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-8s %-6s %-30s %s\n", "OFFSET", "BITS", "FIELD", "TYPE")
+	for _, f := range l {
+		off, bits := "?", "var"
+		if f.Offset >= 0 {
+			off = strconv.Itoa(f.Offset)
+			if f.Type == "bits" {
+				off += "." + strconv.Itoa(f.Bit)
+			}
+		}
+		if f.Bits >= 0 {
+			bits = strconv.Itoa(f.Bits)
+		}
+		fmt.Fprintf(&sb, "%-8s %-6s %-30s %s\n", off, bits, f.Path, f.Type)
+	}
+	return sb.String()
+}
@@ -0,0 +1,280 @@
+package gstruct
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type (
+	// Same layout as gmux rawHeader.
+	muxHeader struct {
+		Ver    uint8
+		Cmd    uint8
+		Length uint16 `bin:"le"`
+		Sid    uint32 `bin:"le"`
+	}
+
+	ipHeader struct {
+		Version  uint8 `bin:"bits=4"`
+		IHL      uint8 `bin:"bits=4"`
+		TOS      uint8
+		TotalLen int    `bin:"u16"`
+		DF       bool   `bin:"bits=2"`
+		FragOff  uint16 `bin:"bits=14"`
+		Src      [4]byte
+		OptLen   uint8
+		Options  []byte `bin:"len=OptLen"`
+	}
+
+	extension struct {
+		Kind  uint8
+		Value int16 `bin:"i16,le"`
+	}
+
+	// Recursive layouts.
+	restNode struct {
+		V    uint8
+		Kids []restNode `bin:"rest"`
+	}
+	sizeNode struct {
+		V    uint8
+		Kids []sizeNode `bin:"size=2"`
+	}
+	sizeNodes struct {
+		Nodes []sizeNode `bin:"rest"`
+	}
+	listNode struct {
+		V    uint8
+		Next *listNode `bin:"optional"`
+	}
+
+	// Nested rest field.
+	restInner struct {
+		N    uint8
+		Data []byte `bin:"rest"`
+	}
+	restOuter struct {
+		Body restInner
+		Tail uint8
+	}
+	restOuters struct {
+		Bodies [2]restInner
+	}
+	restLast struct {
+		Kind uint8
+		Body *restInner `bin:"if=Kind"`
+	}
+)
+
+func TestMarshal(t *testing.T) {
+	h := muxHeader{Ver: 1, Cmd: 2, Length: 0x0304, Sid: 0x05060708}
+	b, err := StructPack(&h)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(b, []byte{1, 2, 4, 3, 8, 7, 6, 5}) {
+		t.Errorf("unexpected encoding %x", b)
+		return
+	}
+	var h2 muxHeader
+	n, err := Decode(append(b, "payload"...), &h2)
+	if err != nil || n != 8 || h2 != h {
+		t.Errorf("unexpected decoding %+v %d %v", h2, n, err)
+		return
+	}
+	if err := StructUnpack(append(b, 0), &h2); err == nil {
+		t.Error("trailing bytes should fail")
+		return
+	}
+
+	type noLength struct {
+		Tags []string
+	}
+	if _, err := Marshal(noLength{}); err == nil || !strings.Contains(err.Error(), "noLength.Tags") {
+		t.Errorf("missing length option should fail, got %v", err)
+	}
+}
+
+type packet struct {
+	Header  ipHeader
+	Name    string `bin:"size=8"`
+	HasExt  bool
+	Ext     *extension `bin:"if=HasExt"`
+	Note    *string    `bin:"optional,len=u8"`
+	Samples []float32  `bin:"len=u16,le"`
+	Payload []byte     `bin:"rest"`
+}
+
+func TestMarshal_Packet(t *testing.T) {
+	note := "hi"
+	p := packet{
+		Header: ipHeader{Version: 4, IHL: 5, TotalLen: 1500, DF: true, FragOff: 0x123, Src: [4]byte{10, 0, 0, 1}, OptLen: 2, Options: []byte{7, 8}},
+		Name:   "gopher",
+		HasExt: true, Ext: &extension{Kind: 9, Value: -2},
+		Note:    &note,
+		Samples: []float32{1.5, -2},
+		Payload: []byte("data"),
+	}
+	b, err := Marshal(p)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if b[0] != 0x45 || !bytes.Equal(b[4:6], []byte{0x41, 0x23}) || string(b[13:19]) != "gopher" {
+		t.Errorf("unexpected encoding %x", b)
+		return
+	}
+	var p2 packet
+	if err := Unmarshal(b, &p2); err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(p, p2) {
+		t.Errorf("expect %+v, got %+v", p, p2)
+		return
+	}
+
+	// Absent conditional and optional fields.
+	p.HasExt, p.Ext, p.Note = false, nil, nil
+	if b, err = Marshal(p); err != nil {
+		t.Error(err)
+		return
+	}
+	p2 = packet{}
+	if err := Unmarshal(b, &p2); err != nil || !reflect.DeepEqual(p, p2) {
+		t.Errorf("expect %+v, got %+v %v", p, p2, err)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	var fe *FieldError
+	_, err := Marshal(ipHeader{Version: 16})
+	if !errors.As(err, &fe) || fe.Path != "ipHeader.Version" || fe.Offset != 0 {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	_, err = Marshal(ipHeader{TotalLen: 70000})
+	if !errors.As(err, &fe) || fe.Path != "ipHeader.TotalLen" || fe.Offset != 2 {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	_, err = Marshal(ipHeader{OptLen: 3, Options: []byte{1}})
+	if !errors.As(err, &fe) || fe.Path != "ipHeader.Options" {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+
+	b, _ := Marshal(packet{Header: ipHeader{OptLen: 1, Options: []byte{1}}, Samples: []float32{1}})
+	var p packet
+	err = Unmarshal(b[:len(b)-2], &p)
+	if !errors.As(err, &fe) || fe.Path != "packet.Samples[0]" || fe.Offset != len(b)-4 {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+
+	type badBits struct {
+		A uint8 `bin:"bits=3"`
+		B uint8
+	}
+	if _, err := Marshal(badBits{}); err == nil || !strings.Contains(err.Error(), "byte boundary") {
+		t.Errorf("unaligned bit fields should fail, got %v", err)
+		return
+	}
+	type badTag struct {
+		Data []byte `bin:"len=Missing"`
+	}
+	if _, err := Marshal(badTag{}); !errors.As(err, &fe) || fe.Offset != -1 || fe.Path != "badTag.Data" {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	type emptyRest struct {
+		N     uint8
+		Items []struct {
+			Skipped int `bin:"-"`
+			Empty   [0]uint32
+			Cond    *uint8 `bin:"if=Empty"`
+		} `bin:"rest"`
+	}
+	if err := Unmarshal([]byte{1, 2}, &emptyRest{}); !errors.As(err, &fe) || fe.Offset != -1 || fe.Path != "emptyRest.Items" {
+		t.Errorf("rest elements taking no bytes should fail, got %v", err)
+	}
+}
+
+func TestDescribeLayout(t *testing.T) {
+	l, err := DescribeLayout(packet{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expect := []LayoutField{
+		{"packet.Header.Version", 0, 0, 4, "bits"},
+		{"packet.Header.IHL", 0, 4, 4, "bits"},
+		{"packet.Header.TOS", 1, 0, 8, "u8"},
+		{"packet.Header.TotalLen", 2, 0, 16, "u16 be"},
+		{"packet.Header.DF", 4, 0, 2, "bits"},
+		{"packet.Header.FragOff", 4, 2, 14, "bits"},
+		{"packet.Header.Src", 6, 0, 32, "[4]u8"},
+		{"packet.Header.OptLen", 10, 0, 8, "u8"},
+		{"packet.Header.Options", 11, 0, -1, "[]uint8 len=OptLen"},
+		{"packet.Name", -1, 0, 64, "string size=8"},
+		{"packet.HasExt", -1, 0, 8, "bool"},
+		{"packet.Ext.Kind", -1, 0, 8, "u8"},
+		{"packet.Ext.Value", -1, 0, 16, "i16 le if HasExt"},
+		{"packet.Note?", -1, 0, 8, "u8 optional flag"},
+		{"packet.Note", -1, 0, -1, "string len=u8"},
+		{"packet.Samples", -1, 0, -1, "[]f32 le len=u16 le"},
+		{"packet.Payload", -1, 0, -1, "[]uint8 rest"},
+	}
+	if !reflect.DeepEqual([]LayoutField(l), expect) {
+		t.Errorf("unexpected layout\n%s", l)
+		return
+	}
+	if s := l.String(); !strings.Contains(s, "4.2      14     packet.Header.FragOff") {
+		t.Errorf("unexpected layout string\n%s", s)
+	}
+}
+
+func TestMarshal_Recursive(t *testing.T) {
+	if err := Unmarshal([]byte{1, 2, 3}, &restNode{}); err == nil {
+		t.Error("recursive layout with rest should fail")
+		return
+	}
+	if err := Unmarshal([]byte{1, 2, 3}, &sizeNodes{}); err == nil || !strings.Contains(err.Error(), "recursive layout") {
+		t.Errorf("recursive layout should fail, got %v", err)
+		return
+	}
+	// Recursion through optional pointer ends with data.
+	list := listNode{V: 1, Next: &listNode{V: 2}}
+	b, err := Marshal(list)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var got listNode
+	if err := Unmarshal(b, &got); err != nil || !bytes.Equal(b, []byte{1, 1, 2, 0}) || !reflect.DeepEqual(got, list) {
+		t.Errorf("unexpected list %v %+v %v", b, got, err)
+	}
+}
+
+func TestMarshal_NestedRest(t *testing.T) {
+	for _, v := range []interface{}{restOuter{}, restOuters{}} {
+		if _, err := Marshal(v); err == nil || !strings.Contains(err.Error(), "rest field") {
+			t.Errorf("nested rest field of %T should fail, got %v", v, err)
+			return
+		}
+	}
+	v := restLast{Kind: 1, Body: &restInner{N: 2, Data: []byte{9, 8}}}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var got restLast
+	if err := Unmarshal(b, &got); err != nil || !reflect.DeepEqual(got, v) {
+		t.Errorf("unexpected %+v %v", got, err)
+	}
+}
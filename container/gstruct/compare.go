@@ -1,6 +1,20 @@
 package gstruct
 
-import "github.com/r3labs/diff"
+import (
+	"github.com/r3labs/diff"
+	"strings"
+)
+
+type (
+	// Change is a difference between two structures, Path is dot separated field names,
+	// slice indexes and map keys, for example "DB.Hosts.0".
+	Change struct {
+		Type string // create, update or delete
+		Path string
+		From interface{}
+		To   interface{}
+	}
+)
 
 // compare map / structures ...
 func Equal(a, b interface{}) (bool, error) {
@@ -10,3 +24,16 @@ func Equal(a, b interface{}) (bool, error) {
 	}
 	return len(([]diff.Change)(changelog)) == 0, nil
 }
+
+// Diff lists all differences from a to b.
+func Diff(a, b interface{}) ([]Change, error) {
+	changelog, err := diff.Diff(a, b)
+	if err != nil {
+		return nil, err
+	}
+	var res []Change
+	for _, v := range changelog {
+		res = append(res, Change{Type: v.Type, Path: strings.Join(v.Path, "."), From: v.From, To: v.To})
+	}
+	return res, nil
+}
@@ -1,19 +1,69 @@
 package gstruct
 
 import (
+	"encoding"
 	"github.com/cryptowilliam/goutil/basic/gerrors"
 	"reflect"
 )
 
 type (
 	IterateFn = func(v reflect.Value) (newVal reflect.Value, modified bool, err error)
+
+	// FieldInfo describes the member visited by IterateEx.
+	FieldInfo struct {
+		// Dot separated field names from root structure, for example "DB.Host".
+		Path  string
+		Field reflect.StructField
+	}
+
+	IterateExFn = func(fi FieldInfo, v reflect.Value) (newVal reflect.Value, modified bool, err error)
 )
 
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func isBasicKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	}
+	return false
+}
+
 // Iterate over the basic type members of a structure with a specific flag.
 // Reference:
 // https://github.com/IQ-tech/go-crypto-layer/blob/master/datacrypto/aesecb.go#L42
 // EncryptStruct crawls all annotated struct properties and encrypts them in place
 func Iterate(toModify interface{}, tagKey, tagVal string, iterFn IterateFn) error {
+	return IterateEx(toModify, func(fi FieldInfo, v reflect.Value) (reflect.Value, bool, error) {
+		hasTagVal, hasTagKey := fi.Field.Tag.Lookup(tagKey)
+		if !hasTagKey || hasTagVal != tagVal {
+			return v, false, nil
+		}
+		kind := v.Kind()
+		if kind == reflect.Ptr {
+			kind = v.Elem().Kind()
+		}
+		if kind == reflect.Struct {
+			// Text unmarshaler structures like time.Time have no member to iterate.
+			return v, false, nil
+		}
+		if !isBasicKind(kind) {
+			return v, false, gerrors.New("Field must be a basic types or a pointer to a them")
+		}
+		return iterFn(v)
+	})
+}
+
+// IterateEx visits all leaf members of a structure with their paths, nested structures are walked recursively.
+// Leaf members are basic types, non-nil pointers to them, structures implement encoding.TextUnmarshaler,
+// and other types like slice and map. Nil pointers and unexported members are skipped.
+func IterateEx(toModify interface{}, iterFn IterateExFn) error {
+	return iterateEx(toModify, "", iterFn)
+}
+
+func iterateEx(toModify interface{}, pathPrefix string, iterFn IterateExFn) error {
 	if toModify == nil || reflect.TypeOf(toModify) == nil {
 		return nil
 	}
@@ -21,71 +71,58 @@ func Iterate(toModify interface{}, tagKey, tagVal string, iterFn IterateFn) erro
 	if cipherVType.Kind() != reflect.Ptr {
 		return gerrors.New("must receive a pointer, but received " + cipherVType.Kind().String())
 	}
-
 	cipherVType = reflect.TypeOf(toModify).Elem()
 	if cipherVType.Kind() != reflect.Struct {
 		return gerrors.New("must receive a pointer to a struct, but received " + cipherVType.Kind().String())
 	}
-
 	instanceValue := reflect.ValueOf(toModify).Elem()
-
 	for i := 0; i < cipherVType.NumField(); i++ {
-		currentFieldTag := cipherVType.Field(i).Tag
-		hasTagVal, hasTagKey := currentFieldTag.Lookup(tagKey)
+		fi := FieldInfo{Path: pathPrefix + cipherVType.Field(i).Name, Field: cipherVType.Field(i)}
 		field := instanceValue.Field(i)
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
 
-		if field.IsValid() && field.CanSet() {
-			switch field.Kind() {
-			case reflect.Ptr:
-				if !field.IsNil() && field.Elem().IsValid() {
-					switch field.Elem().Kind() {
-					case reflect.Struct:
-						err := Iterate(field.Interface(), tagKey, tagVal, iterFn)
-						if err != nil {
-							return err
-						}
-					case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-						reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
-						if hasTagKey && hasTagVal == tagVal {
-							modifiedV, modified, err := iterFn(field)
-							if err != nil {
-								return err
-							}
-							if modified {
-								field.Set(modifiedV)
-							}
-						}
-					default:
-						if hasTagKey && hasTagVal == tagVal {
-							return gerrors.New("Field must be a basic types or a pointer to a them")
-						}
-					}
-				}
-			case reflect.Struct:
-				err := Iterate(field.Addr().Interface(), tagKey, tagVal, iterFn)
-				if err != nil {
+		visit := func() error {
+			modifiedV, modified, err := iterFn(fi, field)
+			if err != nil {
+				return err
+			}
+			if modified {
+				field.Set(modifiedV)
+			}
+			return nil
+		}
+
+		switch field.Kind() {
+		case reflect.Ptr:
+			if field.IsNil() || !field.Elem().IsValid() {
+				continue
+			}
+			if field.Elem().Kind() == reflect.Struct && !field.Type().Implements(textUnmarshalerType) {
+				if err := iterateEx(field.Interface(), fi.Path+".", iterFn); err != nil {
 					return err
 				}
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-				reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
-				if hasTagKey && hasTagVal == tagVal {
-					modifiedV, modified, err := iterFn(field)
-					if err != nil {
-						return err
-					}
-					if modified {
-						field.Set(modifiedV)
-					}
-				}
-			default:
-				if hasTagKey && hasTagVal == tagVal {
-					return gerrors.New("Field must be a basic types or a pointer to a them")
+				continue
+			}
+			if err := visit(); err != nil {
+				return err
+			}
+		case reflect.Struct:
+			if field.Addr().Type().Implements(textUnmarshalerType) {
+				if err := visit(); err != nil {
+					return err
 				}
+				continue
+			}
+			if err := iterateEx(field.Addr().Interface(), fi.Path+".", iterFn); err != nil {
+				return err
+			}
+		default:
+			if err := visit(); err != nil {
+				return err
 			}
 		}
 	}
-
 	return nil
 }
@@ -41,3 +41,34 @@ func TestIterate(t *testing.T) {
 	gtest.Assert(t, err)
 	fmt.Println(s)
 }
+
+func TestIterateEx(t *testing.T) {
+	type (
+		Inner struct {
+			Host string
+			Port *int
+		}
+		Outer struct {
+			Name  string
+			DB    Inner
+			Cache *Inner
+			Tags  []string
+			inner int
+		}
+	)
+	port := 1
+	s := Outer{Cache: &Inner{Port: &port}}
+	var paths []string
+	err := IterateEx(&s, func(fi FieldInfo, v reflect.Value) (newVal reflect.Value, modified bool, err error) {
+		paths = append(paths, fi.Path)
+		if fi.Path == "DB.Host" {
+			return reflect.ValueOf("localhost"), true, nil
+		}
+		return v, false, nil
+	})
+	gtest.Assert(t, err)
+	expect := "[Name DB.Host Cache.Host Cache.Port Tags]"
+	if fmt.Sprint(paths) != expect || s.DB.Host != "localhost" {
+		t.Errorf("expect paths %s, got %v, DB.Host %s", expect, paths, s.DB.Host)
+	}
+}
@@ -4,24 +4,12 @@ package gstruct
 // https://github.com/bitgoin/packer
 // https://github.com/zhuangsirui/binpacker
 
-// https://github.com/dxhbiz/codec/issues/1#issuecomment-331349108
-
-import (
-	"github.com/dxhbiz/codec"
-)
-
-func StructPack(v interface{}, p []byte) error {
-	p, err := codec.Encode(v)
-	if err != nil {
-		return err
-	}
-	return nil
+// StructPack encodes structure by `bin` tags, see Marshal.
+func StructPack(v interface{}) ([]byte, error) {
+	return Marshal(v)
 }
 
+// StructUnpack decodes p into structure pointed by v by `bin` tags, see Unmarshal.
 func StructUnpack(p []byte, v interface{}) error {
-	err := codec.Decode(p, &v)
-	if err != nil {
-		return err
-	}
-	return nil
+	return Unmarshal(p, v)
 }
@@ -0,0 +1,55 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/container/gnum"
+	"math"
+)
+
+// Anomaly is a value which deviates from others, Score is its (modified) z-score.
+type Anomaly struct {
+	Index int
+	Value float64
+	Score float64
+}
+
+// ZScoreAnomalies compares every value with mean and standard deviation of the previous window values,
+// and returns values whose absolute z-score is greater than threshold, threshold is usually 3.
+// It detects spikes in streaming data, because it doesn't look at future values.
+func ZScoreAnomalies(values []float64, window int, threshold float64) []Anomaly {
+	var res []Anomaly
+	if window < 2 {
+		return res
+	}
+	for i := window; i < len(values); i++ {
+		w := values[i-window : i]
+		std := gnum.Std(w, 1)
+		if std == 0 || math.IsNaN(std) {
+			continue
+		}
+		if score := (values[i] - gnum.Mean(w)) / std; math.Abs(score) > threshold {
+			res = append(res, Anomaly{Index: i, Value: values[i], Score: score})
+		}
+	}
+	return res
+}
+
+// MADAnomalies returns values whose modified z-score 0.6745*(x-median)/MAD is greater than threshold,
+// threshold is usually 3.5. It is robust because median and MAD are not affected by outliers.
+func MADAnomalies(values []float64, threshold float64) []Anomaly {
+	var res []Anomaly
+	med := gnum.Median(values)
+	dev := make([]float64, len(values))
+	for i, v := range values {
+		dev[i] = math.Abs(v - med)
+	}
+	mad := gnum.Median(dev)
+	if mad == 0 || math.IsNaN(mad) {
+		return res
+	}
+	for i, v := range values {
+		if score := 0.6745 * (v - med) / mad; math.Abs(score) > threshold {
+			res = append(res, Anomaly{Index: i, Value: v, Score: score})
+		}
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/container/gnum"
+	"math"
+)
+
+// Indicators take values like Series.Values(FieldClose), and return results aligned with values,
+// results before enough values are available are NaN.
+
+// SMA returns simple moving average.
+func SMA(values []float64, n int) []float64 {
+	res := nanSlice(len(values))
+	if n <= 0 {
+		return res
+	}
+	sum := 0.
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			res[i] = sum / float64(n)
+		}
+	}
+	return res
+}
+
+// EMA returns exponential moving average with smoothing factor 2/(n+1), it is seeded by SMA of the first n values.
+// Leading NaNs of values are skipped, so EMA of another indicator works.
+func EMA(values []float64, n int) []float64 {
+	return ema(values, n, 2/float64(n+1))
+}
+
+func ema(values []float64, n int, alpha float64) []float64 {
+	res := nanSlice(len(values))
+	if n <= 0 {
+		return res
+	}
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if start+n > len(values) {
+		return res
+	}
+	prev := gnum.Mean(values[start : start+n])
+	res[start+n-1] = prev
+	for i := start + n; i < len(values); i++ {
+		prev = alpha*values[i] + (1-alpha)*prev
+		res[i] = prev
+	}
+	return res
+}
+
+// RSI returns relative strength index with Wilder's smoothing, n is usually 14.
+func RSI(values []float64, n int) []float64 {
+	res := nanSlice(len(values))
+	if n <= 0 || len(values) <= n {
+		return res
+	}
+	gain, loss := 0., 0.
+	for i := 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		g, l := math.Max(change, 0), math.Max(-change, 0)
+		if i <= n {
+			gain += g / float64(n)
+			loss += l / float64(n)
+			if i < n {
+				continue
+			}
+		} else {
+			gain = (gain*float64(n-1) + g) / float64(n)
+			loss = (loss*float64(n-1) + l) / float64(n)
+		}
+		if loss == 0 {
+			res[i] = 100
+		} else {
+			res[i] = 100 - 100/(1+gain/loss)
+		}
+	}
+	return res
+}
+
+// MACD returns MACD line (EMA(fast) - EMA(slow)), signal line (EMA(signal) of MACD line) and histogram,
+// fast, slow and signal are usually 12, 26 and 9.
+func MACD(values []float64, fast, slow, signal int) (macd, sig, hist []float64) {
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+	macd = make([]float64, len(values))
+	for i := range values {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	sig = EMA(macd, signal)
+	hist = make([]float64, len(values))
+	for i := range values {
+		hist[i] = macd[i] - sig[i]
+	}
+	return macd, sig, hist
+}
+
+// Bollinger returns Bollinger Bands, middle band is SMA(n), upper and lower bands are k population
+// standard deviations away from it, n and k are usually 20 and 2.
+func Bollinger(values []float64, n int, k float64) (middle, upper, lower []float64) {
+	middle = SMA(values, n)
+	std := RollingStd(values, n, 0)
+	upper, lower = make([]float64, len(values)), make([]float64, len(values))
+	for i := range values {
+		upper[i] = middle[i] + k*std[i]
+		lower[i] = middle[i] - k*std[i]
+	}
+	return middle, upper, lower
+}
@@ -0,0 +1,84 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"time"
+)
+
+type FillMethod int
+
+const (
+	// FillPrevious fills gap with flat candles at previous close price.
+	FillPrevious FillMethod = iota
+	// FillLinear fills gap with flat candles whose price is interpolated between previous close and next open.
+	FillLinear
+)
+
+// Resample aggregates candles into a coarser interval, like 1m candles into 1h candles.
+// interval must be a multiple of current interval, and the last candle may be incomplete.
+func (s *Series) Resample(interval time.Duration) (*Series, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	if interval < s.interval || interval%s.interval != 0 {
+		return nil, gerrors.Errorf("can't resample interval %s to %s", s.interval, interval)
+	}
+	res := &Series{interval: interval}
+	for _, c := range s.candles {
+		t := res.Align(c.Time)
+		if n := len(res.candles); n > 0 && res.candles[n-1].Time.Equal(t) {
+			last := &res.candles[n-1]
+			last.High = gdecimal.Max(last.High, c.High)
+			last.Low = gdecimal.Min(last.Low, c.Low)
+			last.Close = c.Close
+			last.Volume = last.Volume.Add(c.Volume)
+			continue
+		}
+		c.Time = t
+		res.candles = append(res.candles, c)
+	}
+	return res, nil
+}
+
+// FillGaps returns a new series which has a candle at every interval between the first and the last candle,
+// the volume of filled candles is zero.
+func (s *Series) FillGaps(method FillMethod) *Series {
+	res := &Series{interval: s.interval}
+	for i, c := range s.candles {
+		if i > 0 {
+			prev := s.candles[i-1]
+			steps := 0
+			for t := prev.Time.Add(s.interval); t.Before(c.Time); t = t.Add(s.interval) {
+				steps++
+			}
+			for k := 1; k <= steps; k++ {
+				price := prev.Close
+				if method == FillLinear {
+					price = price.Add(c.Open.Sub(prev.Close).MulInt(k).DivInt(steps + 1))
+				}
+				res.candles = append(res.candles, Candle{
+					Time:   prev.Time.Add(time.Duration(k) * s.interval),
+					Open:   price,
+					High:   price,
+					Low:    price,
+					Close:  price,
+					Volume: gdecimal.Zero,
+				})
+			}
+		}
+		res.candles = append(res.candles, c)
+	}
+	return res
+}
+
+// Gaps returns start times of missing candles.
+func (s *Series) Gaps() []time.Time {
+	var res []time.Time
+	for i := 1; i < len(s.candles); i++ {
+		for t := s.candles[i-1].Time.Add(s.interval); t.Before(s.candles[i].Time); t = t.Add(s.interval) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/container/gnum"
+	"math"
+)
+
+// Rolling calls fn with every window of values which ends at index i, and returns results aligned with values.
+// The first window-1 results are NaN.
+func Rolling(values []float64, window int, fn func(w []float64) float64) []float64 {
+	res := nanSlice(len(values))
+	if window <= 0 {
+		return res
+	}
+	for i := window - 1; i < len(values); i++ {
+		res[i] = fn(values[i-window+1 : i+1])
+	}
+	return res
+}
+
+// RollingMean returns moving average of values, it is the same as SMA.
+func RollingMean(values []float64, window int) []float64 {
+	return SMA(values, window)
+}
+
+// RollingStd returns moving standard deviation of values, ddof is the same as gnum.Std.
+func RollingStd(values []float64, window, ddof int) []float64 {
+	return Rolling(values, window, func(w []float64) float64 { return gnum.Std(w, ddof) })
+}
+
+func RollingMin(values []float64, window int) []float64 {
+	return Rolling(values, window, func(w []float64) float64 {
+		res := math.Inf(1)
+		for _, v := range w {
+			res = math.Min(res, v)
+		}
+		return res
+	})
+}
+
+func RollingMax(values []float64, window int) []float64 {
+	return Rolling(values, window, func(w []float64) float64 {
+		res := math.Inf(-1)
+		for _, v := range w {
+			res = math.Max(res, v)
+		}
+		return res
+	})
+}
+
+func nanSlice(n int) []float64 {
+	res := make([]float64, n)
+	for i := range res {
+		res[i] = math.NaN()
+	}
+	return res
+}
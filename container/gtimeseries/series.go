@@ -0,0 +1,177 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"github.com/cryptowilliam/goutil/sys/gtime"
+	"sort"
+	"time"
+)
+
+type (
+	// Candle is an OHLCV bar which starts at Time.
+	Candle struct {
+		Time   time.Time
+		Open   gdecimal.Decimal
+		High   gdecimal.Decimal
+		Low    gdecimal.Decimal
+		Close  gdecimal.Decimal
+		Volume gdecimal.Decimal
+	}
+
+	Field int
+
+	// Series is an in-memory TimeSeries with fixed interval, candles are sorted by time
+	// and every candle time is aligned to interval. Series is not concurrency-safe.
+	Series struct {
+		interval time.Duration
+		candles  []Candle
+	}
+)
+
+const (
+	FieldOpen Field = iota
+	FieldHigh
+	FieldLow
+	FieldClose
+	FieldVolume
+)
+
+var _ TimeSeries = &Series{}
+
+func (c Candle) Get(f Field) gdecimal.Decimal {
+	switch f {
+	case FieldOpen:
+		return c.Open
+	case FieldHigh:
+		return c.High
+	case FieldLow:
+		return c.Low
+	case FieldClose:
+		return c.Close
+	default:
+		return c.Volume
+	}
+}
+
+// checkInterval makes sure interval can be aligned by gtime.RoundEarlier into even buckets,
+// it must be whole seconds, minutes or hours dividing its parent unit, like 15m or 4h, or exactly 1d.
+// 90m mixes units, 7m leaves a short bucket at the end of each hour, and 7d buckets restart every month.
+func checkInterval(interval time.Duration) error {
+	day := 24 * time.Hour
+	if interval > day {
+		return gerrors.Errorf("interval %s longer than 1 day is not supported", interval)
+	}
+	for _, u := range []struct{ unit, parent time.Duration }{{day, day}, {time.Hour, day}, {time.Minute, time.Hour}, {time.Second, time.Minute}} {
+		if interval >= u.unit {
+			if interval%u.unit != 0 {
+				return gerrors.Errorf("interval %s mixes multiple units", interval)
+			}
+			if u.parent%interval != 0 {
+				return gerrors.Errorf("interval %s doesn't divide %s evenly", interval, u.parent)
+			}
+			return nil
+		}
+	}
+	return gerrors.Errorf("invalid interval %s", interval)
+}
+
+func NewSeries(interval time.Duration) (*Series, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	return &Series{interval: interval}, nil
+}
+
+// Align returns start time of the interval which t belongs to.
+func (s *Series) Align(t time.Time) time.Time {
+	return gtime.RoundEarlier(t, s.interval)
+}
+
+// Append appends candles, candle time must be aligned to interval and later than the last candle.
+func (s *Series) Append(candles ...Candle) error {
+	for _, c := range candles {
+		if !s.Align(c.Time).Equal(c.Time) {
+			return gerrors.Errorf("candle time %s is not aligned to interval %s", c.Time, s.interval)
+		}
+		if len(s.candles) > 0 && !c.Time.After(s.candles[len(s.candles)-1].Time) {
+			return gerrors.Errorf("candle time %s is not later than the last candle", c.Time)
+		}
+		s.candles = append(s.candles, c)
+	}
+	return nil
+}
+
+func (s *Series) Interval() time.Duration {
+	return s.interval
+}
+
+func (s *Series) Len() int {
+	return len(s.candles)
+}
+
+func (s *Series) Candle(i int) Candle {
+	return s.candles[i]
+}
+
+// Candles returns a copy of all candles.
+func (s *Series) Candles() []Candle {
+	return append([]Candle{}, s.candles...)
+}
+
+func (s *Series) Time(i int) time.Time {
+	return s.candles[i].Time
+}
+
+func (s *Series) Open(i int) float64 {
+	return s.candles[i].Open.Float64()
+}
+
+func (s *Series) High(i int) float64 {
+	return s.candles[i].High.Float64()
+}
+
+func (s *Series) Low(i int) float64 {
+	return s.candles[i].Low.Float64()
+}
+
+func (s *Series) Close(i int) float64 {
+	return s.candles[i].Close.Float64()
+}
+
+func (s *Series) Volume(i int) float64 {
+	return s.candles[i].Volume.Float64()
+}
+
+// Values returns field of all candles as float64, it is the input of indicators.
+func (s *Series) Values(f Field) []float64 {
+	res := make([]float64, len(s.candles))
+	for i, c := range s.candles {
+		res[i] = c.Get(f).Float64()
+	}
+	return res
+}
+
+// Index returns index of candle at time t, or -1 if not found.
+func (s *Series) Index(t time.Time) int {
+	i := s.search(t)
+	if i < len(s.candles) && s.candles[i].Time.Equal(t) {
+		return i
+	}
+	return -1
+}
+
+// search returns index of the first candle not earlier than t.
+func (s *Series) search(t time.Time) int {
+	return sort.Search(len(s.candles), func(i int) bool { return !s.candles[i].Time.Before(t) })
+}
+
+// Between returns a new series with candles in [from, to).
+func (s *Series) Between(from, to time.Time) *Series {
+	res := &Series{interval: s.interval}
+	if !to.After(from) {
+		return res
+	}
+	res.candles = append(res.candles, s.candles[s.search(from):s.search(to)]...)
+	return res
+}
@@ -1,6 +1,126 @@
 package gtimeseries
 
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gnum"
+	"math"
+)
+
 /*
 Strict stationarity
 Weak stationarity
+
+A series is (weakly) stationary if its mean, variance and autocovariance don't change over time.
+Prices are usually not stationary, but returns usually are.
 */
+
+type (
+	// ADFResult is the result of Augmented Dickey-Fuller test.
+	// The null hypothesis is that series has a unit root, which means it is not stationary.
+	ADFResult struct {
+		Statistic float64
+		Lags      int
+		NObs      int
+		// Critical values of 1%, 5% and 10% significance levels.
+		Critical1  float64
+		Critical5  float64
+		Critical10 float64
+	}
+)
+
+// ADF runs Augmented Dickey-Fuller test with a constant term:
+// Δy(t) = α + β*y(t-1) + Σγ(i)*Δy(t-i) + ε, i = 1..lags.
+// If lags < 0, lags is 12*(n/100)^(1/4) by Schwert's rule.
+func ADF(values []float64, lags int) (*ADFResult, error) {
+	n := len(values)
+	if lags < 0 {
+		lags = int(12 * math.Pow(float64(n)/100, 0.25))
+	}
+	cols := 2 + lags
+	nobs := n - 1 - lags
+	if nobs <= cols+1 {
+		return nil, gerrors.Errorf("too few values %d for ADF test with %d lags", n, lags)
+	}
+
+	dy := make([]float64, n-1)
+	for i := range dy {
+		dy[i] = values[i+1] - values[i]
+	}
+	x := make([][]float64, 0, nobs)
+	y := make([]float64, 0, nobs)
+	for t := lags; t < len(dy); t++ {
+		row := make([]float64, cols)
+		row[0] = 1
+		row[1] = values[t]
+		for i := 1; i <= lags; i++ {
+			row[1+i] = dy[t-i]
+		}
+		x = append(x, row)
+		y = append(y, dy[t])
+	}
+
+	beta, inv11, err := ols(x, y, 1)
+	if err != nil {
+		return nil, err
+	}
+	ssr := 0.
+	for i, row := range x {
+		e := y[i]
+		for j, v := range row {
+			e -= beta[j] * v
+		}
+		ssr += e * e
+	}
+	se := math.Sqrt(ssr / float64(nobs-cols) * inv11)
+
+	// MacKinnon (2010) response surface for the constant only case.
+	fn := float64(nobs)
+	return &ADFResult{
+		Statistic:  beta[1] / se,
+		Lags:       lags,
+		NObs:       nobs,
+		Critical1:  -3.43035 - 6.5393/fn - 16.786/(fn*fn) - 79.433/(fn*fn*fn),
+		Critical5:  -2.86154 - 2.8903/fn - 4.234/(fn*fn) - 40.040/(fn*fn*fn),
+		Critical10: -2.56677 - 1.5384/fn - 2.809/(fn*fn),
+	}, nil
+}
+
+// Stationary reports whether unit root is rejected at significance level 0.01, 0.05 or 0.1.
+func (r *ADFResult) Stationary(significance float64) bool {
+	switch {
+	case significance <= 0.01:
+		return r.Statistic < r.Critical1
+	case significance <= 0.05:
+		return r.Statistic < r.Critical5
+	default:
+		return r.Statistic < r.Critical10
+	}
+}
+
+// ols solves least squares x*beta = y, and returns beta and the i-th diagonal element of inverse of x'x.
+func ols(x [][]float64, y []float64, i int) ([]float64, float64, error) {
+	k := len(x[0])
+	xtx := make([][]float64, k)
+	xty := make([]float64, k)
+	for r := range xtx {
+		xtx[r] = make([]float64, k)
+		for n, row := range x {
+			for c := 0; c < k; c++ {
+				xtx[r][c] += row[r] * row[c]
+			}
+			xty[r] += row[r] * y[n]
+		}
+	}
+	beta, err := gnum.SolveLinear(xtx, xty)
+	if err != nil {
+		return nil, 0, gerrors.Wrap(err, "least squares, values may be constant")
+	}
+	// The i-th column of inverse solves x'x*col = e_i.
+	e := make([]float64, k)
+	e[i] = 1
+	col, err := gnum.SolveLinear(xtx, e)
+	if err != nil {
+		return nil, 0, gerrors.Wrap(err, "least squares, values may be constant")
+	}
+	return beta, col[i], nil
+}
@@ -0,0 +1,191 @@
+package gtimeseries
+
+import (
+	"github.com/cryptowilliam/goutil/container/gdecimal"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func testSeries(t *testing.T, interval time.Duration, start time.Time, closes []int) *Series {
+	s, err := NewSeries(interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range closes {
+		if c < 0 {
+			continue // gap
+		}
+		p := gdecimal.NewFromInt(c)
+		err := s.Append(Candle{Time: start.Add(time.Duration(i) * interval), Open: p, High: p.AddInt(1), Low: p.SubInt(1), Close: p, Volume: gdecimal.One})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestSeries_Resample(t *testing.T) {
+	start := time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC)
+	closes := make([]int, 120)
+	for i := range closes {
+		closes[i] = i
+	}
+	s := testSeries(t, time.Minute, start, closes)
+	if err := s.Append(Candle{Time: start.Add(30 * time.Second)}); err == nil {
+		t.Error("unaligned candle should fail")
+		return
+	}
+	h, err := s.Resample(time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if h.Len() != 3 || !h.Time(1).Equal(time.Date(2021, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected resample result %v", h.Candles())
+		return
+	}
+	c := h.Candle(1)
+	if c.Open.IntPart() != 30 || c.Close.IntPart() != 89 || c.High.IntPart() != 90 || c.Low.IntPart() != 29 || c.Volume.IntPart() != 60 {
+		t.Errorf("unexpected candle %+v", c)
+		return
+	}
+	if _, err := s.Resample(90 * time.Minute); err == nil {
+		t.Error("90m should be invalid interval")
+	}
+	// Buckets of them are not even after aligned.
+	for _, interval := range []time.Duration{7 * time.Minute, 45 * time.Minute, 5 * time.Hour, 48 * time.Hour, 7 * 24 * time.Hour} {
+		if _, err := NewSeries(interval); err == nil {
+			t.Errorf("%s should be invalid interval", interval)
+			return
+		}
+	}
+	for _, interval := range []time.Duration{time.Second, 15 * time.Second, 20 * time.Minute, 4 * time.Hour, 24 * time.Hour} {
+		if _, err := NewSeries(interval); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if s.Between(start.Add(10*time.Minute), start.Add(20*time.Minute)).Len() != 10 {
+		t.Error("Between failed")
+	}
+}
+
+func TestSeries_FillGaps(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := testSeries(t, time.Hour, start, []int{10, -1, -1, -1, 18, 20})
+	if len(s.Gaps()) != 3 {
+		t.Errorf("expect 3 gaps, got %v", s.Gaps())
+		return
+	}
+	prev := s.FillGaps(FillPrevious)
+	lin := s.FillGaps(FillLinear)
+	if prev.Len() != 6 || lin.Len() != 6 || len(lin.Gaps()) != 0 {
+		t.Error("FillGaps failed")
+		return
+	}
+	for i, expect := range []float64{10, 10, 10, 10, 18} {
+		if prev.Close(i) != expect {
+			t.Errorf("FillPrevious %d: expect %f, got %f", i, expect, prev.Close(i))
+			return
+		}
+	}
+	for i, expect := range []float64{10, 12, 14, 16, 18} {
+		if lin.Close(i) != expect {
+			t.Errorf("FillLinear %d: expect %f, got %f", i, expect, lin.Close(i))
+			return
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestIndicators(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	sma := SMA(values, 3)
+	if !math.IsNaN(sma[1]) || sma[2] != 2 || sma[9] != 9 {
+		t.Errorf("unexpected SMA %v", sma)
+		return
+	}
+	ema := EMA(values, 3)
+	if ema[2] != 2 || ema[3] != 3 || ema[9] != 9 {
+		t.Errorf("unexpected EMA %v", ema)
+		return
+	}
+	if rsi := RSI(values, 5); !math.IsNaN(rsi[4]) || rsi[5] != 100 {
+		t.Errorf("unexpected RSI %v", rsi)
+		return
+	}
+	rsi := RSI([]float64{1, 2, 1, 2, 1, 2, 1}, 2)
+	if !almostEqual(rsi[2], 50) || !almostEqual(rsi[3], 75) || !almostEqual(rsi[4], 37.5) {
+		t.Errorf("unexpected RSI %v", rsi)
+		return
+	}
+	macd, sig, hist := MACD(values, 2, 4, 2)
+	if !math.IsNaN(macd[2]) || !almostEqual(macd[3], 1) || !math.IsNaN(sig[3]) || !almostEqual(sig[4], 1) || !almostEqual(hist[9], 0) {
+		t.Errorf("unexpected MACD %v %v %v", macd, sig, hist)
+		return
+	}
+	mid, upper, lower := Bollinger([]float64{1, 3, 1, 3}, 2, 2)
+	if mid[1] != 2 || upper[1] != 4 || lower[3] != 0 {
+		t.Errorf("unexpected Bollinger %v %v %v", mid, upper, lower)
+		return
+	}
+	if mx := RollingMax(values, 4); mx[3] != 4 || RollingMin(values, 4)[9] != 7 {
+		t.Error("unexpected rolling min/max")
+	}
+}
+
+func TestADF(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	noise := make([]float64, 500)
+	walk := make([]float64, 500)
+	for i := range noise {
+		noise[i] = rnd.NormFloat64()
+		if i > 0 {
+			walk[i] = walk[i-1] + noise[i]
+		}
+	}
+	r, err := ADF(noise, -1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !r.Stationary(0.01) {
+		t.Errorf("white noise should be stationary %+v", r)
+		return
+	}
+	r, err = ADF(walk, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r.Stationary(0.1) {
+		t.Errorf("random walk should not be stationary %+v", r)
+		return
+	}
+	if _, err := ADF(noise[:5], 3); err == nil {
+		t.Error("too few values should fail")
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = 10 + float64(i%5)
+	}
+	values[50] = 100
+	values[80] = -50
+	z := ZScoreAnomalies(values, 20, 3)
+	if len(z) != 2 || z[0].Index != 50 || z[1].Index != 80 || z[1].Score > 0 {
+		t.Errorf("unexpected z-score anomalies %v", z)
+		return
+	}
+	m := MADAnomalies(values, 3.5)
+	if len(m) != 2 || m[0].Index != 50 || m[1].Index != 80 {
+		t.Errorf("unexpected MAD anomalies %v", m)
+	}
+}
@@ -302,6 +302,11 @@ func passphraseToKey(passphrase string) []byte {
 	return key
 }
 
+// NewAEAD creates ChaCha20-Poly1305 AEAD of version with 32 bytes key.
+func NewAEAD(key []byte, version gcrypto.Cipher) (cipher.AEAD, error) {
+	return newChaCha20AEAD(key, version)
+}
+
 func newChaCha20AEAD(key []byte, version gcrypto.Cipher) (cipher.AEAD, error) {
 	switch version {
 	case gcrypto.CipherChaCha20IETFPoly1305:
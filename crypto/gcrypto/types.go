@@ -36,7 +36,9 @@ type (
 )
 
 var (
-	CipherMono256 Cipher = "mono256"
+	CipherMono256              Cipher = "mono256"
 	CipherChaCha20IETFPoly1305 Cipher = "chacha20-ietf-poly1305"
-	CipherXChaCha20Poly1305 Cipher = "xchacha20-poly1305"
-)
\ No newline at end of file
+	CipherXChaCha20Poly1305    Cipher = "xchacha20-poly1305"
+	CipherAES128GCM            Cipher = "aes-128-gcm"
+	CipherAES256GCM            Cipher = "aes-256-gcm"
+)
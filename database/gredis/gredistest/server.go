@@ -0,0 +1,409 @@
+// Package gredistest provides an in-memory Redis server for tests, it supports a small subset of commands:
+// PING AUTH SELECT GET SET DEL EXPIRE PEXPIRE RPUSH LPUSH LPOP LLEN BLPOP PUBLISH SUBSCRIBE UNSUBSCRIBE.
+// Databases are not separated.
+package gredistest
+
+import (
+	"bufio"
+	"github.com/cryptowilliam/goutil/database/gredis"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	Server struct {
+		ln      net.Listener
+		mu      sync.Mutex
+		strs    map[string][]byte
+		lists   map[string][][]byte
+		expires map[string]time.Time
+		subs    map[string]map[*client]bool
+		pushed  chan struct{} // Closed and replaced on every push, to wake up BLPOP.
+		clients map[*client]bool
+		closed  chan struct{}
+		wg      sync.WaitGroup
+		// Like Redis before 6.0, BLPOP timeout must be an integer unless fractional timeout is enabled.
+		fracTimeout bool
+	}
+
+	client struct {
+		nc   net.Conn
+		wmu  sync.Mutex
+		w    *bufio.Writer
+		gone chan struct{} // Closed when connection is closed by peer, to wake up BLPOP.
+	}
+
+	simple string
+)
+
+// NewServer starts server on a random local port.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		ln:      ln,
+		strs:    map[string][]byte{},
+		lists:   map[string][][]byte{},
+		expires: map[string]time.Time{},
+		subs:    map[string]map[*client]bool{},
+		pushed:  make(chan struct{}),
+		clients: map[*client]bool{},
+		closed:  make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops server and closes all connections.
+func (s *Server) Close() {
+	close(s.closed)
+	s.ln.Close()
+	s.mu.Lock()
+	for c := range s.clients {
+		c.nc.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		c := &client{nc: nc, w: bufio.NewWriter(nc), gone: make(chan struct{})}
+		s.mu.Lock()
+		s.clients[c] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c *client) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		for _, m := range s.subs {
+			delete(m, c)
+		}
+		s.mu.Unlock()
+		c.nc.Close()
+	}()
+	// Commands are read in another goroutine, so blocked command can notice closing of connection.
+	reqs := make(chan interface{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(c.gone)
+		r := bufio.NewReader(c.nc)
+		for {
+			req, err := gredis.ReadReply(r)
+			if err != nil {
+				return
+			}
+			reqs <- req
+		}
+	}()
+	for {
+		var req interface{}
+		select {
+		case req = <-reqs:
+		case <-c.gone:
+			return
+		}
+		arr, ok := req.([]interface{})
+		if !ok || len(arr) == 0 {
+			c.write(gredis.Error("ERR invalid request"))
+			continue
+		}
+		args := make([][]byte, len(arr))
+		for i, a := range arr {
+			args[i], _ = a.([]byte)
+		}
+		s.exec(c, strings.ToUpper(string(args[0])), args[1:])
+	}
+}
+
+func (c *client) write(replies ...interface{}) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	for _, reply := range replies {
+		writeReply(c.w, reply)
+	}
+	c.w.Flush()
+}
+
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case simple:
+		w.WriteString("+" + string(v) + "\r\n")
+	case gredis.Error:
+		w.WriteString("-" + string(v) + "\r\n")
+	case int:
+		w.WriteString(":" + strconv.Itoa(v) + "\r\n")
+	case []byte:
+		w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n")
+		w.Write(v)
+		w.WriteString("\r\n")
+	case string:
+		writeReply(w, []byte(v))
+	case []interface{}:
+		if v == nil {
+			w.WriteString("*-1\r\n")
+			return
+		}
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, e := range v {
+			writeReply(w, e)
+		}
+	}
+}
+
+// expired deletes key if it is expired, s.mu must be locked.
+func (s *Server) expired(key string) {
+	if t, ok := s.expires[key]; ok && !time.Now().Before(t) {
+		delete(s.strs, key)
+		delete(s.lists, key)
+		delete(s.expires, key)
+	}
+}
+
+func (s *Server) exec(c *client, cmd string, args [][]byte) {
+	argc := map[string]int{"GET": 1, "SET": 2, "DEL": 1, "EXPIRE": 2, "PEXPIRE": 2, "RPUSH": 2, "LPUSH": 2,
+		"LPOP": 1, "LLEN": 1, "BLPOP": 2, "PUBLISH": 2, "SUBSCRIBE": 1, "AUTH": 1, "SELECT": 1}
+	if n, ok := argc[cmd]; ok && len(args) < n {
+		c.write(gredis.Error("ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command"))
+		return
+	}
+
+	switch cmd {
+	case "PING":
+		c.write(simple("PONG"))
+	case "AUTH", "SELECT":
+		c.write(simple("OK"))
+	case "GET":
+		s.mu.Lock()
+		s.expired(string(args[0]))
+		v, ok := s.strs[string(args[0])]
+		s.mu.Unlock()
+		if !ok {
+			c.write(nil)
+			return
+		}
+		c.write(v)
+	case "SET":
+		key := string(args[0])
+		s.mu.Lock()
+		delete(s.lists, key)
+		delete(s.expires, key)
+		s.strs[key] = append([]byte{}, args[1]...)
+		if len(args) >= 4 && strings.ToUpper(string(args[2])) == "PX" {
+			ms, _ := strconv.Atoi(string(args[3]))
+			s.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.mu.Unlock()
+		c.write(simple("OK"))
+	case "DEL":
+		n := 0
+		s.mu.Lock()
+		for _, k := range args {
+			key := string(k)
+			s.expired(key)
+			_, ok1 := s.strs[key]
+			_, ok2 := s.lists[key]
+			if ok1 || ok2 {
+				n++
+			}
+			delete(s.strs, key)
+			delete(s.lists, key)
+			delete(s.expires, key)
+		}
+		s.mu.Unlock()
+		c.write(n)
+	case "EXPIRE", "PEXPIRE":
+		key := string(args[0])
+		n, _ := strconv.Atoi(string(args[1]))
+		d := time.Duration(n) * time.Millisecond
+		if cmd == "EXPIRE" {
+			d = time.Duration(n) * time.Second
+		}
+		s.mu.Lock()
+		s.expired(key)
+		_, ok1 := s.strs[key]
+		_, ok2 := s.lists[key]
+		if ok1 || ok2 {
+			s.expires[key] = time.Now().Add(d)
+		}
+		s.mu.Unlock()
+		if ok1 || ok2 {
+			c.write(1)
+		} else {
+			c.write(0)
+		}
+	case "RPUSH", "LPUSH":
+		key := string(args[0])
+		s.mu.Lock()
+		s.expired(key)
+		l := s.lists[key]
+		for _, v := range args[1:] {
+			v = append([]byte{}, v...)
+			if cmd == "RPUSH" {
+				l = append(l, v)
+			} else {
+				l = append([][]byte{v}, l...)
+			}
+		}
+		s.lists[key] = l
+		close(s.pushed)
+		s.pushed = make(chan struct{})
+		s.mu.Unlock()
+		c.write(len(l))
+	case "LPOP":
+		s.mu.Lock()
+		v, ok := s.pop(string(args[0]))
+		s.mu.Unlock()
+		if !ok {
+			c.write(nil)
+			return
+		}
+		c.write(v)
+	case "LLEN":
+		s.mu.Lock()
+		s.expired(string(args[0]))
+		n := len(s.lists[string(args[0])])
+		s.mu.Unlock()
+		c.write(n)
+	case "BLPOP":
+		s.blpop(c, args)
+	case "PUBLISH":
+		ch := string(args[0])
+		s.mu.Lock()
+		var receivers []*client
+		for sub := range s.subs[ch] {
+			receivers = append(receivers, sub)
+		}
+		s.mu.Unlock()
+		for _, sub := range receivers {
+			sub.write([]interface{}{"message", ch, args[1]})
+		}
+		c.write(len(receivers))
+	case "SUBSCRIBE", "UNSUBSCRIBE":
+		s.mu.Lock()
+		var replies []interface{}
+		for _, a := range args {
+			ch := string(a)
+			if cmd == "SUBSCRIBE" {
+				if s.subs[ch] == nil {
+					s.subs[ch] = map[*client]bool{}
+				}
+				s.subs[ch][c] = true
+			} else {
+				delete(s.subs[ch], c)
+			}
+			replies = append(replies, []interface{}{strings.ToLower(cmd), ch, s.subCount(c)})
+		}
+		s.mu.Unlock()
+		c.write(replies...)
+	default:
+		c.write(gredis.Error("ERR unknown command '" + strings.ToLower(cmd) + "'"))
+	}
+}
+
+// pop removes the first element of list, s.mu must be locked.
+func (s *Server) pop(key string) ([]byte, bool) {
+	s.expired(key)
+	l := s.lists[key]
+	if len(l) == 0 {
+		return nil, false
+	}
+	if len(l) == 1 {
+		delete(s.lists, key)
+	} else {
+		s.lists[key] = l[1:]
+	}
+	return l[0], true
+}
+
+func (s *Server) subCount(c *client) int {
+	n := 0
+	for _, m := range s.subs {
+		if m[c] {
+			n++
+		}
+	}
+	return n
+}
+
+// SetFractionalTimeout makes server accept fractional BLPOP timeout like Redis 6.0 or later.
+func (s *Server) SetFractionalTimeout(enable bool) {
+	s.mu.Lock()
+	s.fracTimeout = enable
+	s.mu.Unlock()
+}
+
+func (s *Server) blpop(c *client, args [][]byte) {
+	keys := args[:len(args)-1]
+	s.mu.Lock()
+	frac := s.fracTimeout
+	s.mu.Unlock()
+	var secs float64
+	if frac {
+		f, err := strconv.ParseFloat(string(args[len(args)-1]), 64)
+		if err != nil || f < 0 {
+			c.write(gredis.Error("ERR timeout is not a float or out of range"))
+			return
+		}
+		secs = f
+	} else {
+		n, err := strconv.ParseInt(string(args[len(args)-1]), 10, 64)
+		if err != nil || n < 0 {
+			c.write(gredis.Error("ERR timeout is not an integer or out of range"))
+			return
+		}
+		secs = float64(n)
+	}
+	var timeout <-chan time.Time
+	if secs > 0 {
+		timer := time.NewTimer(time.Duration(secs * float64(time.Second)))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		s.mu.Lock()
+		for _, k := range keys {
+			if v, ok := s.pop(string(k)); ok {
+				s.mu.Unlock()
+				c.write([]interface{}{k, v})
+				return
+			}
+		}
+		pushed := s.pushed
+		s.mu.Unlock()
+		select {
+		case <-pushed:
+		case <-s.closed:
+			return
+		case <-c.gone:
+			return
+		case <-timeout:
+			c.write([]interface{}(nil))
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gredis
+
+import (
+	"context"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"sync"
+	"time"
+)
+
+type (
+	// MessageHandler is called in receiving goroutine of PubSub, it should not block for long.
+	MessageHandler func(channel string, message []byte)
+
+	// PubSub is a dedicated connection in subscribe mode.
+	PubSub struct {
+		cn      *conn
+		handler MessageHandler
+		wmu     sync.Mutex    // Serializes commands.
+		acks    chan struct{} // Confirmations of subscribe and unsubscribe.
+		done    chan struct{}
+		err     error
+	}
+)
+
+// ackTimeout is max time waiting for subscribe or unsubscribe confirmations.
+const ackTimeout = 10 * time.Second
+
+// NewPubSub opens a connection for subscribing, handler receives messages of subscribed channels.
+func (c *Client) NewPubSub(ctx context.Context, handler MessageHandler) (*PubSub, error) {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ps := &PubSub{cn: cn, handler: handler, acks: make(chan struct{}, 64), done: make(chan struct{})}
+	go ps.receive()
+	return ps, nil
+}
+
+func (ps *PubSub) receive() {
+	defer close(ps.done)
+	for {
+		reply, err := ReadReply(ps.cn.r)
+		if err != nil {
+			ps.err = err
+			return
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) < 3 {
+			continue
+		}
+		kind, _ := arr[0].([]byte)
+		switch string(kind) {
+		case "message":
+			channel, _ := arr[1].([]byte)
+			msg, _ := arr[2].([]byte)
+			ps.handler(string(channel), msg)
+		case "subscribe", "unsubscribe":
+			ps.acks <- struct{}{}
+		}
+	}
+}
+
+// command sends command and waits for one confirmation of each channel.
+func (ps *PubSub) command(cmd string, channels ...string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	ps.wmu.Lock()
+	defer ps.wmu.Unlock()
+	args := []interface{}{cmd}
+	for _, ch := range channels {
+		args = append(args, ch)
+	}
+	if err := WriteCommand(ps.cn.w, args...); err != nil {
+		return err
+	}
+	timer := time.NewTimer(ackTimeout)
+	defer timer.Stop()
+	for range channels {
+		select {
+		case <-ps.acks:
+		case <-ps.done:
+			return gerrors.Wrap(ps.err, "redis: pubsub connection broken")
+		case <-timer.C:
+			return gerrors.Errorf("redis: %s confirmation timeout", cmd)
+		}
+	}
+	return nil
+}
+
+// Subscribe subscribes channels, messages published after it returns will be received.
+func (ps *PubSub) Subscribe(channels ...string) error {
+	return ps.command("SUBSCRIBE", channels...)
+}
+
+func (ps *PubSub) Unsubscribe(channels ...string) error {
+	return ps.command("UNSUBSCRIBE", channels...)
+}
+
+// Done is closed when connection is closed or broken.
+func (ps *PubSub) Done() <-chan struct{} {
+	return ps.done
+}
+
+func (ps *PubSub) Close() error {
+	err := ps.cn.nc.Close()
+	<-ps.done
+	return err
+}
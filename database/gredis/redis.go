@@ -1 +1,316 @@
-package xredis
+package gredis
+
+import (
+	"bufio"
+	"context"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"net"
+	"sync"
+	"time"
+)
+
+type (
+	Options struct {
+		Addr        string // host:port, default is localhost:6379.
+		Password    string
+		DB          int
+		PoolSize    int // Max idle connections, default is 10.
+		DialTimeout time.Duration
+		// FractionalTimeout sends blocking command timeout in fractional seconds, it requires Redis 6.0 or later.
+		// Otherwise timeout is rounded up to whole seconds.
+		FractionalTimeout bool
+	}
+
+	// Client is a minimal Redis client with connection pool, it is safe for concurrent use.
+	Client struct {
+		opts   Options
+		mu     sync.Mutex
+		idle   []*conn
+		closed bool
+	}
+
+	conn struct {
+		nc net.Conn
+		r  *bufio.Reader
+		w  *bufio.Writer
+	}
+)
+
+var ErrClosed = gerrors.New("redis: client closed")
+
+func New(opts Options) *Client {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	return &Client{opts: opts}
+}
+
+// Dial creates client and checks connection by PING.
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	c := New(opts)
+	if err := c.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{nc: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.opts.Password != "" {
+		if _, err := cn.do(ctx, "AUTH", c.opts.Password); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(ctx, "SELECT", c.opts.DB); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		cn.nc.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// do sends command and reads reply, error reply is returned as Error.
+// Canceling ctx interrupts network IO.
+func (cn *conn) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	if err := cn.nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if done := ctx.Done(); done != nil {
+		stop, exited := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(exited)
+			select {
+			case <-done:
+				cn.nc.SetDeadline(time.Now())
+			case <-stop:
+			}
+		}()
+		// Watcher must exit before conn is reused.
+		defer func() {
+			close(stop)
+			<-exited
+		}()
+	}
+	if err := WriteCommand(cn.w, args...); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	reply, err := ReadReply(cn.r)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// ctxError returns error of ctx if network error is caused by ctx.
+func ctxError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// Network deadline may be exceeded a little earlier than ctx.
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// Do sends command and returns reply, see ReadReply for reply types. Deadline and cancellation of ctx apply to network IO.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(ctx, args...)
+	if _, isReplyErr := err.(Error); err != nil && !isReplyErr {
+		// Connection state is unknown after network or protocol error.
+		cn.nc.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Close closes idle connections, connections in use are closed when they are returned.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, cn := range c.idle {
+		cn.nc.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+func toBytes(reply interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case nil:
+		return nil, ErrNil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, gerrors.Errorf("redis: unexpected reply type %T", reply)
+}
+
+func toInt(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := reply.(int64); ok {
+		return v, nil
+	}
+	return 0, gerrors.Errorf("redis: unexpected reply type %T", reply)
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	return toBytes(c.Do(ctx, "GET", key))
+}
+
+// Set sets key to value, expiration 0 means no expiration.
+func (c *Client) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	args := []interface{}{"SET", key, value}
+	if expiration > 0 {
+		args = append(args, "PX", int64(expiration/time.Millisecond))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Del deletes keys and returns number of deleted keys.
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	args := []interface{}{"DEL"}
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	return toInt(c.Do(ctx, args...))
+}
+
+func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	_, err := c.Do(ctx, "PEXPIRE", key, int64(expiration/time.Millisecond))
+	return err
+}
+
+// Publish sends message to channel and returns number of receivers.
+func (c *Client) Publish(ctx context.Context, channel string, message []byte) (int64, error) {
+	return toInt(c.Do(ctx, "PUBLISH", channel, message))
+}
+
+// RPush appends values to list and returns its length.
+func (c *Client) RPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	args := []interface{}{"RPUSH", key}
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return toInt(c.Do(ctx, args...))
+}
+
+// LPop removes and returns the first element of list, it returns ErrNil if list is empty.
+// LPush prepends values to list and returns its length, values are inserted one after another to the head.
+func (c *Client) LPush(ctx context.Context, key string, values ...[]byte) (int64, error) {
+	args := []interface{}{"LPUSH", key}
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return toInt(c.Do(ctx, args...))
+}
+
+func (c *Client) LPop(ctx context.Context, key string) ([]byte, error) {
+	return toBytes(c.Do(ctx, "LPOP", key))
+}
+
+func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
+	return toInt(c.Do(ctx, "LLEN", key))
+}
+
+// BLPop waits up to timeout for the first element of lists, and returns the key and element.
+// It returns ErrNil if timeout, 0 means waiting forever. Timeout is rounded up to whole seconds,
+// or to milliseconds if Options.FractionalTimeout is set.
+func (c *Client) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, []byte, error) {
+	args := []interface{}{"BLPOP"}
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	if timeout < 0 {
+		return "", nil, gerrors.Errorf("redis: negative BLPOP timeout %s", timeout)
+	}
+	if c.opts.FractionalTimeout {
+		args = append(args, timeout.Round(time.Millisecond).Seconds())
+	} else {
+		timeout = (timeout + time.Second - 1).Truncate(time.Second)
+		args = append(args, int64(timeout/time.Second))
+	}
+	// Network deadline must be later than server side timeout.
+	if timeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout+5*time.Second)
+			defer cancel()
+		}
+	}
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	arr, ok := reply.([]interface{})
+	if reply == nil || (ok && len(arr) == 0) {
+		return "", nil, ErrNil
+	}
+	if !ok || len(arr) != 2 {
+		return "", nil, gerrors.Errorf("redis: unexpected BLPOP reply %v", reply)
+	}
+	key, _ := arr[0].([]byte)
+	val, _ := arr[1].([]byte)
+	return string(key), val, nil
+}
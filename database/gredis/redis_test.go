@@ -0,0 +1,169 @@
+package gredis_test
+
+import (
+	"context"
+	"github.com/cryptowilliam/goutil/database/gredis"
+	"github.com/cryptowilliam/goutil/database/gredis/gredistest"
+	"os"
+	"testing"
+	"time"
+)
+
+// newClient connects to REDIS_ADDR if it is set, or an in-memory server.
+func newClient(t *testing.T) *gredis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		s, err := gredistest.NewServer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(s.Close)
+		addr = s.Addr()
+	}
+	c, err := gredis.Dial(context.Background(), gredis.Options{Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := newClient(t)
+	ctx := context.Background()
+	key := "gredis:test:" + time.Now().String()
+	defer c.Del(ctx, key, key+":list")
+
+	if _, err := c.Get(ctx, key); err != gredis.ErrNil {
+		t.Errorf("expect ErrNil, got %v", err)
+		return
+	}
+	if err := c.Set(ctx, key, []byte("v\r\n1"), time.Minute); err != nil {
+		t.Error(err)
+		return
+	}
+	if v, err := c.Get(ctx, key); err != nil || string(v) != "v\r\n1" {
+		t.Errorf("unexpected value %q %v", v, err)
+		return
+	}
+	if _, err := c.Do(ctx, "NOSUCHCOMMAND"); err == nil {
+		t.Error("unknown command should fail")
+		return
+	} else if _, ok := err.(gredis.Error); !ok {
+		t.Errorf("expect server error, got %T", err)
+		return
+	}
+
+	list := key + ":list"
+	if n, err := c.RPush(ctx, list, []byte("a"), []byte("b")); err != nil || n != 2 {
+		t.Errorf("unexpected RPUSH result %d %v", n, err)
+		return
+	}
+	if v, err := c.LPop(ctx, list); err != nil || string(v) != "a" {
+		t.Errorf("unexpected LPOP result %s %v", v, err)
+		return
+	}
+	if k, v, err := c.BLPop(ctx, time.Second, list); err != nil || k != list || string(v) != "b" {
+		t.Errorf("unexpected BLPOP result %s %s %v", k, v, err)
+		return
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.RPush(ctx, list, []byte("c"))
+	}()
+	if _, v, err := c.BLPop(ctx, 5*time.Second, list); err != nil || string(v) != "c" {
+		t.Errorf("BLPOP should wait for push, got %s %v", v, err)
+		return
+	}
+	if _, _, err := c.BLPop(ctx, 100*time.Millisecond, list); err != gredis.ErrNil {
+		t.Errorf("BLPOP should timeout, got %v", err)
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	c := newClient(t)
+	ctx := context.Background()
+	received := make(chan string, 10)
+	ps, err := c.NewPubSub(ctx, func(channel string, message []byte) {
+		received <- channel + ":" + string(message)
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer ps.Close()
+
+	if err := ps.Subscribe("gredis:ch1", "gredis:ch2"); err != nil {
+		t.Error(err)
+		return
+	}
+	if n, err := c.Publish(ctx, "gredis:ch2", []byte("hello")); err != nil || n != 1 {
+		t.Errorf("unexpected PUBLISH result %d %v", n, err)
+		return
+	}
+	select {
+	case msg := <-received:
+		if msg != "gredis:ch2:hello" {
+			t.Errorf("unexpected message %s", msg)
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("message not received")
+		return
+	}
+	if err := ps.Unsubscribe("gredis:ch2"); err != nil {
+		t.Error(err)
+		return
+	}
+	if n, _ := c.Publish(ctx, "gredis:ch2", []byte("again")); n != 0 {
+		t.Errorf("unsubscribed channel still has %d receivers", n)
+	}
+}
+
+func TestClient_BLPopTimeout(t *testing.T) {
+	s, err := gredistest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	// Servers before Redis 6.0 reject fractional timeout, so it is rounded up to whole seconds by default.
+	c, err := gredis.Dial(ctx, gredis.Options{Addr: s.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	start := time.Now()
+	if _, _, err := c.BLPop(ctx, 100*time.Millisecond, "list"); err != gredis.ErrNil {
+		t.Errorf("BLPOP should timeout, got %v", err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("timeout should be rounded up to 1s, waited %s", elapsed)
+		return
+	}
+	if _, _, err := c.BLPop(ctx, -time.Second, "list"); err == nil {
+		t.Error("negative timeout should fail")
+		return
+	}
+
+	fc, err := gredis.Dial(ctx, gredis.Options{Addr: s.Addr(), FractionalTimeout: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fc.Close()
+	if _, _, err := fc.BLPop(ctx, 100*time.Millisecond, "list"); err == nil || err == gredis.ErrNil {
+		t.Errorf("fractional timeout should be rejected by old server, got %v", err)
+		return
+	}
+	s.SetFractionalTimeout(true)
+	start = time.Now()
+	if _, _, err := fc.BLPop(ctx, 100*time.Millisecond, "list"); err != gredis.ErrNil {
+		t.Errorf("BLPOP should timeout, got %v", err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("fractional timeout waited %s", elapsed)
+	}
+}
@@ -0,0 +1,111 @@
+package gredis
+
+import (
+	"bufio"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"io"
+	"strconv"
+)
+
+// RESP2 protocol: https://redis.io/docs/reference/protocol-spec/
+
+// ErrNil is returned when reply is nil bulk string or nil array, like LPOP of empty list.
+var ErrNil = gerrors.New("redis: nil reply")
+
+// Error is an error reply from server, like "ERR unknown command".
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// WriteCommand writes args as RESP array of bulk strings.
+// Args can be string, []byte, int, int64 or float64.
+func WriteCommand(w *bufio.Writer, args ...interface{}) error {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(len(args)))
+	w.WriteString("\r\n")
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		case float64:
+			b = strconv.AppendFloat(nil, v, 'f', -1, 64)
+		default:
+			return gerrors.Errorf("redis: unsupported argument type %T", arg)
+		}
+		w.WriteByte('$')
+		w.WriteString(strconv.Itoa(len(b)))
+		w.WriteString("\r\n")
+		w.Write(b)
+		w.WriteString("\r\n")
+	}
+	return w.Flush()
+}
+
+// ReadReply reads one reply: simple string as string, error as Error, integer as int64,
+// bulk string as []byte, array as []interface{}, nil bulk string and nil array as nil.
+func ReadReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, gerrors.New("redis: empty reply line")
+	}
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, gerrors.Errorf("redis: invalid bulk length %s", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, gerrors.Errorf("redis: invalid array length %s", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			if res[i], err = ReadReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, gerrors.Errorf("redis: invalid reply type %q", line[0])
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, gerrors.New("redis: invalid line ending")
+	}
+	return line[:len(line)-2], nil
+}
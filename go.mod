@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/AdguardTeam/dnsproxy v0.39.10
+	github.com/BurntSushi/toml v1.2.1
 	github.com/ChimeraCoder/anaconda v2.0.0+incompatible
 	github.com/Cubox-/libping v0.0.0-20181204104622-3011f76aad09
 	github.com/Pallinder/go-randomdata v1.2.0
@@ -28,7 +29,6 @@ require (
 	github.com/chromedp/cdproto v0.0.0-20211025030258-2570df970243
 	github.com/chromedp/chromedp v0.7.4
 	github.com/domainr/whois v0.0.0-20211025160740-e7d4e4b2d0ab
-	github.com/dxhbiz/codec v0.0.1
 	github.com/emersion/go-imap v1.2.0
 	github.com/emersion/go-message v0.15.0
 	github.com/emirpasic/gods v1.12.0
@@ -73,7 +73,6 @@ require (
 	github.com/lucas-clemente/quic-go v0.26.0
 	github.com/mailru/easyjson v0.7.7
 	github.com/manifoldco/promptui v0.9.0
-	github.com/marcsauter/single v0.0.0-20201009143647-9f8d81240be2
 	github.com/markcheno/go-talib v0.0.0-20190307022042-cd53a9264d70
 	github.com/matcornic/hermes/v2 v2.1.0
 	github.com/mcuadros/go-version v0.0.0-20190830083331-035f6764e8d2
@@ -127,6 +126,7 @@ require (
 	gonum.org/v1/gonum v0.9.3
 	google.golang.org/api v0.60.0
 	gopkg.in/headzoo/surf.v1 v1.0.1
+	gopkg.in/yaml.v2 v2.4.0
 	upper.io/db.v3 v3.8.0+incompatible
 	v2ray.com/core v4.19.1+incompatible
 	xorm.io/xorm v1.2.5
@@ -309,7 +309,6 @@ require (
 	gopkg.in/mattn/go-runewidth.v0 v0.0.3 // indirect
 	gopkg.in/mgo.v2 v2.0.0-20190816093944-a6b53ec6cb22 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 	rsc.io/qr v0.2.0 // indirect
 	xorm.io/builder v0.3.9 // indirect
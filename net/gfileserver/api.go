@@ -7,6 +7,7 @@ package gfileserver
 import (
 	"fmt"
 	"github.com/cryptowilliam/goutil/basic/glog"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
@@ -15,10 +16,23 @@ import (
 
 var (
 	path string
+	root http.FileSystem
 )
 
 func NewFileServer(localAddr, dir string) {
 	path = dir
+	root = http.Dir(dir)
+	serve(localAddr)
+}
+
+// NewFileServerFS serves read-only file system like gbindata.Bundle, upload and delete are disabled.
+func NewFileServerFS(localAddr string, fsys fs.FS) {
+	path = ""
+	root = http.FS(fsys)
+	serve(localAddr)
+}
+
+func serve(localAddr string) {
 	http.HandleFunc("/", detector)
 	err := http.ListenAndServe(localAddr, nil)
 	if err != nil {
@@ -29,6 +43,10 @@ func NewFileServer(localAddr, dir string) {
 
 func detector(w http.ResponseWriter, r *http.Request) {
 	if strings.HasSuffix(r.RequestURI, "uploadapi") {
+		if path == "" {
+			http.Error(w, "read-only file server", http.StatusForbidden)
+			return
+		}
 		uploadHandler(w, r)
 		return
 	}
@@ -36,11 +54,11 @@ func detector(w http.ResponseWriter, r *http.Request) {
 	ip := strings.Split(r.RemoteAddr, ":")[0]
 	log.Println(ip, r.RequestURI, "visited")
 
-	if strings.HasSuffix(r.RequestURI, "upload") {
+	if strings.HasSuffix(r.RequestURI, "upload") && path != "" {
 		uploadPageHandler(w, r)
 		return
 	}
-	http.FileServer(http.Dir(path)).ServeHTTP(w, r)
+	http.FileServer(root).ServeHTTP(w, r)
 }
 
 func checkError(err error) {
@@ -0,0 +1,572 @@
+// Package gmqtest is conformance test suite of gmq.MQ implementations.
+package gmqtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/net/gmq"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wait is max time waiting for asynchronous delivery.
+const wait = 5 * time.Second
+
+// Run runs all conformance tests, newMQ creates a fresh MQ for each test and releases it by t.Cleanup.
+// Request timeout of MQ should be short (about one second), for timeout tests.
+func Run(t *testing.T, newMQ func(t *testing.T) gmq.MQ) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, mq gmq.MQ)
+	}{
+		{"PubSub", testPubSub},
+		{"PubSubOrder", testPubSubOrder},
+		{"UnSub", testUnSub},
+		{"PushPop", testPushPop},
+		{"RequestReply", testRequestReply},
+		{"RequestError", testRequestError},
+		{"RequestTimeout", testRequestTimeout},
+		{"RequestContext", testRequestContext},
+		{"CompetingRepliers", testCompetingRepliers},
+		{"ReplierHandover", testReplierHandover},
+		{"Gather", testGather},
+		{"UnReplyGather", testUnReplyGather},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newMQ(t))
+		})
+	}
+}
+
+// name returns unique name, so tests don't interfere with each other on shared broker.
+func name(t *testing.T, kind string) string {
+	return fmt.Sprintf("gmqtest.%s.%s.%d", strings.ReplaceAll(t.Name(), "/", "."), kind, rand.Int63())
+}
+
+// collector collects messages received by callback.
+type collector struct {
+	mu   sync.Mutex
+	msgs []string
+	ch   chan struct{}
+}
+
+func newCollector() *collector {
+	return &collector{ch: make(chan struct{}, 1024)}
+}
+
+func (c *collector) callback(subj string, b []byte) {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, string(b))
+	c.mu.Unlock()
+	c.ch <- struct{}{}
+}
+
+// waitN waits for n messages, and returns all received messages.
+func (c *collector) waitN(n int) []string {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.ch:
+		case <-timer.C:
+			i = n
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.msgs...)
+}
+
+func testPubSub(t *testing.T, mq gmq.MQ) {
+	subj, other := name(t, "subj"), name(t, "other")
+	c1, c2, c3 := newCollector(), newCollector(), newCollector()
+	for _, sub := range []struct {
+		subj string
+		c    *collector
+	}{{subj, c1}, {subj, c2}, {other, c3}} {
+		if err := mq.Sub(sub.subj, sub.c.callback); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if err := mq.Pub(subj, []byte("hello")); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.Pub(other, []byte("world")); err != nil {
+		t.Error(err)
+		return
+	}
+	for i, c := range []*collector{c1, c2} {
+		if msgs := c.waitN(1); len(msgs) != 1 || msgs[0] != "hello" {
+			t.Errorf("subscriber %d received %v", i, msgs)
+			return
+		}
+	}
+	if msgs := c3.waitN(1); len(msgs) != 1 || msgs[0] != "world" {
+		t.Errorf("subscriber of other subject received %v", msgs)
+	}
+}
+
+func testPubSubOrder(t *testing.T, mq gmq.MQ) {
+	subj := name(t, "subj")
+	c := newCollector()
+	if err := mq.Sub(subj, c.callback); err != nil {
+		t.Error(err)
+		return
+	}
+	const n = 100
+	for i := 0; i < n; i++ {
+		if err := mq.Pub(subj, []byte(fmt.Sprint(i))); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	msgs := c.waitN(n)
+	if len(msgs) != n {
+		t.Errorf("received %d messages, expect %d", len(msgs), n)
+		return
+	}
+	for i, msg := range msgs {
+		if msg != fmt.Sprint(i) {
+			t.Errorf("message %d is %s, out of order", i, msg)
+			return
+		}
+	}
+}
+
+func testUnSub(t *testing.T, mq gmq.MQ) {
+	subj := name(t, "subj")
+	c1, c2 := newCollector(), newCollector()
+	if err := mq.Sub(subj, c1.callback); err != nil {
+		t.Error(err)
+		return
+	}
+	// Method values c1.callback and c2.callback have the same function code, use another func for c2.
+	cb2 := func(subj string, b []byte) { c2.callback(subj, b) }
+	if err := mq.Sub(subj, cb2); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.UnSub(subj, c1.callback); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.UnSub(subj, c1.callback); err == nil {
+		t.Error("UnSub of removed callback should fail")
+		return
+	}
+	if err := mq.Pub(subj, []byte("1")); err != nil {
+		t.Error(err)
+		return
+	}
+	// c2 receiving the message means it has been dispatched.
+	if msgs := c2.waitN(1); len(msgs) != 1 {
+		t.Errorf("remaining subscriber received %v", msgs)
+		return
+	}
+	time.Sleep(50 * time.Millisecond)
+	if msgs := c1.waitN(0); len(msgs) != 0 {
+		t.Errorf("removed subscriber received %v", msgs)
+		return
+	}
+
+	if err := mq.UnSub(subj, cb2); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.Sub(subj, c1.callback); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.Pub(subj, []byte("2")); err != nil {
+		t.Error(err)
+		return
+	}
+	if msgs := c1.waitN(1); len(msgs) != 1 || msgs[0] != "2" {
+		t.Errorf("resubscribed subscriber received %v", msgs)
+	}
+}
+
+func testPushPop(t *testing.T, mq gmq.MQ) {
+	queue := name(t, "queue")
+	if _, err := mq.Pop(queue); !errors.Is(err, gmq.ErrEmpty) {
+		t.Errorf("Pop of empty queue returns %v, expect ErrEmpty", err)
+		return
+	}
+	items := [][]byte{[]byte("first"), {0, 1, 2, '\r', '\n', 255}, {}, []byte("last")}
+	for _, item := range items {
+		if err := mq.Push(queue, item); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	for i, item := range items {
+		b, err := mq.Pop(queue)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(b) != string(item) {
+			t.Errorf("item %d is %v, expect %v", i, b, item)
+			return
+		}
+	}
+	if _, err := mq.Pop(queue); !errors.Is(err, gmq.ErrEmpty) {
+		t.Errorf("Pop of drained queue returns %v, expect ErrEmpty", err)
+	}
+}
+
+// echo replies request with prefix "re:".
+func echo(service string, b []byte) ([]byte, error) {
+	return append([]byte("re:"), b...), nil
+}
+
+func testRequestReply(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	var mu sync.Mutex
+	handled := 0
+	callback := func(s string, b []byte) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if s != service {
+			return nil, fmt.Errorf("unexpected service %s", s)
+		}
+		handled++
+		return echo(s, b)
+	}
+	if err := mq.Reply(service, callback); err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 3; i++ {
+		req := []byte{byte(i), 0, '\r', '\n', 255}
+		rep, err := mq.RequestContext(context.Background(), service, req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(rep) != "re:"+string(req) {
+			t.Errorf("reply is %v, expect echo of %v", rep, req)
+			return
+		}
+	}
+	if err := mq.Request(service, []byte("ping")); err != nil {
+		t.Error(err)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 4 {
+		t.Errorf("handled %d requests, expect 4", handled)
+	}
+}
+
+func testRequestError(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	if err := mq.Reply(service, func(_ string, b []byte) ([]byte, error) {
+		if len(b) == 0 {
+			return nil, errors.New("remote failure")
+		}
+		return nil, gerrors.Wrap(gerrors.CodeNotFound.New("user %s not found", b), "query")
+	}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err := mq.RequestContext(context.Background(), service, nil)
+	if !gerrors.IsGerror(err) || err.Error() != "remote failure" || gerrors.CodeOf(err) != gerrors.CodeUnknown {
+		t.Errorf("RequestContext returns %#v, expect remote error as gerrors value", err)
+		return
+	}
+	_, err = mq.RequestContext(context.Background(), service, []byte("bob"))
+	if !errors.Is(err, gerrors.CodeNotFound) || err.Error() != "query: user bob not found" {
+		t.Errorf("RequestContext returns %v, expect remote error with code", err)
+		return
+	}
+	if err := mq.Request(service, nil); err == nil || err.Error() != "remote failure" {
+		t.Errorf("Request returns %v, expect remote error", err)
+	}
+}
+
+func testRequestTimeout(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	if err := mq.Request(service, nil); !errors.Is(err, gmq.ErrTimeout) {
+		t.Errorf("Request without replier returns %v, expect ErrTimeout", err)
+		return
+	}
+
+	callback := func(string, []byte) ([]byte, error) { return nil, nil }
+	if err := mq.Reply(service, callback); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.UnReply(service, callback); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := mq.UnReply(service, callback); err == nil {
+		t.Error("UnReply of removed callback should fail")
+		return
+	}
+	if err := mq.Request(service, nil); !errors.Is(err, gmq.ErrTimeout) {
+		t.Errorf("Request after UnReply returns %v, expect ErrTimeout", err)
+	}
+}
+
+func testRequestContext(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	release := make(chan struct{})
+	defer close(release)
+	if err := mq.Reply(service, func(string, []byte) ([]byte, error) {
+		<-release
+		return nil, nil
+	}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := mq.RequestContext(ctx, service, nil)
+	if !errors.Is(err, gmq.ErrTimeout) || gerrors.CodeOf(err) != gerrors.CodeDeadlineExceeded {
+		t.Errorf("RequestContext returns %v, expect ErrTimeout", err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("RequestContext returns after %v, deadline of context is ignored", elapsed)
+		return
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := mq.RequestContext(ctx, service, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("RequestContext returns %v, expect context.Canceled", err)
+	}
+}
+
+func testCompetingRepliers(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	var mu sync.Mutex
+	counts := make([]int, 3)
+	for i := range counts {
+		i := i
+		if err := mq.Reply(service, func(string, []byte) ([]byte, error) {
+			mu.Lock()
+			counts[i]++
+			mu.Unlock()
+			return nil, nil
+		}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	const n = 30
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- mq.Request(service, nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if total != n {
+		t.Errorf("repliers handled %d requests, expect each of %d requests handled once", total, n)
+	}
+}
+
+// testReplierHandover checks requests arriving while repliers are removed are not lost.
+func testReplierHandover(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	const n = 3
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		if err := mq.Reply(service, echo); err != nil {
+			t.Error(err)
+			return
+		}
+		go func(i int) {
+			ctx, cancel := context.WithTimeout(context.Background(), wait)
+			defer cancel()
+			req := []byte(fmt.Sprint(i))
+			rep, err := mq.RequestContext(ctx, service, req)
+			if err == nil && string(rep) != "re:"+string(req) {
+				err = fmt.Errorf("reply is %s, expect echo of %s", rep, req)
+			}
+			errs <- err
+		}(i)
+		// Request may arrive before, during or after UnReply.
+		time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+		if err := mq.UnReply(service, echo); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	// Requests not handled yet are left to the next replier.
+	if err := mq.Reply(service, echo); err != nil {
+		t.Error(err)
+		return
+	}
+	defer mq.UnReply(service, echo)
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+			return
+		}
+	}
+}
+
+func testGather(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	for i := 0; i < 3; i++ {
+		i := i
+		if err := mq.Reply(service, func(_ string, b []byte) ([]byte, error) {
+			if i == 2 {
+				return nil, gerrors.CodeUnavailable.New("replier %d is down", i)
+			}
+			return []byte(fmt.Sprintf("%s from %d", b, i)), nil
+		}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	check := func(res []gmq.Response, expect int) bool {
+		var bodies []string
+		failed := 0
+		for _, r := range res {
+			if r.Err != nil {
+				if !errors.Is(r.Err, gerrors.CodeUnavailable) || r.Err.Error() != "replier 2 is down" {
+					t.Errorf("unexpected error %v in replies", r.Err)
+					return false
+				}
+				failed++
+				continue
+			}
+			bodies = append(bodies, string(r.Body))
+		}
+		sort.Strings(bodies)
+		if len(res) != expect || (expect == 3 && (failed != 1 || strings.Join(bodies, ",") != "hi from 0,hi from 1")) {
+			t.Errorf("received %d replies %v, expect %d", len(res), bodies, expect)
+			return false
+		}
+		return true
+	}
+
+	res, err := mq.Gather(context.Background(), service, []byte("hi"), 3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !check(res, 3) {
+		return
+	}
+	res, err = mq.Gather(context.Background(), service, []byte("hi"), 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !check(res, 2) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	res, err = mq.Gather(ctx, service, []byte("hi"), 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !check(res, 3) {
+		return
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	res, err = mq.Gather(ctx, service, []byte("hi"), 5)
+	if !errors.Is(err, gmq.ErrTimeout) {
+		t.Errorf("Gather of too many replies returns %v, expect ErrTimeout", err)
+		return
+	}
+	if !check(res, 3) {
+		return
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	res, err = mq.Gather(ctx, name(t, "nobody"), nil, 1)
+	if !errors.Is(err, gmq.ErrTimeout) || len(res) != 0 {
+		t.Errorf("Gather without replier returns %v %v, expect ErrTimeout", res, err)
+	}
+}
+
+// testUnReplyGather checks UnReply waits for callback handling broadcast request.
+func testUnReplyGather(t *testing.T, mq gmq.MQ) {
+	service := name(t, "service")
+	entered, release := make(chan struct{}), make(chan struct{})
+	callback := func(string, []byte) ([]byte, error) {
+		close(entered)
+		<-release
+		return nil, nil
+	}
+	if err := mq.Reply(service, callback); err != nil {
+		t.Error(err)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		mq.Gather(ctx, service, nil, 1)
+	}()
+	select {
+	case <-entered:
+	case <-time.After(wait):
+		t.Error("callback is not called by Gather")
+		return
+	}
+
+	unreplied := make(chan error, 1)
+	go func() {
+		unreplied <- mq.UnReply(service, callback)
+	}()
+	select {
+	case <-unreplied:
+		close(release)
+		t.Error("UnReply returns before callback returns")
+		return
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-unreplied:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(wait):
+		t.Error("UnReply doesn't return after callback returns")
+	}
+}
@@ -0,0 +1,238 @@
+package gmq
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Memory is an in-process MQ, it is suitable for tests and single process applications.
+	Memory struct {
+		opts     Options
+		subs     subscribers
+		mu       sync.Mutex
+		queues   map[string][][]byte
+		services map[string]chan *memRequest
+		repliers map[string][]*replier
+		closed   chan struct{}
+		wg       sync.WaitGroup
+	}
+
+	memRequest struct {
+		ctx    context.Context
+		body   []byte
+		result chan Response // Buffered, replier never blocks on it.
+	}
+)
+
+func NewMemory(opts Options) *Memory {
+	return &Memory{
+		opts:     opts.withDefaults(),
+		queues:   map[string][][]byte{},
+		services: map[string]chan *memRequest{},
+		repliers: map[string][]*replier{},
+		closed:   make(chan struct{}),
+	}
+}
+
+func (m *Memory) isClosed() bool {
+	select {
+	case <-m.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Memory) Pub(subj string, b []byte) error {
+	if m.isClosed() {
+		return ErrClosed
+	}
+	m.subs.publish(subj, append([]byte{}, b...))
+	return nil
+}
+
+func (m *Memory) Sub(subj string, callback SubCallback) error {
+	if m.isClosed() {
+		return ErrClosed
+	}
+	m.subs.add(subj, callback)
+	return nil
+}
+
+func (m *Memory) UnSub(subj string, callback SubCallback) error {
+	_, err := m.subs.remove(subj, callback)
+	return err
+}
+
+func (m *Memory) Push(queue string, b []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClosed() {
+		return ErrClosed
+	}
+	m.queues[queue] = append(m.queues[queue], append([]byte{}, b...))
+	return nil
+}
+
+func (m *Memory) Pop(queue string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClosed() {
+		return nil, ErrClosed
+	}
+	q := m.queues[queue]
+	if len(q) == 0 {
+		return nil, ErrEmpty
+	}
+	if len(q) == 1 {
+		delete(m.queues, queue)
+	} else {
+		m.queues[queue] = q[1:]
+	}
+	return q[0], nil
+}
+
+// service returns request channel of service, m.mu must be locked.
+func (m *Memory) service(service string) chan *memRequest {
+	ch, ok := m.services[service]
+	if !ok {
+		ch = make(chan *memRequest, 1024)
+		m.services[service] = ch
+	}
+	return ch
+}
+
+func (m *Memory) Request(service string, b []byte) error {
+	_, err := m.RequestContext(context.Background(), service, b)
+	return err
+}
+
+func (m *Memory) RequestContext(ctx context.Context, service string, b []byte) ([]byte, error) {
+	m.mu.Lock()
+	if m.isClosed() {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	ch := m.service(service)
+	m.mu.Unlock()
+
+	ctx, cancel := m.opts.requestContext(ctx)
+	defer cancel()
+	req := &memRequest{ctx: ctx, body: append([]byte{}, b...), result: make(chan Response, 1)}
+	select {
+	case ch <- req:
+	case <-ctx.Done():
+		return nil, ctxError(ctx, service)
+	case <-m.closed:
+		return nil, ErrClosed
+	}
+	select {
+	case res := <-req.result:
+		return res.Body, res.Err
+	case <-ctx.Done():
+		return nil, ctxError(ctx, service)
+	case <-m.closed:
+		return nil, ErrClosed
+	}
+}
+
+func (m *Memory) Gather(ctx context.Context, service string, b []byte, n int) ([]Response, error) {
+	m.mu.Lock()
+	if m.isClosed() {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	repliers := append([]*replier{}, m.repliers[service]...)
+	m.wg.Add(len(repliers))
+	m.mu.Unlock()
+
+	ctx, cancel := m.opts.requestContext(ctx)
+	defer cancel()
+	results := make(chan Response, len(repliers))
+	for _, r := range repliers {
+		// Replier removed after it is copied doesn't answer.
+		if !r.enter() {
+			m.wg.Done()
+			continue
+		}
+		go func(r *replier) {
+			defer m.wg.Done()
+			defer r.calls.Done()
+			body, err := r.callback(service, append([]byte{}, b...))
+			results <- Response{Body: body, Err: remoteError(err)}
+		}(r)
+	}
+
+	var res []Response
+	for n <= 0 || len(res) < n {
+		select {
+		case r := <-results:
+			res = append(res, r)
+		case <-ctx.Done():
+			return res, gatherError(ctx, service, n, len(res))
+		case <-m.closed:
+			return res, ErrClosed
+		}
+	}
+	return res, nil
+}
+
+// Reply starts a replier of service, repliers of the same service compete for requests.
+func (m *Memory) Reply(service string, callback ReplyCallback) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClosed() {
+		return ErrClosed
+	}
+	ch := m.service(service)
+	r := newReplier(callback)
+	m.repliers[service] = append(m.repliers[service], r)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer close(r.exited)
+		for {
+			select {
+			case req := <-ch:
+				if req.ctx.Err() != nil {
+					continue
+				}
+				body, err := callback(service, req.body)
+				req.result <- Response{Body: body, Err: remoteError(err)}
+			case <-r.stop:
+				return
+			case <-m.closed:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// UnReply stops replier and waits for requests being handled, it must not be called in callback.
+func (m *Memory) UnReply(service string, callback ReplyCallback) error {
+	m.mu.Lock()
+	rp, err := removeReplier(m.repliers, service, callback)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	rp.wait()
+	return nil
+}
+
+// Close stops all subscribers and repliers, and drops queued messages.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	if m.isClosed() {
+		m.mu.Unlock()
+		return nil
+	}
+	close(m.closed)
+	m.queues = nil
+	m.mu.Unlock()
+	m.subs.closeAll()
+	m.wg.Wait()
+	return nil
+}
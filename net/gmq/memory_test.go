@@ -0,0 +1,16 @@
+package gmq_test
+
+import (
+	"github.com/cryptowilliam/goutil/net/gmq"
+	"github.com/cryptowilliam/goutil/net/gmq/gmqtest"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	gmqtest.Run(t, func(t *testing.T) gmq.MQ {
+		m := gmq.NewMemory(gmq.Options{RequestTimeout: 500 * time.Millisecond})
+		t.Cleanup(func() { m.Close() })
+		return m
+	})
+}
@@ -1,9 +1,28 @@
 package gmq
 
+import (
+	"context"
+	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"reflect"
+	"sync"
+	"time"
+	"unsafe"
+)
+
 type (
-	SubCallback   func(subj string, b []byte)
-	ReplyCallback func(service string) ([]byte, error)
+	SubCallback func(subj string, b []byte)
 
+	// ReplyCallback handles request b of service, returned error is sent back to requester.
+	ReplyCallback func(service string, b []byte) ([]byte, error)
+
+	// MQ is message queue with three patterns:
+	// Pub/Sub broadcasts message to all subscribers of subject, messages from one publisher are delivered in order.
+	// Push/Pop is a FIFO queue, Pop doesn't wait and returns ErrEmpty if queue is empty.
+	// Request/Reply sends request to one of repliers of service and waits for reply, or gathers replies of all
+	// repliers. Requests wait until ctx is done, or Options.RequestTimeout if ctx has no deadline, and return
+	// ErrTimeout if deadline exceeded. Error returned by ReplyCallback is returned to requester as gerrors value,
+	// its message and registered code are kept.
 	MQ interface {
 		Pub(subj string, b []byte) error
 		Sub(subj string, callback SubCallback) error
@@ -12,8 +31,251 @@ type (
 		Push(queue string, b []byte) error
 		Pop(queue string) ([]byte, error)
 
+		// Request is RequestContext without context, reply is discarded.
 		Request(service string, b []byte) error
+		// RequestContext sends request to one of repliers of service, and returns its reply.
+		RequestContext(ctx context.Context, service string, b []byte) ([]byte, error)
+		// Gather sends request to all repliers of service, and collects replies until n replies received or ctx is
+		// done, n <= 0 means collecting until ctx is done. If fewer than n replies received, received replies are
+		// returned with ErrTimeout.
+		Gather(ctx context.Context, service string, b []byte, n int) ([]Response, error)
 		Reply(service string, callback ReplyCallback) error
 		UnReply(service string, callback ReplyCallback) error
 	}
+
+	// Response is one reply collected by Gather.
+	Response struct {
+		Body []byte
+		Err  error
+	}
+
+	Options struct {
+		Prefix         string        // Prefix of keys and channels in external broker, default is "gmq:".
+		RequestTimeout time.Duration // Default is 10 seconds.
+	}
+
+	replier struct {
+		callback ReplyCallback
+		gather   SubCallback // Handler of broadcast requests, for brokers which broadcast them by pub/sub.
+		stop     chan struct{}
+		exited   chan struct{}  // Closed when replier goroutine exits.
+		mu       sync.Mutex     // Guards closing stop and adding calls.
+		calls    sync.WaitGroup // Callbacks of broadcast requests in progress.
+	}
+
+	// subscriber calls callback in its own goroutine, so slow callback doesn't block others.
+	subscriber struct {
+		callback SubCallback
+		ch       chan []byte
+		done     chan struct{}
+	}
+)
+
+var (
+	ErrEmpty    = gerrors.New("gmq: queue is empty")
+	ErrClosed   = gerrors.New("gmq: closed")
+	ErrTimeout  = gerrors.CodeDeadlineExceeded.New("gmq: request timeout")
+	ErrNotFound = gerrors.New("gmq: callback not found")
 )
+
+func (o Options) withDefaults() Options {
+	if o.Prefix == "" {
+		o.Prefix = "gmq:"
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = 10 * time.Second
+	}
+	return o
+}
+
+// requestContext applies default request timeout to ctx.
+func (o Options) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.RequestTimeout)
+}
+
+// ctxError converts error of done ctx, deadline exceeded is reported as ErrTimeout.
+func ctxError(ctx context.Context, service string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return gerrors.Wrap(ErrTimeout, service)
+	}
+	return ctx.Err()
+}
+
+// gatherError returns ErrTimeout if fewer than n replies received before ctx is done.
+func gatherError(ctx context.Context, service string, n, received int) error {
+	if ctx.Err() == context.Canceled {
+		return ctx.Err()
+	}
+	if n > 0 && received < n {
+		return gerrors.Wrap(ErrTimeout, fmt.Sprintf("%s: %d of %d replies received", service, received, n))
+	}
+	return nil
+}
+
+// remoteError converts error of ReplyCallback to what requester receives, it is the same for all backends.
+func remoteError(err error) error {
+	return gerrors.FromPayload(gerrors.ToPayload(err, false))
+}
+
+// funcIndex returns index of f in funcs, or -1 if not found. Func values are not comparable in Go,
+// so f is matched by identity of func value first, then by function code. Method values and closures are
+// created again on each evaluation, they are matched by function code, the first registered one is returned.
+func funcIndex(funcs []interface{}, f interface{}) int {
+	code := reflect.ValueOf(f).Pointer()
+	res := -1
+	for i, fn := range funcs {
+		if funcValue(fn) == funcValue(f) {
+			return i
+		}
+		if res < 0 && reflect.ValueOf(fn).Pointer() == code {
+			res = i
+		}
+	}
+	return res
+}
+
+// funcValue returns address of func value, closures created by the same func literal get different addresses.
+func funcValue(f interface{}) uintptr {
+	switch cb := f.(type) {
+	case SubCallback:
+		return *(*uintptr)(unsafe.Pointer(&cb))
+	case ReplyCallback:
+		return *(*uintptr)(unsafe.Pointer(&cb))
+	}
+	return 0
+}
+
+func newSubscriber(subj string, callback SubCallback) *subscriber {
+	s := &subscriber{callback: callback, ch: make(chan []byte, 1024), done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case b := <-s.ch:
+				callback(subj, b)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *subscriber) deliver(b []byte) {
+	select {
+	case s.ch <- b:
+	case <-s.done:
+	}
+}
+
+func (s *subscriber) stop() {
+	close(s.done)
+}
+
+// subscribers is a set of subscribers grouped by subject.
+type subscribers struct {
+	mu   sync.RWMutex
+	subs map[string][]*subscriber
+}
+
+// add returns true if it is the first subscriber of subj.
+func (ss *subscribers) add(subj string, callback SubCallback) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.subs == nil {
+		ss.subs = map[string][]*subscriber{}
+	}
+	ss.subs[subj] = append(ss.subs[subj], newSubscriber(subj, callback))
+	return len(ss.subs[subj]) == 1
+}
+
+// remove returns true if no subscriber of subj left.
+func (ss *subscribers) remove(subj string, callback SubCallback) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	list := ss.subs[subj]
+	funcs := make([]interface{}, len(list))
+	for i, s := range list {
+		funcs[i] = s.callback
+	}
+	i := funcIndex(funcs, callback)
+	if i < 0 {
+		return false, gerrors.Wrap(ErrNotFound, subj)
+	}
+	list[i].stop()
+	list = append(list[:i:i], list[i+1:]...)
+	if len(list) == 0 {
+		delete(ss.subs, subj)
+		return true, nil
+	}
+	ss.subs[subj] = list
+	return false, nil
+}
+
+func (ss *subscribers) publish(subj string, b []byte) {
+	ss.mu.RLock()
+	list := ss.subs[subj]
+	ss.mu.RUnlock()
+	for _, s := range list {
+		s.deliver(b)
+	}
+}
+
+func (ss *subscribers) closeAll() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, list := range ss.subs {
+		for _, s := range list {
+			s.stop()
+		}
+	}
+	ss.subs = nil
+}
+
+func newReplier(callback ReplyCallback) *replier {
+	return &replier{callback: callback, stop: make(chan struct{}), exited: make(chan struct{})}
+}
+
+// enter returns false if replier is stopped, otherwise caller must call r.calls.Done after callback returns.
+func (r *replier) enter() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.stop:
+		return false
+	default:
+	}
+	r.calls.Add(1)
+	return true
+}
+
+// wait waits for replier goroutine and callbacks in progress after replier is stopped.
+func (r *replier) wait() {
+	<-r.exited
+	r.calls.Wait()
+}
+
+// removeReplier removes and stops replier of callback from repliers.
+func removeReplier(repliers map[string][]*replier, service string, callback ReplyCallback) (*replier, error) {
+	list := repliers[service]
+	funcs := make([]interface{}, len(list))
+	for i, r := range list {
+		funcs[i] = r.callback
+	}
+	i := funcIndex(funcs, callback)
+	if i < 0 {
+		return nil, gerrors.Wrap(ErrNotFound, service)
+	}
+	r := list[i]
+	r.mu.Lock()
+	close(r.stop)
+	r.mu.Unlock()
+	if len(list) == 1 {
+		delete(repliers, service)
+	} else {
+		repliers[service] = append(list[:i:i], list[i+1:]...)
+	}
+	return r, nil
+}
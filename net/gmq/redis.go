@@ -0,0 +1,400 @@
+package gmq
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/database/gredis"
+	"github.com/cryptowilliam/goutil/dsa/guuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Redis is an MQ backed by Redis.
+	// Pub/Sub uses Redis pub/sub on channel "<prefix>sub:<subj>", Push/Pop uses list "<prefix>q:<queue>".
+	// Requests are pushed to list "<prefix>req:<service>", and Gather requests are published to channel
+	// "<prefix>gather:<service>". Replies are pushed to list "<prefix>rep:<id>" where id is correlation ID of request.
+	Redis struct {
+		opts     Options
+		client   *gredis.Client
+		subs     subscribers // Subscribers of Sub.
+		gathers  subscribers // Handlers of Gather requests, grouped by service.
+		mu       sync.Mutex
+		ps       *gredis.PubSub // Created by the first subscription.
+		repliers map[string][]*replier
+		closed   chan struct{}
+		wg       sync.WaitGroup
+	}
+
+	// envelope is the wire format of requests and replies.
+	envelope struct {
+		ID       string              `json:"id"`
+		ReplyTo  string              `json:"reply_to,omitempty"`
+		Deadline int64               `json:"deadline,omitempty"` // Unix nanoseconds.
+		Body     []byte              `json:"body,omitempty"`
+		Err      *gerrors.ErrPayload `json:"err,omitempty"`
+	}
+)
+
+// pollInterval is the BLPOP timeout of repliers.
+const pollInterval = time.Second
+
+// NewRedis creates MQ on client, client is not closed by Redis.Close.
+func NewRedis(client *gredis.Client, opts Options) *Redis {
+	return &Redis{
+		opts:     opts.withDefaults(),
+		client:   client,
+		repliers: map[string][]*replier{},
+		closed:   make(chan struct{}),
+	}
+}
+
+func (r *Redis) isClosed() bool {
+	select {
+	case <-r.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ctx returns context of parent that is also canceled when r is closed or stop is closed, stop can be nil.
+func (r *Redis) ctx(parent context.Context, stop <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-r.closed:
+			cancel()
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (r *Redis) Pub(subj string, b []byte) error {
+	if r.isClosed() {
+		return ErrClosed
+	}
+	_, err := r.client.Publish(context.Background(), r.opts.Prefix+"sub:"+subj, b)
+	return err
+}
+
+func (r *Redis) onMessage(channel string, message []byte) {
+	if subj := strings.TrimPrefix(channel, r.opts.Prefix+"sub:"); subj != channel {
+		r.subs.publish(subj, message)
+	} else if service := strings.TrimPrefix(channel, r.opts.Prefix+"gather:"); service != channel {
+		r.gathers.publish(service, message)
+	}
+}
+
+// subscribe adds callback of name to ss, and subscribes channel if it is the first one, r.mu must be locked.
+// All subscriptions share one connection.
+func (r *Redis) subscribe(ss *subscribers, channel, name string, callback SubCallback) error {
+	if r.ps == nil {
+		ps, err := r.client.NewPubSub(context.Background(), r.onMessage)
+		if err != nil {
+			return err
+		}
+		r.ps = ps
+	}
+	if ss.add(name, callback) {
+		if err := r.ps.Subscribe(channel); err != nil {
+			ss.remove(name, callback)
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribe removes callback of name from ss, and unsubscribes channel if it is the last one, r.mu must be locked.
+func (r *Redis) unsubscribe(ss *subscribers, channel, name string, callback SubCallback) error {
+	last, err := ss.remove(name, callback)
+	if err != nil {
+		return err
+	}
+	if last && r.ps != nil {
+		return r.ps.Unsubscribe(channel)
+	}
+	return nil
+}
+
+func (r *Redis) Sub(subj string, callback SubCallback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.isClosed() {
+		return ErrClosed
+	}
+	return r.subscribe(&r.subs, r.opts.Prefix+"sub:"+subj, subj, callback)
+}
+
+func (r *Redis) UnSub(subj string, callback SubCallback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unsubscribe(&r.subs, r.opts.Prefix+"sub:"+subj, subj, callback)
+}
+
+func (r *Redis) Push(queue string, b []byte) error {
+	if r.isClosed() {
+		return ErrClosed
+	}
+	_, err := r.client.RPush(context.Background(), r.opts.Prefix+"q:"+queue, b)
+	return err
+}
+
+func (r *Redis) Pop(queue string) ([]byte, error) {
+	if r.isClosed() {
+		return nil, ErrClosed
+	}
+	b, err := r.client.LPop(context.Background(), r.opts.Prefix+"q:"+queue)
+	if err == gredis.ErrNil {
+		return nil, ErrEmpty
+	}
+	return b, err
+}
+
+func (r *Redis) Request(service string, b []byte) error {
+	_, err := r.RequestContext(context.Background(), service, b)
+	return err
+}
+
+// newRequest creates request envelope with new correlation ID, ctx must have deadline.
+func (r *Redis) newRequest(ctx context.Context, b []byte) (envelope, []byte, error) {
+	deadline, _ := ctx.Deadline()
+	id := guuid.NewString(false, false)
+	req := envelope{ID: id, ReplyTo: r.opts.Prefix + "rep:" + id, Deadline: deadline.UnixNano(), Body: b}
+	data, err := json.Marshal(req)
+	return req, data, err
+}
+
+// waitReply waits for next reply of req until ctx is done, ctx must have deadline.
+func (r *Redis) waitReply(ctx context.Context, service string, req envelope) (Response, error) {
+	deadline, _ := ctx.Deadline()
+	wait := time.Until(deadline)
+	if wait < time.Millisecond {
+		wait = time.Millisecond // 0 means waiting forever.
+	}
+	_, val, err := r.client.BLPop(ctx, wait, req.ReplyTo)
+	if err == gredis.ErrNil {
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		return Response{}, r.requestError(ctx, service, err)
+	}
+	var rep envelope
+	if err := json.Unmarshal(val, &rep); err != nil {
+		return Response{}, gerrors.Wrap(err, "gmq: invalid reply")
+	}
+	if rep.ID != req.ID {
+		return Response{}, gerrors.Errorf("gmq: reply ID %s mismatch request ID %s", rep.ID, req.ID)
+	}
+	return Response{Body: rep.Body, Err: gerrors.FromPayload(rep.Err)}, nil
+}
+
+// requestError converts error of client during request, errors of ctx are reported like other backends.
+func (r *Redis) requestError(ctx context.Context, service string, err error) error {
+	if err != context.DeadlineExceeded && err != context.Canceled {
+		return err
+	}
+	if r.isClosed() {
+		return ErrClosed
+	}
+	// Server side or network timeout may be a little earlier than ctx.
+	<-ctx.Done()
+	return ctxError(ctx, service)
+}
+
+func (r *Redis) RequestContext(ctx context.Context, service string, b []byte) ([]byte, error) {
+	if r.isClosed() {
+		return nil, ErrClosed
+	}
+	ctx, cancel := r.opts.requestContext(ctx)
+	defer cancel()
+	ctx, cancelClose := r.ctx(ctx, nil)
+	defer cancelClose()
+
+	req, data, err := r.newRequest(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.client.RPush(ctx, r.opts.Prefix+"req:"+service, data); err != nil {
+		return nil, r.requestError(ctx, service, err)
+	}
+	res, err := r.waitReply(ctx, service, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, res.Err
+}
+
+func (r *Redis) Gather(ctx context.Context, service string, b []byte, n int) ([]Response, error) {
+	if r.isClosed() {
+		return nil, ErrClosed
+	}
+	ctx, cancel := r.opts.requestContext(ctx)
+	defer cancel()
+	ctx, cancelClose := r.ctx(ctx, nil)
+	defer cancelClose()
+
+	req, data, err := r.newRequest(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.client.Publish(ctx, r.opts.Prefix+"gather:"+service, data); err != nil {
+		return nil, r.requestError(ctx, service, err)
+	}
+	// Late replies are dropped with reply list.
+	defer r.client.Del(context.Background(), req.ReplyTo)
+
+	var res []Response
+	for n <= 0 || len(res) < n {
+		rep, err := r.waitReply(ctx, service, req)
+		if err != nil {
+			if ctx.Err() != nil && err != ErrClosed {
+				return res, gatherError(ctx, service, n, len(res))
+			}
+			return res, err
+		}
+		res = append(res, rep)
+	}
+	return res, nil
+}
+
+// Reply starts a replier of service, repliers of the same service compete for requests.
+func (r *Redis) Reply(service string, callback ReplyCallback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.isClosed() {
+		return ErrClosed
+	}
+	rp := newReplier(callback)
+	ctx, cancel := r.ctx(context.Background(), nil)
+	rp.gather = func(_ string, b []byte) {
+		if !rp.enter() {
+			return
+		}
+		defer rp.calls.Done()
+		r.handle(ctx, service, rp, b)
+	}
+	if err := r.subscribe(&r.gathers, r.opts.Prefix+"gather:"+service, service, rp.gather); err != nil {
+		cancel()
+		return err
+	}
+	r.repliers[service] = append(r.repliers[service], rp)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(rp.exited)
+		defer cancel()
+		r.serve(ctx, service, rp)
+	}()
+	return nil
+}
+
+// serve handles requests until rp is stopped, replies are sent by ctx.
+// Waiting for requests is not interrupted by UnReply or Close, because the request popped by an interrupted BLPOP
+// would be lost, so they wait up to pollInterval. Request popped after rp is stopped is pushed back.
+func (r *Redis) serve(ctx context.Context, service string, rp *replier) {
+	queue := r.opts.Prefix + "req:" + service
+	for {
+		select {
+		case <-rp.stop:
+			return
+		case <-r.closed:
+			return
+		default:
+		}
+
+		_, val, err := r.client.BLPop(context.Background(), pollInterval, queue)
+		if err == gredis.ErrNil {
+			continue
+		}
+		if err != nil {
+			// Wait before retrying on connection error.
+			select {
+			case <-time.After(pollInterval):
+			case <-rp.stop:
+			case <-r.closed:
+			}
+			continue
+		}
+		select {
+		case <-rp.stop:
+		case <-r.closed:
+		default:
+			r.handle(ctx, service, rp, val)
+			continue
+		}
+		// Leave request to other repliers.
+		pushCtx, cancel := context.WithTimeout(context.Background(), pollInterval)
+		r.client.LPush(pushCtx, queue, val)
+		cancel()
+		return
+	}
+}
+
+// handle calls callback with request and sends reply.
+func (r *Redis) handle(ctx context.Context, service string, rp *replier, data []byte) {
+	var req envelope
+	if err := json.Unmarshal(data, &req); err != nil || req.ReplyTo == "" {
+		return
+	}
+	deadline := time.Unix(0, req.Deadline)
+	if req.Deadline > 0 && time.Now().After(deadline) {
+		return
+	}
+
+	body, err := rp.callback(service, req.Body)
+	b, err := json.Marshal(envelope{ID: req.ID, Body: body, Err: gerrors.ToPayload(err, false)})
+	if err != nil {
+		return
+	}
+	r.client.RPush(ctx, req.ReplyTo, b)
+	// Reply list is left behind if requester gave up.
+	expiration := time.Until(deadline)
+	if req.Deadline <= 0 || expiration < time.Second {
+		expiration = time.Second
+	}
+	r.client.Expire(ctx, req.ReplyTo, expiration)
+}
+
+// UnReply stops replier and waits for requests being handled, it must not be called in callback.
+// It may take up to pollInterval to wait for pending BLPOP.
+func (r *Redis) UnReply(service string, callback ReplyCallback) error {
+	r.mu.Lock()
+	rp, err := removeReplier(r.repliers, service, callback)
+	if err == nil {
+		err = r.unsubscribe(&r.gathers, r.opts.Prefix+"gather:"+service, service, rp.gather)
+	}
+	r.mu.Unlock()
+	if rp == nil {
+		return err
+	}
+	rp.wait()
+	return err
+}
+
+// Close stops all subscribers and repliers.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	if r.isClosed() {
+		r.mu.Unlock()
+		return nil
+	}
+	close(r.closed)
+	ps := r.ps
+	r.ps = nil
+	r.mu.Unlock()
+
+	var err error
+	if ps != nil {
+		err = ps.Close()
+	}
+	r.subs.closeAll()
+	r.gathers.closeAll()
+	r.wg.Wait()
+	return err
+}
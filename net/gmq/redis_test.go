@@ -0,0 +1,40 @@
+package gmq_test
+
+import (
+	"context"
+	"github.com/cryptowilliam/goutil/database/gredis"
+	"github.com/cryptowilliam/goutil/database/gredis/gredistest"
+	"github.com/cryptowilliam/goutil/net/gmq"
+	"github.com/cryptowilliam/goutil/net/gmq/gmqtest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRedis runs on in-memory Redis server, and also on real Redis if REDIS_ADDR is set.
+func TestRedis(t *testing.T) {
+	s, err := gredistest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addrs := []string{s.Addr()}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		addrs = append(addrs, addr)
+	}
+
+	for _, addr := range addrs {
+		client, err := gredis.Dial(context.Background(), gredis.Options{Addr: addr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(addr, func(t *testing.T) {
+			gmqtest.Run(t, func(t *testing.T) gmq.MQ {
+				r := gmq.NewRedis(client, gmq.Options{RequestTimeout: 500 * time.Millisecond})
+				t.Cleanup(func() { r.Close() })
+				return r
+			})
+		})
+		client.Close()
+	}
+}
@@ -0,0 +1,319 @@
+package gsecureconn
+
+import (
+	"crypto/aes"
+	stdCipher "crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/crypto/gchacha20"
+	"github.com/cryptowilliam/goutil/crypto/gcrypto"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// AEAD 模式的数据格式, 每个方向独立:
+//
+//	[salt][header chunk][chunk]...
+//	chunk: [加密的 2 字节长度][长度 tag][加密的数据][数据 tag]
+//
+// 密钥由 HKDF-SHA256(密码, salt, 方向) 派生, nonce 是从 0 开始的计数器, 每次加密后加一,
+// 所以数据块被重排, 删除或重放都会导致认证失败. header chunk 中是发送时间, 接收方拒绝时间相差超过
+// replayWindow 的连接, 并记录 replayWindow 内见过的 salt, 拒绝重复的 salt, 以防止整个连接被重放.
+// 两个方向的密钥不同, 所以数据被反射回发送方也会认证失败.
+
+const (
+	saltSize       = 32
+	maxPayloadSize = 0x3FFF
+	headerSize     = 8
+	replayWindow   = 2 * time.Minute
+)
+
+var (
+	ErrReplay = gerrors.New("gsecureconn: replayed or expired connection")
+	ErrAuth   = gerrors.New("gsecureconn: message authentication failed")
+)
+
+type (
+	// 记录最近见过的 salt
+	saltFilter struct {
+		mu     sync.Mutex
+		seen   map[string]time.Time
+		pruned time.Time
+	}
+
+	// AEAD 模式的加密 Socket
+	aeadConn struct {
+		net.Conn
+		cipher *Cipher
+		server bool // 由 Listener 接受的连接
+
+		rmu    sync.Mutex
+		reader stdCipher.AEAD
+		rnonce []byte
+		rsalt  []byte // 已读取但 header 还没有验证的 salt
+		rready bool   // salt 和 header 已验证
+		rraw   []byte // 数据块中已读取的部分, 读取超时后下次继续读取
+		rsize  int    // 已解密的数据长度, -1 表示还没有读取长度
+		rbuf   []byte // 已解密未读取的数据
+		rerr   error
+
+		wmu    sync.Mutex
+		writer stdCipher.AEAD
+		wnonce []byte
+	}
+)
+
+func newSaltFilter() *saltFilter {
+	return &saltFilter{seen: map[string]time.Time{}}
+}
+
+// 记录 salt, 如果 salt 已经见过返回 false
+func (f *saltFilter) add(salt []byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	// 超过两倍 replayWindow 的 salt 所在的连接会因为时间被拒绝, 不需要再记录
+	if now.Sub(f.pruned) > replayWindow {
+		for k, t := range f.seen {
+			if now.Sub(t) > 2*replayWindow {
+				delete(f.seen, k)
+			}
+		}
+		f.pruned = now
+	}
+	if _, ok := f.seen[string(salt)]; ok {
+		return false
+	}
+	f.seen[string(salt)] = now
+	return true
+}
+
+func keySize(method gcrypto.Cipher) (int, error) {
+	switch method {
+	case gcrypto.CipherAES128GCM:
+		return 16, nil
+	case gcrypto.CipherAES256GCM, gcrypto.CipherChaCha20IETFPoly1305, gcrypto.CipherXChaCha20Poly1305:
+		return 32, nil
+	}
+	return 0, gerrors.New("gsecureconn: unsupported cipher '%s'", method)
+}
+
+// 用 salt 派生 direction 方向的密钥并创建 AEAD
+func (cipher *Cipher) newAEAD(salt []byte, direction string) (stdCipher.AEAD, error) {
+	size, err := keySize(cipher.method)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, size)
+	kdf := hkdf.New(sha256.New, cipher.secret, salt, []byte("gsecureconn "+string(cipher.method)+" "+direction))
+	if _, err := io.ReadFull(kdf, key); err != nil {
+		return nil, err
+	}
+	switch cipher.method {
+	case gcrypto.CipherAES128GCM, gcrypto.CipherAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return stdCipher.NewGCM(block)
+	}
+	return gchacha20.NewAEAD(key, cipher.method)
+}
+
+func newAEADConn(conn net.Conn, cipher *Cipher, server bool) *aeadConn {
+	return &aeadConn{Conn: conn, cipher: cipher, server: server, rsize: -1}
+}
+
+// 客户端到服务端和服务端到客户端的方向
+func (c *aeadConn) directions() (write, read string) {
+	if c.server {
+		return "s2c", "c2s"
+	}
+	return "c2s", "s2c"
+}
+
+// nonce 按小端序加一
+func increment(nonce []byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
+
+// 把 payload 加密为一个数据块追加到 dst
+func (c *aeadConn) seal(dst, payload []byte) []byte {
+	var size [2]byte
+	binary.BigEndian.PutUint16(size[:], uint16(len(payload)))
+	dst = c.writer.Seal(dst, c.wnonce, size[:], nil)
+	increment(c.wnonce)
+	dst = c.writer.Seal(dst, c.wnonce, payload, nil)
+	increment(c.wnonce)
+	return dst
+}
+
+// 把 b 加密后全部写入输出流, 第一次写入时先发送 salt 和 header
+func (c *aeadConn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	var buf []byte
+	if c.writer == nil {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return 0, err
+		}
+		direction, _ := c.directions()
+		writer, err := c.cipher.newAEAD(salt, direction)
+		if err != nil {
+			return 0, err
+		}
+		c.writer, c.wnonce = writer, make([]byte, writer.NonceSize())
+		var header [headerSize]byte
+		binary.BigEndian.PutUint64(header[:], uint64(time.Now().Unix()))
+		buf = c.seal(append(buf, salt...), header[:])
+	}
+
+	for p := b; len(p) > 0; {
+		n := len(p)
+		if n > maxPayloadSize {
+			n = maxPayloadSize
+		}
+		buf = c.seal(buf, p[:n])
+		p = p[n:]
+	}
+	if _, err := c.Conn.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// 读取 n 字节原始数据, 出错时已读取的部分保留在 rraw 中, 下次调用继续读取
+func (c *aeadConn) fill(n int) ([]byte, error) {
+	if cap(c.rraw) < n {
+		c.rraw = append(make([]byte, 0, n), c.rraw...)
+	}
+	for len(c.rraw) < n {
+		m, err := c.Conn.Read(c.rraw[len(c.rraw):n])
+		c.rraw = c.rraw[:len(c.rraw)+m]
+		if err != nil && len(c.rraw) < n {
+			if err == io.EOF && len(c.rraw) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	data := c.rraw
+	c.rraw = nil
+	return data, nil
+}
+
+// 读取并解密一个数据块
+func (c *aeadConn) open() ([]byte, error) {
+	overhead := c.reader.Overhead()
+	if c.rsize < 0 {
+		buf, err := c.fill(2 + overhead)
+		if err != nil {
+			return nil, err
+		}
+		size, err := c.reader.Open(buf[:0], c.rnonce, buf, nil)
+		if err != nil {
+			return nil, ErrAuth
+		}
+		increment(c.rnonce)
+		if c.rsize = int(binary.BigEndian.Uint16(size)); c.rsize > maxPayloadSize {
+			return nil, ErrAuth
+		}
+	}
+	buf, err := c.fill(c.rsize + overhead)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	c.rsize = -1
+	payload, err := c.reader.Open(buf[:0], c.rnonce, buf, nil)
+	if err != nil {
+		return nil, ErrAuth
+	}
+	increment(c.rnonce)
+	return payload, nil
+}
+
+// 读取 salt 和 header, 并检查重放
+func (c *aeadConn) handshake() error {
+	if c.reader == nil {
+		salt, err := c.fill(saltSize)
+		if err != nil {
+			return err
+		}
+		_, direction := c.directions()
+		reader, err := c.cipher.newAEAD(salt, direction)
+		if err != nil {
+			return err
+		}
+		c.reader, c.rnonce, c.rsalt = reader, make([]byte, reader.NonceSize()), salt
+	}
+	header, err := c.open()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if len(header) != headerSize {
+		return ErrAuth
+	}
+	sent := time.Unix(int64(binary.BigEndian.Uint64(header)), 0)
+	if d := time.Since(sent); d > replayWindow || d < -replayWindow {
+		return ErrReplay
+	}
+	if !c.cipher.salts.add(c.rsalt) {
+		return ErrReplay
+	}
+	c.rsalt, c.rready = nil, true
+	return nil
+}
+
+// 从输入流里读取加密过的数据块, 解密后把原数据放到 b 里
+func (c *aeadConn) Read(b []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	if c.rerr != nil {
+		return 0, c.rerr
+	}
+	if !c.rready {
+		if err := c.handshake(); err != nil {
+			c.setReadErr(err)
+			return 0, err
+		}
+	}
+	for len(c.rbuf) == 0 {
+		payload, err := c.open()
+		if err != nil {
+			c.setReadErr(err)
+			return 0, err
+		}
+		c.rbuf = payload
+	}
+	n := copy(b, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+// 超时的读取可以重试, 已读取的部分数据块保存在 rraw 中; 其他错误后数据流无法恢复
+func (c *aeadConn) setReadErr(err error) {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return
+	}
+	c.rerr = err
+}
+
+// 数据块读到一半时的 EOF 说明数据被截断
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
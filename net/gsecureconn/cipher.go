@@ -1,10 +1,41 @@
 package gsecureconn
 
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/crypto/gcrypto"
+)
+
+// 连接的编码解码器.
+// NewCipher 创建的字节替换表没有完整性校验, 也会泄露数据特征, 新的使用者应使用 NewAEADCipher.
 type Cipher struct {
 	// 编码用的密码
 	encodePassword *password
 	// 解码用的密码
 	decodePassword *password
+
+	// AEAD 模式的算法, 为空时使用字节替换表
+	method gcrypto.Cipher
+	// AEAD 模式下派生每个连接密钥用的密码
+	secret []byte
+	// AEAD 模式下见过的 salt, 用于拒绝重放
+	salts *saltFilter
+}
+
+// 新建一个 AEAD 模式的编码解码器, method 支持 aes-128-gcm, aes-256-gcm, chacha20-ietf-poly1305, xchacha20-poly1305.
+// 每个连接的每个方向都使用随机 salt 和 HKDF 从密码派生独立的密钥.
+func NewAEADCipher(password string, method gcrypto.Cipher) (*Cipher, error) {
+	if password == "" {
+		return nil, gerrors.New("gsecureconn: password is required")
+	}
+	if _, err := keySize(method); err != nil {
+		return nil, err
+	}
+	return &Cipher{method: method, secret: []byte(password), salts: newSaltFilter()}, nil
+}
+
+// 是否为 AEAD 模式
+func (cipher *Cipher) IsAEAD() bool {
+	return cipher.method != ""
 }
 
 // 加密原数据
@@ -31,14 +31,29 @@ type SecureConn struct {
 }
 
 // see net.DialTCP
+// cipher 由 NewAEADCipher 创建时使用 AEAD 模式, 否则使用字节替换表.
+// WrapConn 用于主动连接的一方, 接受连接的一方要用 WrapServerConn 或 WrapListener,
+// 否则 AEAD 模式下两端的密钥方向相同, 无法通信.
 func WrapConn(rawConn net.Conn, cipher *Cipher) (net.Conn, error) {
+	return wrapConn(rawConn, cipher, false), nil
+}
+
+// WrapServerConn 用于包装接受的连接, 对端要用 WrapConn 包装.
+func WrapServerConn(rawConn net.Conn, cipher *Cipher) (net.Conn, error) {
+	return wrapConn(rawConn, cipher, true), nil
+}
+
+func wrapConn(rawConn net.Conn, cipher *Cipher, server bool) net.Conn {
 	// Conn被关闭时直接清除所有数据 不管没有发送的数据
 	//rawConn.SetLinger(0)
 
+	if cipher.IsAEAD() {
+		return newAEADConn(rawConn, cipher, server)
+	}
 	return &SecureConn{
 		conn:   rawConn,
 		cipher: cipher,
-	}, nil
+	}
 }
 
 // 从输入流里读取加密过的数据，解密后把原数据放到bs里
@@ -53,8 +68,10 @@ func (c *SecureConn) Read(b []byte) (n int, err error) {
 
 // 把放在bs里的数据加密后立即全部写入输出流
 func (c *SecureConn) Write(b []byte) (int, error) {
-	c.cipher.Encode(b)
-	return c.Write(b)
+	// 不能修改调用者的数据
+	buf := append([]byte{}, b...)
+	c.cipher.Encode(buf)
+	return c.conn.Write(buf)
 }
 
 func (c *SecureConn) LocalAddr() net.Addr {
@@ -1,9 +1,185 @@
 package gsecureconn
 
 import (
+	"bytes"
+	"crypto/rand"
+	"github.com/cryptowilliam/goutil/crypto/gcrypto"
+	"io"
+	"net"
 	"testing"
 )
 
+// bufConn is a net.Conn reading from and writing to buffer.
+type bufConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *bufConn) Read(b []byte) (int, error)  { return c.buf.Read(b) }
+func (c *bufConn) Write(b []byte) (int, error) { return c.buf.Write(b) }
+
+// slowConn reads at most 3 bytes at a time, and every other read times out.
+type slowConn struct {
+	bufConn
+	reads int
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func (c *slowConn) Read(b []byte) (int, error) {
+	c.reads++
+	if c.reads%2 == 0 {
+		return 0, timeoutErr{}
+	}
+	if len(b) > 3 {
+		b = b[:3]
+	}
+	return c.buf.Read(b)
+}
+
 func TestListenEncryptedTCP(t *testing.T) {
+	methods := []gcrypto.Cipher{gcrypto.CipherAES128GCM, gcrypto.CipherAES256GCM,
+		gcrypto.CipherChaCha20IETFPoly1305, gcrypto.CipherXChaCha20Poly1305}
+	for _, method := range methods {
+		cipher, err := NewAEADCipher("password", method)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lis = WrapListener(lis, cipher)
+		go func() {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+
+		raw, err := net.Dial("tcp", lis.Addr().String())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn, _ := WrapConn(raw, cipher)
+		data := make([]byte, 3*maxPayloadSize+100)
+		rand.Read(data)
+		go conn.Write(data)
+		echo := make([]byte, len(data))
+		if _, err := io.ReadFull(conn, echo); err != nil {
+			t.Errorf("%s: %v", method, err)
+			return
+		}
+		if !bytes.Equal(echo, data) {
+			t.Errorf("%s: echo mismatch", method)
+			return
+		}
+		conn.Close()
+		lis.Close()
+	}
+}
+
+func TestAEADConn(t *testing.T) {
+	cipher, err := NewAEADCipher("password", gcrypto.CipherChaCha20IETFPoly1305)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := NewAEADCipher("password", "rot13"); err == nil {
+		t.Error("unsupported cipher should fail")
+		return
+	}
+	sent := &bufConn{}
+	w, _ := WrapConn(sent, cipher)
+	w.Write([]byte("hello "))
+	w.Write([]byte("world"))
+	stream := append([]byte{}, sent.buf.Bytes()...)
+	if bytes.Contains(stream, []byte("hello")) {
+		t.Error("data is not encrypted")
+		return
+	}
+
+	receiver, _ := NewAEADCipher("password", gcrypto.CipherChaCha20IETFPoly1305)
+	read := func(cipher *Cipher, stream []byte) (string, error) {
+		conn, _ := WrapServerConn(&bufConn{buf: *bytes.NewBuffer(append([]byte{}, stream...))}, cipher)
+		b, err := io.ReadAll(conn)
+		return string(b), err
+	}
+	if s, err := read(receiver, stream); err != nil || s != "hello world" {
+		t.Errorf("read %q %v", s, err)
+		return
+	}
+	if _, err := read(receiver, stream); err != ErrReplay {
+		t.Errorf("replayed stream returns %v, expect ErrReplay", err)
+		return
+	}
+	// Stream sent by client is reflected back to client.
+	reflected, _ := WrapConn(&bufConn{buf: *bytes.NewBuffer(append([]byte{}, stream...))}, cipher)
+	if _, err := io.ReadAll(reflected); err != ErrAuth {
+		t.Errorf("reflected stream returns %v, expect ErrAuth", err)
+		return
+	}
+
+	fresh := func() *Cipher {
+		c, _ := NewAEADCipher("password", gcrypto.CipherChaCha20IETFPoly1305)
+		return c
+	}
+	tampered := append([]byte{}, stream...)
+	tampered[len(tampered)-1] ^= 1
+	if s, err := read(fresh(), tampered); err != ErrAuth || s != "hello " {
+		t.Errorf("tampered stream returns %q %v, expect ErrAuth after first chunk", s, err)
+		return
+	}
+	if _, err := read(fresh(), stream[:len(stream)-3]); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated stream returns %v, expect io.ErrUnexpectedEOF", err)
+		return
+	}
+	wrong, _ := NewAEADCipher("wrong password", gcrypto.CipherChaCha20IETFPoly1305)
+	if _, err := read(wrong, stream); err != ErrAuth {
+		t.Errorf("wrong password returns %v, expect ErrAuth", err)
+	}
+}
+
+func TestAEADConn_ReadTimeout(t *testing.T) {
+	sender, _ := NewAEADCipher("password", gcrypto.CipherAES256GCM)
+	sent := &bufConn{}
+	w, _ := WrapConn(sent, sender)
+	w.Write([]byte("hello world"))
 
+	receiver, _ := NewAEADCipher("password", gcrypto.CipherAES256GCM)
+	conn := newAEADConn(&slowConn{bufConn: bufConn{buf: sent.buf}}, receiver, true)
+	var got []byte
+	b := make([]byte, 4)
+	for timeouts := 0; ; {
+		n, err := conn.Read(b)
+		got = append(got, b[:n]...)
+		if err == nil {
+			continue
+		}
+		if ne, ok := err.(net.Error); ok && ne.Timeout() && timeouts < 1000 {
+			timeouts++
+			continue
+		}
+		if err != io.EOF {
+			t.Errorf("read returns %v after %d timeouts", err, timeouts)
+			return
+		}
+		if timeouts == 0 {
+			t.Error("expect read timeouts")
+			return
+		}
+		break
+	}
+	if string(got) != "hello world" {
+		t.Errorf("read %q after timeouts", got)
+	}
 }
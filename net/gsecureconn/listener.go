@@ -22,12 +22,7 @@ func (l *Listener) Accept() (net.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	// localConn被关闭时直接清除所有数据 不管没有发送的数据
-	//localConn.SetLinger(0)
-	return &SecureConn{
-		conn:   localConn,
-		cipher: l.cipher,
-	}, nil
+	return wrapConn(localConn, l.cipher, true), nil
 }
 
 func (l *Listener) Addr() net.Addr {
@@ -35,5 +30,5 @@ func (l *Listener) Addr() net.Addr {
 }
 
 func (l *Listener) Close() error {
-	return l.Close()
+	return l.lis.Close()
 }
@@ -0,0 +1,318 @@
+package gweb
+
+import (
+	"encoding"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tags of request struct fields, field is filled from the source named by its tag:
+//
+//	uri:"id"        path parameter ":id"
+//	form:"page"     query parameter "page", or field "page" of form body, like gin
+//	header:"X-Key"  header "X-Key"
+//	json:"name"     field of JSON body
+//	binding:"..."   validation rules of github.com/go-playground/validator, e.g. binding:"required,min=1"
+const (
+	tagUri    = "uri"
+	tagForm   = "form"
+	tagHeader = "header"
+
+	maxMultipartMemory = 32 << 20
+)
+
+// paramSource gets values of request parameter by key in tag.
+type paramSource struct {
+	tag string
+	get func(key string) []string
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Bind fills struct pointed by v from body, path, query and header of request, then validates it.
+// Body is decoded by Content-Type: JSON body for "application/json", "*+json" or empty Content-Type,
+// and form body for "application/x-www-form-urlencoded" or "multipart/form-data", which fills fields with form tag.
+// Fields with uri, form or header tag are filled only from their parameters, JSON body doesn't overwrite them.
+// Errors are coded as gerrors.CodeInvalidArgument, so Ctx.Error responds them as 400.
+func (c Ctx) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return gerrors.New("gweb: Bind requires non-nil pointer to struct, got %T", v)
+	}
+
+	req := c.ctx.Request
+	form := req.URL.Query()
+	if req.Body != nil && req.Body != http.NoBody {
+		switch ct := c.ctx.ContentType(); {
+		case ct == "" || ct == binding.MIMEJSON || strings.HasSuffix(ct, "+json"):
+			saved := copyStruct(rv.Elem(), map[reflect.Type]bool{})
+			err := json.NewDecoder(req.Body).Decode(v)
+			if err != nil && err != io.EOF {
+				return gerrors.CodeInvalidArgument.Wrap(err, "invalid JSON body")
+			}
+			restoreParamFields(rv.Elem(), saved, map[reflect.Type]bool{})
+		case ct == binding.MIMEPOSTForm || ct == binding.MIMEMultipartPOSTForm:
+			// Form contains both query and body parameters after parsing.
+			if err := req.ParseMultipartForm(maxMultipartMemory); err != nil && err != http.ErrNotMultipart {
+				return gerrors.CodeInvalidArgument.Wrap(err, "invalid form body")
+			}
+			form = req.Form
+		default:
+			return gerrors.CodeInvalidArgument.New("unsupported Content-Type '%s'", ct)
+		}
+	}
+
+	uri := map[string][]string{}
+	for _, p := range c.ctx.Params {
+		uri[p.Key] = []string{p.Value}
+	}
+	sources := []paramSource{
+		{tagUri, func(key string) []string { return uri[key] }},
+		{tagForm, func(key string) []string { return form[key] }},
+		{tagHeader, func(key string) []string { return req.Header[textproto.CanonicalMIMEHeaderKey(key)] }},
+	}
+	if _, err := bindFields(rv.Elem(), sources, map[reflect.Type]bool{}); err != nil {
+		return gerrors.CodeInvalidArgument.Wrap(err, "")
+	}
+
+	if binding.Validator != nil {
+		if err := binding.Validator.ValidateStruct(v); err != nil {
+			return gerrors.CodeInvalidArgument.Wrap(err, "")
+		}
+	}
+	return nil
+}
+
+// embeddedStruct returns struct type of field if it is embedded struct or pointer to struct without JSON name,
+// whose fields are flattened like encoding/json does.
+func embeddedStruct(field reflect.StructField) (reflect.Type, bool) {
+	if !field.Anonymous || tagName(field, "json") != "" {
+		return nil, false
+	}
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	return ft, ft.Kind() == reflect.Struct
+}
+
+// bindFields sets fields of struct rv which have tag of sources, and returns whether any field is set.
+// Embedded structs are walked through, nil embedded pointer is allocated if any field of it is set.
+// Embedded structs in visiting are skipped, so recursive types end.
+func bindFields(rv reflect.Value, sources []paramSource, visiting map[reflect.Type]bool) (bool, error) {
+	rt := rv.Type()
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	set := false
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		if et, ok := embeddedStruct(field); ok {
+			if visiting[et] {
+				continue
+			}
+			if field.Type.Kind() != reflect.Ptr {
+				ok, err := bindFields(fv, sources, visiting)
+				if err != nil {
+					return set, err
+				}
+				set = set || ok
+				continue
+			}
+			p := fv
+			if fv.IsNil() {
+				if !fv.CanSet() {
+					continue // Unexported pointer can't be allocated, like encoding/json.
+				}
+				p = reflect.New(et)
+			}
+			ok, err := bindFields(p.Elem(), sources, visiting)
+			if err != nil {
+				return set, err
+			}
+			if ok && fv.IsNil() {
+				fv.Set(p)
+			}
+			set = set || ok
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // Unexported.
+		}
+		for _, src := range sources {
+			key := tagName(field, src.tag)
+			if key == "" {
+				continue
+			}
+			vals := src.get(key)
+			if len(vals) == 0 {
+				continue
+			}
+			if err := setValue(fv, vals); err != nil {
+				return set, gerrors.Wrap(err, "invalid "+src.tag+" parameter '"+key+"'")
+			}
+			set = true
+		}
+	}
+	return set, nil
+}
+
+// isParamField returns true if field has uri, form or header tag.
+func isParamField(field reflect.StructField) bool {
+	return tagName(field, tagUri) != "" || tagName(field, tagForm) != "" || tagName(field, tagHeader) != ""
+}
+
+// copyStruct returns copy of struct rv, embedded struct pointers are copied too so decoding into rv doesn't change it.
+func copyStruct(rv reflect.Value, visiting map[reflect.Type]bool) reflect.Value {
+	rt := rv.Type()
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	c := reflect.New(rt).Elem()
+	c.Set(rv)
+	for i := 0; i < rt.NumField(); i++ {
+		et, ok := embeddedStruct(rt.Field(i))
+		if !ok || visiting[et] || rt.Field(i).Type.Kind() != reflect.Ptr || rv.Field(i).IsNil() || !c.Field(i).CanSet() {
+			continue
+		}
+		p := reflect.New(et)
+		p.Elem().Set(copyStruct(rv.Field(i).Elem(), visiting))
+		c.Field(i).Set(p)
+	}
+	return c
+}
+
+// restoreParamFields sets fields of struct rv which have uri, form or header tag back to saved,
+// embedded structs are walked through like bindFields.
+func restoreParamFields(rv, saved reflect.Value, visiting map[reflect.Type]bool) {
+	rt := rv.Type()
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if et, ok := embeddedStruct(field); ok {
+			if visiting[et] {
+				continue
+			}
+			fv, sv := rv.Field(i), saved.Field(i)
+			if field.Type.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+				if sv.IsNil() {
+					sv = reflect.New(et) // Allocated by JSON body, parameters are zero before.
+				}
+				sv = sv.Elem()
+			}
+			restoreParamFields(fv, sv, visiting)
+			continue
+		}
+		if field.PkgPath == "" && isParamField(field) {
+			rv.Field(i).Set(saved.Field(i))
+		}
+	}
+}
+
+// tagName returns name in tag of field, empty if field has no such tag or it is "-".
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// setValue parses vals into v, slice takes all values and others take the first one.
+func setValue(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textUnmarshalerType) && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setString(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setString(v, vals[0])
+}
+
+func setString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return gerrors.New("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Typed adapts fn to HandlerFunc, Req must be struct, request is bound by Ctx.Bind before fn is called,
+// response is written as JSON with status 200, and errors are written by Ctx.Error.
+func Typed[Req, Resp any](fn func(c *Ctx, req *Req) (Resp, error)) HandlerFunc {
+	return func(c *Ctx) {
+		req := new(Req)
+		if err := c.Bind(req); err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.WriteStructJSON(http.StatusOK, resp, nil)
+	}
+}
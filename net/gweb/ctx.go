@@ -2,6 +2,7 @@ package gweb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/cryptowilliam/goutil/basic/gerrors"
@@ -9,14 +10,17 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type (
 	Ctx struct {
 		ctx *gin.Context
+		r   *Router
 	}
 
+	// ErrFormatter formats error into response body.
 	ErrFormatter func(err error) string
 
 	ParamSource string
@@ -94,13 +98,81 @@ func (c Ctx) PrintHead() {
 	}
 }
 
-// TODO Bind似乎可以自动从Header、Uri、Query、Body中解析需要的参数，但测试下来不对
+// Next runs following handlers in chain, it is used in middlewares.
+func (c Ctx) Next() {
+	c.ctx.Next()
+}
+
+// Abort prevents following handlers from being called, handlers before it are not interrupted.
+func (c Ctx) Abort() {
+	c.ctx.Abort()
+}
+
+// IsAborted reports whether Abort or Error has been called.
+func (c Ctx) IsAborted() bool {
+	return c.ctx.IsAborted()
+}
+
+// Set stores value for following handlers of this request.
+func (c Ctx) Set(key string, value interface{}) {
+	c.ctx.Set(key, value)
+}
+
+// Get returns value stored by Set.
+func (c Ctx) Get(key string) (interface{}, bool) {
+	return c.ctx.Get(key)
+}
+
+func (c Ctx) Method() string {
+	return c.ctx.Request.Method
+}
+
+// Path of request URL, without query.
+func (c Ctx) Path() string {
+	return c.ctx.Request.URL.Path
+}
+
+func (c Ctx) ClientIP() string {
+	return c.ctx.ClientIP()
+}
+
+func (c Ctx) GetHeader(key string) string {
+	return c.ctx.GetHeader(key)
+}
+
+func (c Ctx) SetHeader(key, value string) {
+	c.ctx.Header(key, value)
+}
+
+// BearerToken returns token in "Authorization: Bearer <token>" header, empty if not found.
+func (c Ctx) BearerToken() string {
+	auth := c.ctx.GetHeader("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// Status returns status code written to response.
+func (c Ctx) Status() int {
+	return c.ctx.Writer.Status()
+}
 
 // Unmarshal HTTP body to json
 func (c Ctx) Body2JSON(output interface{}) error {
 	return c.ctx.BindJSON(output)
 }
 
+// Read whole HTTP body
+func (c Ctx) Body2Bytes() ([]byte, error) {
+	return io.ReadAll(c.ctx.Request.Body)
+}
+
+// Context of HTTP request, it is canceled when client disconnects
+func (c Ctx) Context() context.Context {
+	return c.ctx.Request.Context()
+}
+
 // receive file from HTTP body
 func (c Ctx) Body2File(key string) ([]byte, error) {
 	f, hd, err := c.ctx.Request.FormFile(key)
@@ -159,14 +231,41 @@ func (c Ctx) WriteMapJSON(code int, values map[string]interface{}) {
 func (c Ctx) WriteStructJSON(code int, output interface{}, errFmt ErrFormatter) {
 	buf, err := json.Marshal(output)
 	if err != nil {
-		c.WriteString(code, errFmt(err))
+		c.WriteError(code, err, errFmt)
 		return
 	}
-	c.ctx.String(code, string(buf))
+	c.ctx.Data(code, "application/json; charset=utf-8", buf)
 }
 
+// Write status code only, without body
+func (c Ctx) WriteStatus(code int) {
+	c.ctx.Status(code)
+}
+
+// WriteError writes error formatted by errFmt as text, or by formatter of router if errFmt is nil,
+// with content type of router formatter.
 func (c Ctx) WriteError(code int, err error, errFmt ErrFormatter) {
-	c.WriteString(code, errFmt(err))
+	if errFmt != nil {
+		c.WriteString(code, errFmt(err))
+		return
+	}
+	errFmt, contentType := DefaultErrFormatter, "application/json; charset=utf-8"
+	if c.r != nil && c.r.errFmt != nil {
+		errFmt, contentType = c.r.errFmt, c.r.errContentType
+	}
+	c.ctx.Data(code, contentType, []byte(errFmt(err)))
+}
+
+// Error writes error with status code from gerrors.HTTPStatus, and aborts following handlers.
+func (c Ctx) Error(err error) {
+	c.WriteError(gerrors.HTTPStatus(err), err, nil)
+	c.ctx.Abort()
+}
+
+// DefaultErrFormatter formats error as JSON of gerrors.ErrPayload without stacks.
+func DefaultErrFormatter(err error) string {
+	b, _ := json.Marshal(gerrors.ToPayload(err, false))
+	return string(b)
 }
 
 func (c Ctx) ServeDiskFile(filename, filepath string) {
@@ -0,0 +1,126 @@
+package gweb
+
+import (
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/glog"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type (
+	docsPage struct {
+		Info       OpenAPIInfo
+		SpecURL    string
+		Operations []docsOperation
+		Schemas    []docsSchema
+	}
+
+	docsOperation struct {
+		Method string
+		Path   string
+		*Operation
+		Body     string
+		Response string
+	}
+
+	docsSchema struct {
+		Name string
+		JSON string
+	}
+)
+
+var docsPageTmpl = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+.op { border: 1px solid #ddd; border-radius: 4px; margin: 1em 0; padding: 0.5em 1em; }
+.method { display: inline-block; min-width: 4em; font-weight: bold; }
+.deprecated { text-decoration: line-through; }
+pre { background: #f6f8fa; padding: 0.5em; overflow: auto; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: 2px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Info.Title}} <small>{{.Info.Version}}</small></h1>
+<p>{{.Info.Description}}</p>
+<p><a href="{{.SpecURL}}">OpenAPI JSON</a></p>
+{{range .Operations}}
+<div class="op">
+<h3{{if .Deprecated}} class="deprecated"{{end}}><span class="method">{{.Method}}</span> {{.Path}}</h3>
+{{if .Summary}}<p><b>{{.Summary}}</b></p>{{end}}
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .Parameters}}
+<table>
+<tr><th>Name</th><th>In</th><th>Type</th><th>Required</th><th>Description</th></tr>
+{{range .Parameters}}<tr><td>{{.Name}}</td><td>{{.In}}</td><td>{{.Schema.Type}}</td><td>{{.Required}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Body}}<p>Request body</p><pre>{{.Body}}</pre>{{end}}
+{{if .Response}}<p>Response</p><pre>{{.Response}}</pre>{{end}}
+</div>
+{{end}}
+{{if .Schemas}}<h2>Schemas</h2>{{end}}
+{{range .Schemas}}
+<h3 id="{{.Name}}">{{.Name}}</h3>
+<pre>{{.JSON}}</pre>
+{{end}}
+</body>
+</html>
+`))
+
+// ServeDocs serves OpenAPI document at "<relativePath>/openapi.json" and a docs page at relativePath.
+// The document is generated on each request, so routes registered after ServeDocs are included,
+// routes of ServeDocs itself are not.
+func (r *Router) ServeDocs(relativePath string, info OpenAPIInfo) {
+	specPath := joinPaths(relativePath, "openapi.json")
+	r.root.g.GET(specPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, r.OpenAPI(info))
+	})
+	r.root.g.GET(relativePath, func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := docsPageTmpl.Execute(c.Writer, newDocsPage(r.OpenAPI(info), specPath)); err != nil {
+			glog.Erro(err, "gweb: render docs page")
+		}
+	})
+}
+
+func newDocsPage(doc *OpenAPI, specURL string) *docsPage {
+	page := &docsPage{Info: doc.Info, SpecURL: specURL}
+	for p, ops := range doc.Paths {
+		for method, op := range ops {
+			o := docsOperation{Method: strings.ToUpper(method), Path: p, Operation: op}
+			if op.RequestBody != nil {
+				o.Body = indentJSON(op.RequestBody.Content["application/json"].Schema)
+			}
+			if res := op.Responses["200"]; res != nil && res.Content != nil {
+				o.Response = indentJSON(res.Content["application/json"].Schema)
+			}
+			page.Operations = append(page.Operations, o)
+		}
+	}
+	sort.Slice(page.Operations, func(i, j int) bool {
+		a, b := page.Operations[i], page.Operations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	for name, s := range doc.Components.Schemas {
+		page.Schemas = append(page.Schemas, docsSchema{Name: name, JSON: indentJSON(s)})
+	}
+	sort.Slice(page.Schemas, func(i, j int) bool { return page.Schemas[i].Name < page.Schemas[j].Name })
+	return page
+}
+
+func indentJSON(v interface{}) string {
+	b, _ := json.MarshalIndent(v, "", "  ")
+	return string(b)
+}
@@ -0,0 +1,192 @@
+package gweb
+
+import (
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/basic/glog"
+	"github.com/cryptowilliam/goutil/basic/gpanic"
+	"github.com/cryptowilliam/goutil/sys/gcron"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CORSOptions configures CORS middleware.
+	CORSOptions struct {
+		AllowOrigins     []string // "*" allows any origin, empty means "*".
+		AllowMethods     []string // Empty means GET, POST, PUT, PATCH, DELETE, HEAD.
+		AllowHeaders     []string // Empty means headers requested by preflight request.
+		ExposeHeaders    []string
+		AllowCredentials bool          // Requires AllowOrigins without "*".
+		MaxAge           time.Duration // How long preflight result can be cached.
+	}
+
+	// rateLimiters holds a gcron.RateLimiter for each key.
+	rateLimiters struct {
+		d        time.Duration
+		mu       sync.Mutex
+		limiters map[string]*rateLimiter
+		pruned   time.Time
+	}
+
+	rateLimiter struct {
+		*gcron.RateLimiter
+		used time.Time
+	}
+)
+
+// Recovery recovers panic in following handlers, logs it and responds 500.
+func Recovery() HandlerFunc {
+	return func(c *Ctx) {
+		defer gpanic.Recover(func(err error) {
+			glog.Erro(err, c.Method()+" "+c.Path())
+			if !c.ctx.Writer.Written() {
+				c.Error(gerrors.CodeInternal.New("internal server error"))
+			}
+			c.Abort()
+		})
+		c.Next()
+	}
+}
+
+// Logger logs method, path, status, latency and client IP of every request.
+func Logger() HandlerFunc {
+	return func(c *Ctx) {
+		start := time.Now()
+		path := c.Path()
+		c.Next()
+		glog.Infof("%s %s %d %s %s", c.Method(), path, c.Status(), time.Since(start), c.ClientIP())
+	}
+}
+
+// CORS handles cross-origin requests, preflight requests are responded with 204 and not passed to handlers.
+// Preflight requests use OPTIONS method which has no routes in general, so use it on Router rather than groups.
+// It panics if AllowCredentials is set with any origin allowed, which would let any site read responses with
+// credentials of users.
+func CORS(opts CORSOptions) HandlerFunc {
+	anyOrigin := len(opts.AllowOrigins) == 0
+	origins := map[string]bool{}
+	for _, o := range opts.AllowOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(o)] = true
+	}
+	if anyOrigin && opts.AllowCredentials {
+		panic(gerrors.New("gweb: CORS AllowCredentials requires explicit AllowOrigins"))
+	}
+	methods := strings.Join(opts.AllowMethods, ", ")
+	if methods == "" {
+		methods = "GET, POST, PUT, PATCH, DELETE, HEAD"
+	}
+	headers := strings.Join(opts.AllowHeaders, ", ")
+	expose := strings.Join(opts.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge / time.Second))
+
+	return func(c *Ctx) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !anyOrigin && !origins[strings.ToLower(origin)] {
+			if c.Method() == http.MethodOptions {
+				c.WriteStatus(http.StatusForbidden)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			c.SetHeader("Access-Control-Allow-Origin", "*")
+		} else {
+			c.SetHeader("Access-Control-Allow-Origin", origin)
+			c.SetHeader("Vary", "Origin")
+		}
+		if opts.AllowCredentials {
+			c.SetHeader("Access-Control-Allow-Credentials", "true")
+		}
+		if expose != "" {
+			c.SetHeader("Access-Control-Expose-Headers", expose)
+		}
+		if c.Method() != http.MethodOptions || c.GetHeader("Access-Control-Request-Method") == "" {
+			c.Next()
+			return
+		}
+
+		c.SetHeader("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			c.SetHeader("Access-Control-Allow-Headers", headers)
+		} else if h := c.GetHeader("Access-Control-Request-Headers"); h != "" {
+			c.SetHeader("Access-Control-Allow-Headers", h)
+		}
+		if opts.MaxAge > 0 {
+			c.SetHeader("Access-Control-Max-Age", maxAge)
+		}
+		c.WriteStatus(http.StatusNoContent)
+		c.Abort()
+	}
+}
+
+// Auth calls fn to authenticate request, request is rejected with error returned by fn,
+// errors without code are responded as 401.
+// fn can save identity by Ctx.Set for following handlers.
+func Auth(fn func(c *Ctx) error) HandlerFunc {
+	return func(c *Ctx) {
+		if err := fn(c); err != nil {
+			if gerrors.CodeOf(err) == gerrors.CodeUnknown {
+				err = gerrors.CodeUnauthenticated.Wrap(err, "")
+			}
+			c.Error(err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimit allows one request per d for each key, other requests are responded as 429.
+// key returns key of request, nil means client IP.
+func RateLimit(d time.Duration, key func(c *Ctx) string) HandlerFunc {
+	if key == nil {
+		key = func(c *Ctx) string { return c.ClientIP() }
+	}
+	rl := &rateLimiters{d: d, limiters: map[string]*rateLimiter{}}
+	return func(c *Ctx) {
+		if !rl.get(key(c)).MarkAndWaitUnblock() {
+			c.Error(gerrors.CodeResourceExhausted.New("too many requests"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// get returns limiter of key, limiters unused for a while are removed.
+func (rl *rateLimiters) get(key string) *rateLimiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	// Limiter unused longer than d allows next request anyway.
+	idle := rl.d
+	if idle < time.Minute {
+		idle = time.Minute
+	}
+	if now.Sub(rl.pruned) > idle {
+		for k, l := range rl.limiters {
+			if now.Sub(l.used) > idle {
+				delete(rl.limiters, k)
+			}
+		}
+		rl.pruned = now
+	}
+	l, ok := rl.limiters[key]
+	if !ok {
+		l = &rateLimiter{RateLimiter: gcron.NewRateLimiter(rl.d)}
+		rl.limiters[key] = l
+	}
+	l.used = now
+	return l
+}
@@ -0,0 +1,488 @@
+package gweb
+
+import (
+	"encoding"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/net/ghttp"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// RouteDoc is optional metadata of route, it is used to generate OpenAPI document.
+	RouteDoc struct {
+		Summary     string
+		Description string
+		Tags        []string
+		Deprecated  bool
+
+		// Request is a value of request struct bound by Ctx.Bind, e.g. CreateItemReq{} or (*CreateItemReq)(nil).
+		// Fields with uri, form and header tags are documented as parameters, other fields as JSON body.
+		Request interface{}
+
+		// Response is a value of JSON response type, e.g. Item{} or []Item{}.
+		Response interface{}
+
+		// Params documents parameters not in Request, they override parameters of Request with same name.
+		Params []ParamDoc
+	}
+
+	// ParamDoc documents a request parameter.
+	ParamDoc struct {
+		Source      ParamSource // Head, UriSlice or Query.
+		Name        string
+		Type        string // OpenAPI type such as "integer", empty means "string".
+		Description string
+		Required    bool
+	}
+
+	// OpenAPIInfo is the info object of OpenAPI document.
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// OpenAPI is an OpenAPI 3 document, paths are keyed by path and lower case method.
+	OpenAPI struct {
+		OpenAPI    string                           `json:"openapi"`
+		Info       OpenAPIInfo                      `json:"info"`
+		Paths      map[string]map[string]*Operation `json:"paths"`
+		Components Components                       `json:"components,omitempty"`
+	}
+
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+
+	Operation struct {
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		Tags        []string             `json:"tags,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+		Deprecated  bool                 `json:"deprecated,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"` // "path", "query" or "header".
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	RequestBody struct {
+		Required bool                 `json:"required,omitempty"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Schema is a subset of OpenAPI schema object.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Enum                 []string           `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *uint64            `json:"minLength,omitempty"`
+		MaxLength            *uint64            `json:"maxLength,omitempty"`
+		MinItems             *uint64            `json:"minItems,omitempty"`
+		MaxItems             *uint64            `json:"maxItems,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+	}
+
+	// schemaGen generates schemas by reflection, named struct types are placed in components.
+	schemaGen struct {
+		schemas map[string]*Schema
+		names   map[reflect.Type]string
+	}
+)
+
+// tagDoc is the struct tag of field description.
+const tagDoc = "doc"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	invalidNameChars  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	ginParam          = regexp.MustCompile(`[:*]([^/]+)`)
+)
+
+// OpenAPI generates OpenAPI document of routes registered by Handle and HandleDoc.
+func (r *Router) OpenAPI(info OpenAPIInfo) *OpenAPI {
+	doc := &OpenAPI{OpenAPI: "3.0.3", Info: info, Paths: map[string]map[string]*Operation{}}
+	g := &schemaGen{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+	for _, route := range r.Routes() {
+		p := ginParam.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*Operation{}
+		}
+		doc.Paths[p][strings.ToLower(string(route.Method))] = g.operation(route)
+	}
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+func (g *schemaGen) operation(route Route) *Operation {
+	d := route.Doc
+	op := &Operation{Summary: d.Summary, Description: d.Description, Tags: d.Tags, Deprecated: d.Deprecated}
+
+	var params []*Parameter
+	if t := typeOf(d.Request); t != nil && t.Kind() == reflect.Struct {
+		var body *Schema
+		params, body = g.request(t)
+		if body != nil && route.Method != ghttp.GET && string(route.Method) != http.MethodHead {
+			op.RequestBody = &RequestBody{Required: len(body.Required) > 0, Content: jsonContent(body)}
+		}
+	}
+	for _, pd := range d.Params {
+		in := paramIn(pd.Source)
+		if in == "" {
+			continue
+		}
+		typ := pd.Type
+		if typ == "" {
+			typ = "string"
+		}
+		params = setParam(params, &Parameter{Name: pd.Name, In: in, Description: pd.Description,
+			Required: pd.Required || in == "path", Schema: &Schema{Type: typ}})
+	}
+	// Path parameters must be documented.
+	for _, m := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+		if findParam(params, "path", m[1]) == nil {
+			params = append(params, &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	op.Parameters = params
+
+	ok := &Response{Description: "OK"}
+	if t := typeOf(d.Response); t != nil {
+		ok.Content = jsonContent(g.schema(t))
+	}
+	op.Responses = map[string]*Response{
+		"200":     ok,
+		"default": {Description: "Error", Content: jsonContent(g.schema(reflect.TypeOf(gerrors.ErrPayload{})))},
+	}
+	return op
+}
+
+// request splits fields of request struct into parameters and JSON body, body is nil if there is no body field.
+// Fields with form tag are described as query parameters, Bind accepts them in form body too.
+func (g *schemaGen) request(t reflect.Type) ([]*Parameter, *Schema) {
+	var params []*Parameter
+	body := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	isParam := false
+	walkFields(t, func(field reflect.StructField) {
+		for _, src := range []struct{ tag, in string }{{tagUri, "path"}, {tagForm, "query"}, {tagHeader, "header"}} {
+			if name := tagName(field, src.tag); name != "" {
+				isParam = true
+				s := g.paramSchema(field.Type)
+				applyBinding(s, field.Tag.Get("binding"))
+				params = setParam(params, &Parameter{Name: name, In: src.in, Description: field.Tag.Get(tagDoc),
+					Required: src.in == "path" || hasRule(field, "required"), Schema: s})
+				return
+			}
+		}
+		g.property(body, field)
+	})
+	if len(body.Properties) == 0 {
+		return params, nil
+	}
+	if !isParam && t.Name() != "" {
+		return params, g.schema(t)
+	}
+	return params, body
+}
+
+// schema returns schema of t, it is reference to components if t is named struct.
+func (g *schemaGen) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return &Schema{} // Any value.
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.name(t)
+			g.names[t] = name
+			g.schemas[name] = &Schema{} // Placeholder for recursive types.
+			g.schemas[name] = g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// paramSchema returns schema of parameter parsed by Ctx.Bind.
+func (g *schemaGen) paramSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &Schema{Type: "string", Description: "duration such as \"1m30s\""}
+	}
+	if t.Kind() == reflect.Slice && !t.Implements(textUnmarshalerType) && t.Elem().Kind() != reflect.Uint8 {
+		return &Schema{Type: "array", Items: g.paramSchema(t.Elem())}
+	}
+	return g.schema(t)
+}
+
+// object returns inline object schema of struct t.
+func (g *schemaGen) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	walkFields(t, func(field reflect.StructField) {
+		g.property(s, field)
+	})
+	return s
+}
+
+// property adds field to object s as JSON encodes it.
+func (g *schemaGen) property(s *Schema, field reflect.StructField) {
+	name, opts := field.Name, ""
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if tag == "-" {
+			return
+		}
+		if i := strings.Index(tag, ","); i >= 0 {
+			tag, opts = tag[:i], tag[i:]
+		}
+		if tag != "" {
+			name = tag
+		}
+	}
+	switch field.Type.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return
+	}
+
+	ps := g.schema(field.Type)
+	if strings.Contains(opts, ",string") {
+		ps = &Schema{Type: "string"}
+	}
+	desc := field.Tag.Get(tagDoc)
+	if ps.Ref != "" {
+		// Siblings of $ref are ignored, so reference is wrapped to carry description.
+		if desc != "" {
+			ps = &Schema{AllOf: []*Schema{ps}, Description: desc}
+		}
+	} else {
+		ps.Description = desc
+		applyBinding(ps, field.Tag.Get("binding"))
+	}
+	s.Properties[name] = ps
+	if hasRule(field, "required") {
+		s.Required = append(s.Required, name)
+		sort.Strings(s.Required)
+	}
+}
+
+// name returns unique component name of named type t.
+func (g *schemaGen) name(t reflect.Type) string {
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if _, used := g.schemas[name]; !used {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	base := invalidNameChars.ReplaceAllString(pkg, "_") + "." + name
+	name = base
+	for i := 2; ; i++ {
+		if _, used := g.schemas[name]; !used {
+			return name
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+// walkFields calls fn with exported fields of struct t, fields of embedded structs without JSON name are flattened
+// like Bind does, embedded structs which are being walked are skipped.
+func walkFields(t reflect.Type, fn func(field reflect.StructField)) {
+	walkStructFields(t, fn, map[reflect.Type]bool{})
+}
+
+func walkStructFields(t reflect.Type, fn func(field reflect.StructField), visiting map[reflect.Type]bool) {
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if et, ok := embeddedStruct(field); ok {
+			if !visiting[et] {
+				walkStructFields(et, fn, visiting)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // Unexported.
+		}
+		fn(field)
+	}
+}
+
+// applyBinding documents validation rules of binding tag in s.
+func applyBinding(s *Schema, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "dive" {
+			return // Following rules apply to elements.
+		}
+		key, val := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, val = rule[:i], rule[i+1:]
+		}
+		switch key {
+		case "min", "gte", "max", "lte", "len":
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			lower, upper := key != "max" && key != "lte", key != "min" && key != "gte"
+			switch s.Type {
+			case "integer", "number":
+				if lower {
+					s.Minimum = &f
+				}
+				if upper {
+					s.Maximum = &f
+				}
+			case "string":
+				n := uint64(f)
+				if lower {
+					s.MinLength = &n
+				}
+				if upper {
+					s.MaxLength = &n
+				}
+			case "array":
+				n := uint64(f)
+				if lower {
+					s.MinItems = &n
+				}
+				if upper {
+					s.MaxItems = &n
+				}
+			}
+		case "oneof":
+			s.Enum = strings.Fields(val)
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid":
+			s.Format = "uuid"
+		}
+	}
+}
+
+func hasRule(field reflect.StructField, rule string) bool {
+	for _, r := range strings.Split(field.Tag.Get("binding"), ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+func paramIn(src ParamSource) string {
+	switch src {
+	case Head:
+		return "header"
+	case UriSlice:
+		return "path"
+	case Query:
+		return "query"
+	}
+	return ""
+}
+
+func findParam(params []*Parameter, in, name string) *Parameter {
+	for _, p := range params {
+		if p.In == in && p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// setParam replaces parameter with same location and name, or appends p.
+func setParam(params []*Parameter, p *Parameter) []*Parameter {
+	for i, old := range params {
+		if old.In == p.In && old.Name == p.Name {
+			params[i] = p
+			return params
+		}
+	}
+	return append(params, p)
+}
@@ -0,0 +1,178 @@
+package gweb
+
+import (
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/net/ghttp"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	testItem struct {
+		ID       int64             `json:"id"`
+		Name     string            `json:"name" doc:"display name"`
+		Created  time.Time         `json:"created"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		Children []*testItem       `json:"children,omitempty"`
+		secret   string
+	}
+
+	testCreateReq struct {
+		Group string `uri:"group" doc:"group of item"`
+		Token string `header:"X-Token" binding:"required"`
+		Dry   bool   `form:"dry"`
+		Name  string `json:"name" binding:"required,min=1,max=64"`
+		Kind  string `json:"kind" binding:"oneof=a b"`
+		Raw   []byte `json:"raw,omitempty"`
+	}
+
+	testListReq struct {
+		Page  int      `form:"page" binding:"min=1"`
+		Tags  []string `form:"tag"`
+		Since time.Duration
+	}
+)
+
+func TestOpenAPI(t *testing.T) {
+	r := NewRouter()
+	r.ServeDocs("/docs", OpenAPIInfo{Title: "Items", Version: "1.0"})
+	api := r.Group("/api/v1")
+	api.HandleDoc(ghttp.POST, "/groups/:group/items", Typed(func(c *Ctx, req *testCreateReq) (testItem, error) {
+		return testItem{Name: req.Name}, nil
+	}), RouteDoc{Summary: "Create item", Tags: []string{"items"}, Request: testCreateReq{}, Response: testItem{}})
+	api.HandleDoc(ghttp.GET, "/items", func(c *Ctx) {}, RouteDoc{
+		Summary:  "List items",
+		Request:  (*testListReq)(nil),
+		Response: []testItem{},
+		Params:   []ParamDoc{{Source: Head, Name: "X-Trace", Description: "trace ID"}},
+	})
+	api.Handle(ghttp.DELETE, "/items/:id", func(c *Ctx) {})
+
+	w := do(r, "GET", "/docs/openapi.json", "", nil)
+	var doc OpenAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || w.Code != http.StatusOK {
+		t.Errorf("openapi.json returns %d %s", w.Code, w.Body.String())
+		return
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Info.Title != "Items" || len(doc.Paths) != 3 {
+		t.Errorf("unexpected document %s", w.Body.String())
+		return
+	}
+
+	create := doc.Paths["/api/v1/groups/{group}/items"]["post"]
+	if create == nil || create.Summary != "Create item" || len(create.Tags) != 1 {
+		t.Errorf("create operation %+v", create)
+		return
+	}
+	params := map[string]*Parameter{}
+	for _, p := range create.Parameters {
+		params[p.In+":"+p.Name] = p
+	}
+	if p := params["path:group"]; p == nil || !p.Required || p.Description != "group of item" {
+		t.Errorf("path parameter %+v", p)
+		return
+	}
+	if p := params["header:X-Token"]; p == nil || !p.Required {
+		t.Errorf("header parameter %+v", p)
+		return
+	}
+	if p := params["query:dry"]; p == nil || p.Required || p.Schema.Type != "boolean" {
+		t.Errorf("query parameter %+v", p)
+		return
+	}
+	body := create.RequestBody.Content["application/json"].Schema
+	if len(body.Properties) != 3 || strings.Join(body.Required, ",") != "name" || !create.RequestBody.Required {
+		t.Errorf("request body %+v", body)
+		return
+	}
+	if name := body.Properties["name"]; *name.MinLength != 1 || *name.MaxLength != 64 {
+		t.Errorf("name schema %+v", name)
+		return
+	}
+	if kind := body.Properties["kind"]; strings.Join(kind.Enum, ",") != "a,b" {
+		t.Errorf("kind schema %+v", kind)
+		return
+	}
+	if raw := body.Properties["raw"]; raw.Type != "string" || raw.Format != "byte" {
+		t.Errorf("raw schema %+v", raw)
+		return
+	}
+	if s := create.Responses["200"].Content["application/json"].Schema; s.Ref != "#/components/schemas/testItem" {
+		t.Errorf("response schema %+v", s)
+		return
+	}
+	if s := create.Responses["default"].Content["application/json"].Schema; s.Ref != "#/components/schemas/ErrPayload" {
+		t.Errorf("error schema %+v", s)
+		return
+	}
+
+	item := doc.Components.Schemas["testItem"]
+	if item == nil || len(item.Properties) != 5 || item.Properties["created"].Format != "date-time" ||
+		item.Properties["name"].Description != "display name" || item.Properties["labels"].AdditionalProperties.Type != "string" ||
+		item.Properties["children"].Items.Ref != "#/components/schemas/testItem" {
+		t.Errorf("item schema %+v", item)
+		return
+	}
+	if errPayload := doc.Components.Schemas["ErrPayload"]; errPayload == nil || errPayload.Properties["Cause"].Ref != "#/components/schemas/ErrPayload" {
+		t.Errorf("error payload schema %+v", errPayload)
+		return
+	}
+
+	list := doc.Paths["/api/v1/items"]["get"]
+	if list == nil || list.RequestBody != nil || len(list.Parameters) != 3 {
+		t.Errorf("list operation %+v", list)
+		return
+	}
+	if p := list.Parameters[0]; p.Name != "page" || *p.Schema.Minimum != 1 {
+		t.Errorf("page parameter %+v", p)
+		return
+	}
+	if p := list.Parameters[1]; p.Name != "tag" || p.Schema.Type != "array" || p.Schema.Items.Type != "string" {
+		t.Errorf("tag parameter %+v", p)
+		return
+	}
+	if p := list.Parameters[2]; p.Name != "X-Trace" || p.In != "header" {
+		t.Errorf("trace parameter %+v", p)
+		return
+	}
+	if s := list.Responses["200"].Content["application/json"].Schema; s.Type != "array" || s.Items.Ref != "#/components/schemas/testItem" {
+		t.Errorf("list response %+v", s)
+		return
+	}
+
+	del := doc.Paths["/api/v1/items/{id}"]["delete"]
+	if del == nil || len(del.Parameters) != 1 || del.Parameters[0].Name != "id" || !del.Parameters[0].Required {
+		t.Errorf("delete operation %+v", del)
+		return
+	}
+
+	w = do(r, "GET", "/docs", "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/v1/groups/{group}/items") ||
+		!strings.Contains(w.Body.String(), "Create item") || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("docs page returns %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWalkFields(t *testing.T) {
+	type (
+		Base struct {
+			Page int `form:"page"`
+		}
+		Node struct {
+			*Node
+			*Base
+			Name string `json:"name"`
+			next *Node
+		}
+	)
+	var names []string
+	walkFields(reflect.TypeOf(Node{}), func(field reflect.StructField) {
+		names = append(names, field.Name)
+	})
+	if strings.Join(names, ",") != "Page,Name" {
+		t.Errorf("walked fields %v, expect Page,Name", names)
+	}
+}
@@ -1,38 +1,201 @@
 package gweb
 
 import (
+	"context"
 	"github.com/cryptowilliam/goutil/net/ghttp"
 	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
 )
 
 type (
+	// Router dispatches requests to handlers, routes registered on it directly belong to the root group.
 	Router struct {
-		ng *gin.Engine
+		root            *Group
+		ng              *gin.Engine
+		errFmt          ErrFormatter
+		errContentType  string
+		shutdownTimeout time.Duration
+		routesMu        sync.Mutex
+		routes          []Route
 	}
 
+	// Route is a registered route, it is described in OpenAPI document.
+	Route struct {
+		Method ghttp.Method
+		Path   string // Absolute path in gin syntax, e.g. "/items/:id".
+		Doc    RouteDoc
+	}
+
+	// Group is a set of routes sharing path prefix and middlewares.
+	Group struct {
+		r *Router
+		g *gin.RouterGroup
+	}
+
+	// HandlerFunc handles request, it is also used as middleware which calls Ctx.Next to run following handlers.
 	HandlerFunc func(*Ctx)
 )
 
+// NewRouter creates router with Recovery and Logger middlewares.
 func NewRouter() *Router {
 	gin.SetMode(gin.ReleaseMode)
-	return &Router{ng: gin.Default()}
+	r := &Router{ng: gin.New(), errFmt: DefaultErrFormatter, errContentType: "application/json; charset=utf-8", shutdownTimeout: 10 * time.Second}
+	r.root = &Group{r: r, g: &r.ng.RouterGroup}
+	r.Use(Recovery(), Logger())
+	return r
 }
 
-func (r *Router) Handle(m ghttp.Method, relativePath string, fn HandlerFunc) {
-	fn2 := func(c *gin.Context) {
-		fn(&Ctx{ctx: c})
+// SetErrFormatter sets formatter used by Ctx.Error and by WriteError without formatter,
+// its output is sent with contentType, empty means "text/plain; charset=utf-8".
+func (r *Router) SetErrFormatter(errFmt ErrFormatter, contentType string) {
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	r.errFmt, r.errContentType = errFmt, contentType
+}
+
+// SetShutdownTimeout sets how long ServeContext waits for active requests after its context is done,
+// connections still active after it are closed.
+func (r *Router) SetShutdownTimeout(d time.Duration) {
+	r.shutdownTimeout = d
+}
+
+func (r *Router) wrap(fn HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fn(&Ctx{ctx: c, r: r})
+	}
+}
+
+func (r *Router) wraps(fns []HandlerFunc) []gin.HandlerFunc {
+	res := make([]gin.HandlerFunc, 0, len(fns))
+	for _, fn := range fns {
+		res = append(res, r.wrap(fn))
+	}
+	return res
+}
+
+// Use appends middlewares to group, they only apply to routes registered after it.
+func (g *Group) Use(mw ...HandlerFunc) {
+	g.g.Use(g.r.wraps(mw)...)
+}
+
+// Group creates sub group with path prefix, mw runs after middlewares of g.
+func (g *Group) Group(prefix string, mw ...HandlerFunc) *Group {
+	return &Group{r: g.r, g: g.g.Group(prefix, g.r.wraps(mw)...)}
+}
+
+func (g *Group) Handle(m ghttp.Method, relativePath string, fn HandlerFunc) {
+	g.HandleDoc(m, relativePath, fn, RouteDoc{})
+}
+
+// HandleDoc is like Handle, and doc describes the route in OpenAPI document.
+func (g *Group) HandleDoc(m ghttp.Method, relativePath string, fn HandlerFunc, doc RouteDoc) {
+	g.g.Handle(string(m), relativePath, g.r.wrap(fn))
+	g.r.routesMu.Lock()
+	g.r.routes = append(g.r.routes, Route{Method: m, Path: joinPaths(g.g.BasePath(), relativePath), Doc: doc})
+	g.r.routesMu.Unlock()
+}
+
+// joinPaths joins path like gin, trailing slash of relativePath is kept.
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	res := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(res, "/") {
+		return res + "/"
 	}
-	r.ng.Handle(string(m), relativePath, fn2)
+	return res
+}
+
+func (g *Group) Static(relativePath, root string) {
+	g.g.Static(relativePath, root)
+}
+
+func (g *Group) StaticFile(relativePath, filepath string) {
+	g.g.StaticFile(relativePath, filepath)
+}
+
+// Use appends middlewares to root group, they also apply to requests matching no route.
+func (r *Router) Use(mw ...HandlerFunc) {
+	r.ng.Use(r.wraps(mw)...)
+}
+
+// Group creates group with path prefix under root group.
+func (r *Router) Group(prefix string, mw ...HandlerFunc) *Group {
+	return r.root.Group(prefix, mw...)
+}
+
+func (r *Router) Handle(m ghttp.Method, relativePath string, fn HandlerFunc) {
+	r.root.Handle(m, relativePath, fn)
+}
+
+// HandleDoc is like Handle, and doc describes the route in OpenAPI document.
+func (r *Router) HandleDoc(m ghttp.Method, relativePath string, fn HandlerFunc, doc RouteDoc) {
+	r.root.HandleDoc(m, relativePath, fn, doc)
+}
+
+// Routes returns routes registered by Handle and HandleDoc in order of registration.
+func (r *Router) Routes() []Route {
+	r.routesMu.Lock()
+	defer r.routesMu.Unlock()
+	return append([]Route{}, r.routes...)
 }
 
 func (r *Router) Static(relativePath, root string) {
-	r.ng.Static(relativePath, root)
+	r.root.Static(relativePath, root)
 }
 
 func (r *Router) StaticFile(relativePath, filepath string) {
-	r.ng.StaticFile(relativePath, filepath)
+	r.root.StaticFile(relativePath, filepath)
 }
 
+// Serve listens on addr and serves until error occurs.
 func (r *Router) Serve(addr string) error {
-	return r.ng.Run(addr) // listen and serve on 0.0.0.0:8080
+	return r.ServeContext(context.Background(), addr)
+}
+
+// ServeContext listens on addr and serves until ctx is done, then shuts down gracefully:
+// it stops accepting connections and waits for active requests at most shutdown timeout.
+// It returns nil if shut down by ctx.
+func (r *Router) ServeContext(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return r.ServeListener(ctx, lis)
+}
+
+// ServeListener is like ServeContext but serves on lis, lis is closed when it returns.
+func (r *Router) ServeListener(ctx context.Context, lis net.Listener) error {
+	srv := &http.Server{Handler: r.ng}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		// Connections still active after shutdown timeout are closed forcibly.
+		srv.Close()
+	}
+	<-errCh // http.ErrServerClosed
+	return err
+}
+
+// ServeHTTP implements http.Handler, so Router can be mounted into any http.Server.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.ng.ServeHTTP(w, req)
 }
@@ -0,0 +1,393 @@
+package gweb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/net/ghttp"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func do(r http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGroup(t *testing.T) {
+	r := NewRouter()
+	var trace []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Ctx) {
+			trace = append(trace, name)
+			c.Next()
+		}
+	}
+	r.Use(mark("root"))
+	api := r.Group("/api", mark("api"))
+	v1 := api.Group("/v1")
+	v1.Use(mark("v1"))
+	v1.Handle(ghttp.GET, "/ping", func(c *Ctx) {
+		trace = append(trace, "handler")
+		c.WriteString(http.StatusOK, "pong")
+	})
+	r.Handle(ghttp.GET, "/health", func(c *Ctx) {
+		c.WriteStatus(http.StatusOK)
+	})
+
+	w := do(r, "GET", "/api/v1/ping", "", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "pong" {
+		t.Errorf("ping returns %d %s", w.Code, w.Body.String())
+		return
+	}
+	if strings.Join(trace, ",") != "root,api,v1,handler" {
+		t.Errorf("middleware order %v", trace)
+		return
+	}
+	trace = nil
+	if w := do(r, "GET", "/health", "", nil); w.Code != http.StatusOK || strings.Join(trace, ",") != "root" {
+		t.Errorf("health returns %d, middlewares %v", w.Code, trace)
+		return
+	}
+}
+
+func TestCORS_Credentials(t *testing.T) {
+	for _, origins := range [][]string{nil, {"https://a.com", "*"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CORS with credentials and origins %v should panic", origins)
+				}
+			}()
+			CORS(CORSOptions{AllowOrigins: origins, AllowCredentials: true})
+		}()
+	}
+
+	r := NewRouter()
+	r.Use(CORS(CORSOptions{AllowOrigins: []string{"https://a.com"}, AllowCredentials: true}))
+	r.Handle(ghttp.GET, "/", func(c *Ctx) {
+		c.WriteStatus(http.StatusOK)
+	})
+	w := do(r, "GET", "/", "", map[string]string{"Origin": "https://a.com"})
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://a.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("allowed origin returns %v", w.Header())
+		return
+	}
+	w = do(r, "GET", "/", "", map[string]string{"Origin": "https://b.com"})
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("disallowed origin returns %v", w.Header())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.Use(CORS(CORSOptions{AllowOrigins: []string{"https://a.com"}, AllowHeaders: []string{"Authorization"}, MaxAge: time.Hour}))
+	r.Handle(ghttp.GET, "/panic", func(c *Ctx) {
+		panic("oops")
+	})
+	auth := r.Group("/private", Auth(func(c *Ctx) error {
+		if c.BearerToken() != "secret" {
+			return gerrors.New("invalid token")
+		}
+		c.Set("user", "alice")
+		return nil
+	}))
+	auth.Handle(ghttp.GET, "/me", func(c *Ctx) {
+		user, _ := c.Get("user")
+		c.WriteString(http.StatusOK, "%v", user)
+	})
+	limited := r.Group("/limited", RateLimit(time.Hour, nil))
+	limited.Handle(ghttp.GET, "", func(c *Ctx) {
+		c.WriteStatus(http.StatusOK)
+	})
+
+	w := do(r, "GET", "/panic", "", nil)
+	var p gerrors.ErrPayload
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || w.Code != http.StatusInternalServerError || p.Code != gerrors.CodeInternal.Code {
+		t.Errorf("panic returns %d %s", w.Code, w.Body.String())
+		return
+	}
+
+	w = do(r, "OPTIONS", "/private/me", "", map[string]string{"Origin": "https://a.com", "Access-Control-Request-Method": "GET"})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://a.com" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Authorization" || w.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("preflight returns %d %v", w.Code, w.Header())
+		return
+	}
+	w = do(r, "OPTIONS", "/private/me", "", map[string]string{"Origin": "https://b.com", "Access-Control-Request-Method": "GET"})
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight of disallowed origin returns %d %v", w.Code, w.Header())
+		return
+	}
+
+	if w := do(r, "GET", "/private/me", "", map[string]string{"Authorization": "Bearer wrong"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token returns %d", w.Code)
+		return
+	}
+	w = do(r, "GET", "/private/me", "", map[string]string{"Authorization": "Bearer secret", "Origin": "https://a.com"})
+	if w.Code != http.StatusOK || w.Body.String() != "alice" || w.Header().Get("Access-Control-Allow-Origin") != "https://a.com" {
+		t.Errorf("authorized returns %d %s %v", w.Code, w.Body.String(), w.Header())
+		return
+	}
+
+	if w := do(r, "GET", "/limited", "", nil); w.Code != http.StatusOK {
+		t.Errorf("first request returns %d", w.Code)
+		return
+	}
+	if w := do(r, "GET", "/limited", "", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second request returns %d", w.Code)
+		return
+	}
+}
+
+func TestBind(t *testing.T) {
+	type (
+		Paging struct {
+			Page int `form:"page" binding:"min=1"`
+		}
+		Req struct {
+			Paging
+			ID      int64         `uri:"id" binding:"required"`
+			Tags    []string      `form:"tag"`
+			Timeout time.Duration `form:"timeout"`
+			Key     string        `header:"X-Key" binding:"required"`
+			Name    string        `json:"name" binding:"required"`
+		}
+		Resp struct {
+			Echo Req `json:"echo"`
+		}
+	)
+	r := NewRouter()
+	r.Handle(ghttp.POST, "/items/:id", Typed(func(c *Ctx, req *Req) (Resp, error) {
+		if req.Name == "missing" {
+			return Resp{}, gerrors.CodeNotFound.New("item %d not found", req.ID)
+		}
+		return Resp{Echo: *req}, nil
+	}))
+
+	w := do(r, "POST", "/items/7?page=2&tag=a&tag=b&timeout=3s", `{"name":"x"}`, map[string]string{"X-Key": "k"})
+	var resp Resp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Errorf("bind returns %d %s", w.Code, w.Body.String())
+		return
+	}
+	expect := Req{Paging: Paging{Page: 2}, ID: 7, Tags: []string{"a", "b"}, Timeout: 3 * time.Second, Key: "k", Name: "x"}
+	if resp.Echo.ID != expect.ID || resp.Echo.Page != expect.Page || strings.Join(resp.Echo.Tags, ",") != "a,b" ||
+		resp.Echo.Timeout != expect.Timeout || resp.Echo.Key != expect.Key || resp.Echo.Name != expect.Name {
+		t.Errorf("bound %+v, expect %+v", resp.Echo, expect)
+		return
+	}
+	// JSON body doesn't fill fields of parameters.
+	w = do(r, "POST", "/items/7?page=2", `{"name":"x","ID":9,"Page":9,"Tags":["z"],"Key":"evil"}`, map[string]string{"X-Key": "k"})
+	resp = Resp{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Errorf("bind returns %d %s", w.Code, w.Body.String())
+		return
+	}
+	if resp.Echo.ID != 7 || resp.Echo.Page != 2 || resp.Echo.Tags != nil || resp.Echo.Key != "k" {
+		t.Errorf("JSON body overwrites parameters %+v", resp.Echo)
+		return
+	}
+
+	cases := []struct {
+		target string
+		body   string
+		header map[string]string
+		code   int
+	}{
+		{"/items/7?page=2", `{"name":"x"}`, nil, http.StatusBadRequest},                             // Missing header.
+		{"/items/7?page=0", `{"name":"x"}`, map[string]string{"X-Key": "k"}, http.StatusBadRequest}, // Validation.
+		{"/items/x?page=1", `{"name":"x"}`, map[string]string{"X-Key": "k"}, http.StatusBadRequest}, // Invalid int.
+		{"/items/7?page=1", `{"name":`, map[string]string{"X-Key": "k"}, http.StatusBadRequest},     // Invalid JSON.
+		{"/items/7?page=1", `{"name":"missing"}`, map[string]string{"X-Key": "k"}, http.StatusNotFound},
+	}
+	for _, cs := range cases {
+		w := do(r, "POST", cs.target, cs.body, cs.header)
+		var p gerrors.ErrPayload
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || w.Code != cs.code || p.HTTPStatus != cs.code {
+			t.Errorf("%s %s returns %d %s, expect %d", cs.target, cs.body, w.Code, w.Body.String(), cs.code)
+			return
+		}
+	}
+
+	// Form body fills fields with form tag, JSON fields are left empty and fail validation.
+	form := map[string]string{"X-Key": "k", "Content-Type": "application/x-www-form-urlencoded"}
+	r.Handle(ghttp.POST, "/form/:id", func(c *Ctx) {
+		req := Req{}
+		err := c.Bind(&req)
+		c.WriteString(http.StatusOK, "%d %d %s %v", req.ID, req.Page, strings.Join(req.Tags, ","), err != nil)
+	})
+	if w := do(r, "POST", "/form/7?tag=a", "page=3&tag=b", form); w.Body.String() != "7 3 b,a true" {
+		t.Errorf("form body returns %s", w.Body.String())
+		return
+	}
+	w = do(r, "POST", "/items/7?page=1", "name=x", map[string]string{"X-Key": "k", "Content-Type": "text/plain"})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Content-Type") {
+		t.Errorf("unsupported Content-Type returns %d %s", w.Code, w.Body.String())
+		return
+	}
+
+	// Embedded pointer is allocated when its parameters are present, recursive embedding is skipped.
+	type (
+		Base struct {
+			Page int `form:"page"`
+		}
+		Node struct {
+			*Node
+			*Base
+			Name string `json:"name"`
+		}
+	)
+	r.Handle(ghttp.POST, "/node", func(c *Ctx) {
+		req := Node{}
+		if err := c.Bind(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		page := -1
+		if req.Base != nil {
+			page = req.Page
+		}
+		c.WriteString(http.StatusOK, "%v %d %s", req.Node == nil, page, req.Name)
+	})
+	for target, expect := range map[string]string{
+		"/node?page=3": "true 3 n",
+		"/node":        "true 0 n", // Allocated by JSON body, but parameter is not filled by it.
+	} {
+		if w := do(r, "POST", target, `{"name":"n","Page":9}`, nil); w.Body.String() != expect {
+			t.Errorf("%s returns %d %s, expect %s", target, w.Code, w.Body.String(), expect)
+			return
+		}
+	}
+	if w := do(r, "POST", "/node", `{"name":"n"}`, nil); w.Body.String() != "true -1 n" {
+		t.Errorf("embedded pointer without parameters returns %s", w.Body.String())
+		return
+	}
+
+	r.SetErrFormatter(func(err error) string { return "error: " + err.Error() }, "")
+	w = do(r, "POST", "/items/7?page=1", `{"name":"missing"}`, map[string]string{"X-Key": "k"})
+	if w.Code != http.StatusNotFound || w.Body.String() != "error: item 7 not found" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("custom formatter returns %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	r := NewRouter()
+	r.Handle(ghttp.GET, "/explicit", func(c *Ctx) {
+		c.WriteStructJSON(http.StatusOK, func() {}, func(err error) string { return "404" })
+	})
+	r.Handle(ghttp.GET, "/router", func(c *Ctx) {
+		c.WriteStructJSON(http.StatusOK, func() {}, nil)
+	})
+
+	// Output of explicit formatter is text, even if it looks like JSON.
+	w := do(r, "GET", "/explicit", "", nil)
+	if w.Body.String() != "404" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("explicit formatter returns %s %s", w.Header().Get("Content-Type"), w.Body.String())
+		return
+	}
+	w = do(r, "GET", "/router", "", nil)
+	var p gerrors.ErrPayload
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("router formatter returns %s %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestServeContext(t *testing.T) {
+	r := NewRouter()
+	r.SetShutdownTimeout(5 * time.Second)
+	started := make(chan struct{})
+	r.Handle(ghttp.GET, "/slow", func(c *Ctx) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		c.WriteString(http.StatusOK, "done")
+	})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- r.ServeListener(ctx, lis)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+	if err := <-served; err != nil {
+		t.Error(err)
+		return
+	}
+	// Active request completes before ServeListener returns.
+	if b := <-body; b != "done" {
+		t.Errorf("active request returns %s", b)
+		return
+	}
+	if _, err := http.Get("http://" + lis.Addr().String() + "/slow"); err == nil {
+		t.Error("server still accepts connections after shutdown")
+	}
+}
+
+func TestServeContext_ShutdownTimeout(t *testing.T) {
+	r := NewRouter()
+	r.SetShutdownTimeout(100 * time.Millisecond)
+	started, handled := make(chan struct{}), make(chan struct{})
+	r.Handle(ghttp.GET, "/hang", func(c *Ctx) {
+		defer close(handled)
+		close(started)
+		select {
+		case <-c.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- r.ServeListener(ctx, lis)
+	}()
+
+	go func() {
+		if resp, err := http.Get("http://" + lis.Addr().String() + "/hang"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+	if err := <-served; err != context.DeadlineExceeded {
+		t.Errorf("ServeListener returns %v, expect context.DeadlineExceeded", err)
+		return
+	}
+	// Connection of active request is closed, so the request is canceled.
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Error("active request is not canceled after shutdown timeout")
+	}
+}
@@ -0,0 +1,339 @@
+package gcache
+
+import (
+	"container/list"
+	"context"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
+	"github.com/cryptowilliam/goutil/container/gvolume"
+	"sync"
+	"time"
+)
+
+type (
+	EvictionPolicy string
+
+	// EvictReason tells why an item left the cache.
+	EvictReason string
+
+	// Options of cache, zero value means unlimited LRU cache without expiration.
+	Options[K comparable, V any] struct {
+		Policy     EvictionPolicy
+		MaxItems   int
+		MaxCost    gvolume.Volume
+		Cost       func(key K, val V) gvolume.Volume // Required if MaxCost > 0.
+		DefaultTTL time.Duration                     // 0 means never expire.
+
+		// Loader is called by GetOrLoad on miss, concurrent misses of the same key share one call.
+		Loader func(ctx context.Context, key K) (V, error)
+
+		// OnEvict is called outside of lock whenever an item is expired, evicted, deleted or replaced.
+		OnEvict func(key K, val V, reason EvictReason)
+
+		// CleanupInterval of background expiration, 0 means expired items are removed lazily.
+		CleanupInterval time.Duration
+	}
+
+	Stats struct {
+		Hits        uint64
+		Misses      uint64
+		Loads       uint64
+		LoadErrors  uint64
+		Evictions   uint64
+		Expirations uint64
+		Items       int
+		Cost        gvolume.Volume
+	}
+
+	entry[K comparable, V any] struct {
+		key      K
+		val      V
+		cost     uint64 // Bytes.
+		expireAt time.Time
+		elem     *list.Element // LRU
+		index    int           // LFU
+		freq     uint64        // LFU
+		seq      uint64        // LFU
+	}
+
+	call[V any] struct {
+		wg  sync.WaitGroup
+		val V
+		err error
+	}
+
+	evicted[K comparable, V any] struct {
+		key    K
+		val    V
+		reason EvictReason
+	}
+
+	// Cache is a concurrency-safe generic cache with TTL, LRU/LFU eviction by count or cost, and loader.
+	Cache[K comparable, V any] struct {
+		opt     Options[K, V]
+		maxCost uint64
+		mu      sync.Mutex
+		items   map[K]*entry[K, V]
+		policy  evictor[K, V]
+		cost    uint64
+		stats   Stats
+		calls   map[K]*call[V]
+		callsMu sync.Mutex
+		done    chan struct{}
+		once    sync.Once
+	}
+)
+
+const (
+	LRU = EvictionPolicy("lru")
+	LFU = EvictionPolicy("lfu")
+
+	ReasonExpired  = EvictReason("expired")
+	ReasonEvicted  = EvictReason("evicted")
+	ReasonDeleted  = EvictReason("deleted")
+	ReasonReplaced = EvictReason("replaced")
+)
+
+var ErrNoLoader = gerrors.New("cache loader not set")
+
+// New creates cache, Close must be called if CleanupInterval is set.
+func New[K comparable, V any](opt Options[K, V]) (*Cache[K, V], error) {
+	switch opt.Policy {
+	case "":
+		opt.Policy = LRU
+	case LRU, LFU:
+	default:
+		return nil, gerrors.New("unknown eviction policy %s", opt.Policy)
+	}
+	if opt.MaxItems < 0 || opt.MaxCost < 0 || opt.DefaultTTL < 0 {
+		return nil, gerrors.New("negative cache options")
+	}
+	if opt.MaxCost > 0 && opt.Cost == nil {
+		return nil, gerrors.New("Cost function required by MaxCost")
+	}
+	c := &Cache[K, V]{
+		opt:     opt,
+		maxCost: opt.MaxCost.Bytes(),
+		items:   map[K]*entry[K, V]{},
+		policy:  newEvictor[K, V](opt.Policy),
+		calls:   map[K]*call[V]{},
+		done:    make(chan struct{}),
+	}
+	if opt.CleanupInterval > 0 {
+		go c.janitor()
+	}
+	return c, nil
+}
+
+func (c *Cache[K, V]) janitor() {
+	ticker := time.NewTicker(c.opt.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close stops background cleanup.
+func (c *Cache[K, V]) Close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+func (c *Cache[K, V]) notify(list []evicted[K, V]) {
+	if c.opt.OnEvict == nil {
+		return
+	}
+	for _, v := range list {
+		c.opt.OnEvict(v.key, v.val, v.reason)
+	}
+}
+
+// removeLocked removes entry and counts statistics.
+func (c *Cache[K, V]) removeLocked(e *entry[K, V], reason EvictReason, out []evicted[K, V]) []evicted[K, V] {
+	delete(c.items, e.key)
+	c.policy.remove(e)
+	c.cost -= e.cost
+	switch reason {
+	case ReasonExpired:
+		c.stats.Expirations++
+	case ReasonEvicted:
+		c.stats.Evictions++
+	}
+	return append(out, evicted[K, V]{key: e.key, val: e.val, reason: reason})
+}
+
+func (c *Cache[K, V]) overLimit() bool {
+	return (c.opt.MaxItems > 0 && len(c.items) > c.opt.MaxItems) || (c.maxCost > 0 && c.cost > c.maxCost)
+}
+
+// Set adds or replaces item with default TTL.
+func (c *Cache[K, V]) Set(key K, val V) {
+	c.SetWithTTL(key, val, 0)
+}
+
+// SetWithTTL adds or replaces item, ttl 0 means default TTL, negative ttl means never expire.
+// Item costs more than MaxCost is not cached and evicts nothing but the old item of the same key,
+// OnEvict receives it with ReasonEvicted.
+func (c *Cache[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = c.opt.DefaultTTL
+	}
+	e := &entry[K, V]{key: key, val: val}
+	if ttl > 0 {
+		e.expireAt = time.Now().Add(ttl)
+	}
+	if c.opt.Cost != nil {
+		e.cost = c.opt.Cost(key, val).Bytes()
+	}
+
+	var out []evicted[K, V]
+	c.mu.Lock()
+	if old, ok := c.items[key]; ok {
+		out = c.removeLocked(old, ReasonReplaced, out)
+	}
+	if c.maxCost > 0 && e.cost > c.maxCost {
+		c.stats.Evictions++
+		out = append(out, evicted[K, V]{key: key, val: val, reason: ReasonEvicted})
+		c.mu.Unlock()
+		c.notify(out)
+		return
+	}
+	c.items[key] = e
+	c.cost += e.cost
+	// New item joins eviction order after others are evicted, otherwise LFU always evicts the new one.
+	for victim := c.policy.victim(); victim != nil && c.overLimit(); victim = c.policy.victim() {
+		out = c.removeLocked(victim, ReasonEvicted, out)
+	}
+	c.policy.add(e)
+	c.mu.Unlock()
+	c.notify(out)
+}
+
+// Get returns unexpired item.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var out []evicted[K, V]
+	c.mu.Lock()
+	e, ok := c.items[key]
+	if ok && !e.expireAt.IsZero() && time.Now().After(e.expireAt) {
+		out = c.removeLocked(e, ReasonExpired, out)
+		ok = false
+	}
+	var val V
+	if ok {
+		c.stats.Hits++
+		c.policy.touch(e)
+		val = e.val
+	} else {
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+	c.notify(out)
+	return val, ok
+}
+
+// GetOrLoad returns cached item, or loads it with Loader on miss.
+// Concurrent misses of the same key wait for one loader call, load errors are not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K) (V, error) {
+	if val, ok := c.Get(key); ok {
+		return val, nil
+	}
+	if c.opt.Loader == nil {
+		var zero V
+		return zero, ErrNoLoader
+	}
+
+	c.callsMu.Lock()
+	if cl, ok := c.calls[key]; ok {
+		c.callsMu.Unlock()
+		cl.wg.Wait()
+		return cl.val, cl.err
+	}
+	cl := &call[V]{}
+	cl.wg.Add(1)
+	c.calls[key] = cl
+	c.callsMu.Unlock()
+
+	defer func() {
+		c.callsMu.Lock()
+		delete(c.calls, key)
+		c.callsMu.Unlock()
+		cl.wg.Done()
+	}()
+
+	cl.val, cl.err = c.opt.Loader(ctx, key)
+	c.mu.Lock()
+	c.stats.Loads++
+	if cl.err != nil {
+		c.stats.LoadErrors++
+	}
+	c.mu.Unlock()
+	if cl.err == nil {
+		c.Set(key, cl.val)
+	}
+	return cl.val, cl.err
+}
+
+// Delete removes item, it returns false if item not exists.
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	e, ok := c.items[key]
+	var out []evicted[K, V]
+	if ok {
+		out = c.removeLocked(e, ReasonDeleted, out)
+	}
+	c.mu.Unlock()
+	c.notify(out)
+	return ok
+}
+
+// DeleteExpired removes all expired items.
+func (c *Cache[K, V]) DeleteExpired() {
+	now := time.Now()
+	var out []evicted[K, V]
+	c.mu.Lock()
+	for _, e := range c.items {
+		if !e.expireAt.IsZero() && now.After(e.expireAt) {
+			out = c.removeLocked(e, ReasonExpired, out)
+		}
+	}
+	c.mu.Unlock()
+	c.notify(out)
+}
+
+// Purge removes all items, OnEvict is called with ReasonDeleted.
+func (c *Cache[K, V]) Purge() {
+	var out []evicted[K, V]
+	c.mu.Lock()
+	for _, e := range c.items {
+		out = c.removeLocked(e, ReasonDeleted, out)
+	}
+	c.mu.Unlock()
+	c.notify(out)
+}
+
+// Len returns number of items, expired but not yet removed items are included.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := c.stats
+	res.Items = len(c.items)
+	res.Cost = gvolume.FromByteSizeUint64(c.cost)
+	return res
+}
+
+// HitRate returns hits / (hits + misses).
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
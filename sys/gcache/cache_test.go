@@ -0,0 +1,149 @@
+package gcache
+
+import (
+	"context"
+	"errors"
+	"github.com/cryptowilliam/goutil/container/gvolume"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_LRU(t *testing.T) {
+	var evicted []string
+	c, err := New(Options[string, int]{
+		MaxItems: 2,
+		OnEvict: func(key string, val int, reason EvictReason) {
+			evicted = append(evicted, key+":"+string(reason))
+		},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should be evicted as least recently used")
+		return
+	}
+	c.Set("a", 10)
+	c.Delete("c")
+	expect := []string{"b:evicted", "a:replaced", "c:deleted"}
+	if len(evicted) != len(expect) {
+		t.Errorf("unexpected evictions %v", evicted)
+		return
+	}
+	for i := range expect {
+		if evicted[i] != expect[i] {
+			t.Errorf("unexpected evictions %v", evicted)
+			return
+		}
+	}
+	st := c.Stats()
+	if st.Hits != 1 || st.Misses != 1 || st.Evictions != 1 || st.Items != 1 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
+
+func TestCache_LFUAndCost(t *testing.T) {
+	c, _ := New(Options[string, []byte]{
+		Policy:  LFU,
+		MaxCost: gvolume.FromByteSizeUint64(10),
+		Cost:    func(key string, val []byte) gvolume.Volume { return gvolume.FromByteSizeUint64(uint64(len(val))) },
+	})
+	c.Set("hot", make([]byte, 4))
+	c.Set("cold", make([]byte, 4))
+	for i := 0; i < 3; i++ {
+		c.Get("hot")
+	}
+	c.Get("cold")
+	c.Set("new", make([]byte, 4))
+	if _, ok := c.Get("cold"); ok {
+		t.Error("cold should be evicted as least frequently used")
+		return
+	}
+	if _, ok := c.Get("hot"); !ok {
+		t.Error("hot should be kept")
+		return
+	}
+	if st := c.Stats(); st.Cost.Bytes() != 8 {
+		t.Errorf("expect cost 8 bytes, got %d", st.Cost.Bytes())
+		return
+	}
+	c.Set("huge", make([]byte, 11))
+	if _, ok := c.Get("huge"); ok || c.Len() != 2 || c.Stats().Cost.Bytes() != 8 {
+		t.Error("item larger than MaxCost should not be cached, nor evict others")
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	expired := int32(0)
+	c, _ := New(Options[int, int]{
+		DefaultTTL:      20 * time.Millisecond,
+		CleanupInterval: 10 * time.Millisecond,
+		OnEvict: func(key int, val int, reason EvictReason) {
+			if reason == ReasonExpired {
+				atomic.AddInt32(&expired, 1)
+			}
+		},
+	})
+	defer c.Close()
+	c.Set(1, 1)
+	c.SetWithTTL(2, 2, -1)
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get(1); ok {
+		t.Error("item 1 should be expired")
+		return
+	}
+	if _, ok := c.Get(2); !ok {
+		t.Error("item 2 should never expire")
+		return
+	}
+	if atomic.LoadInt32(&expired) != 1 || c.Stats().Expirations != 1 {
+		t.Error("expiration callback not called by janitor")
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	loads := int32(0)
+	release := make(chan struct{})
+	c, _ := New(Options[string, string]{
+		Loader: func(ctx context.Context, key string) (string, error) {
+			atomic.AddInt32(&loads, 1)
+			<-release
+			if key == "bad" {
+				return "", errors.New("load failed")
+			}
+			return "v-" + key, nil
+		},
+	})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad(context.Background(), "k"); err != nil || v != "v-k" {
+				t.Errorf("unexpected load result %s %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if atomic.LoadInt32(&loads) != 1 {
+		t.Errorf("expect 1 load, got %d", loads)
+		return
+	}
+	if _, err := c.GetOrLoad(context.Background(), "bad"); err == nil {
+		t.Error("load error expected")
+		return
+	}
+	if st := c.Stats(); st.Loads != 2 || st.LoadErrors != 1 || st.Items != 1 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
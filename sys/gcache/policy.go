@@ -0,0 +1,107 @@
+package gcache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+type (
+	// evictor orders entries, victim is the next one to evict.
+	evictor[K comparable, V any] interface {
+		add(e *entry[K, V])
+		touch(e *entry[K, V])
+		remove(e *entry[K, V])
+		victim() *entry[K, V]
+	}
+
+	lruEvictor[K comparable, V any] struct {
+		l *list.List
+	}
+
+	// lfuEvictor is a min-heap of access frequency, least recently used one goes first if frequencies are equal.
+	lfuEvictor[K comparable, V any] struct {
+		h   lfuHeap[K, V]
+		seq uint64
+	}
+
+	lfuHeap[K comparable, V any] []*entry[K, V]
+)
+
+func newEvictor[K comparable, V any](policy EvictionPolicy) evictor[K, V] {
+	if policy == LFU {
+		return &lfuEvictor[K, V]{}
+	}
+	return &lruEvictor[K, V]{l: list.New()}
+}
+
+func (p *lruEvictor[K, V]) add(e *entry[K, V]) {
+	e.elem = p.l.PushFront(e)
+}
+
+func (p *lruEvictor[K, V]) touch(e *entry[K, V]) {
+	p.l.MoveToFront(e.elem)
+}
+
+func (p *lruEvictor[K, V]) remove(e *entry[K, V]) {
+	p.l.Remove(e.elem)
+}
+
+func (p *lruEvictor[K, V]) victim() *entry[K, V] {
+	if back := p.l.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	return nil
+}
+
+func (p *lfuEvictor[K, V]) add(e *entry[K, V]) {
+	p.seq++
+	e.freq, e.seq = 1, p.seq
+	heap.Push(&p.h, e)
+}
+
+func (p *lfuEvictor[K, V]) touch(e *entry[K, V]) {
+	p.seq++
+	e.freq++
+	e.seq = p.seq
+	heap.Fix(&p.h, e.index)
+}
+
+func (p *lfuEvictor[K, V]) remove(e *entry[K, V]) {
+	heap.Remove(&p.h, e.index)
+}
+
+func (p *lfuEvictor[K, V]) victim() *entry[K, V] {
+	if len(p.h) == 0 {
+		return nil
+	}
+	return p.h[0]
+}
+
+func (h lfuHeap[K, V]) Len() int { return len(h) }
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K, V]) Push(x interface{}) {
+	e := x.(*entry[K, V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K, V]) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
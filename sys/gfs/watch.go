@@ -1,7 +1,7 @@
 package gfs
 
 import (
-	"fmt"
+	"github.com/cryptowilliam/goutil/basic/gerrors"
 	"github.com/radovskyb/watcher"
 	"time"
 )
@@ -40,22 +40,38 @@ func NewWatcher() *FsWatcher {
 
 // this function is block
 func (w *FsWatcher) Loop(path string, evts WatchEvent, interval time.Duration, notifyCh chan ChangedEvent) (err error) {
+	return w.loop(path, []WatchEvent{evts}, interval, notifyCh, nil)
+}
+
+// loop closes ready if not nil when all checks passed and watching is about to start.
+func (w *FsWatcher) loop(path string, evts []WatchEvent, interval time.Duration, notifyCh chan ChangedEvent, ready chan struct{}) (err error) {
 	// SetMaxEvents to 1 to allow at most 1 event's to be received
 	// on the Event channel per watching cycle.
 	//
 	// If SetMaxEvents is not set, the default is to send all events.
 	//w.SetMaxEvents(1)
 
-	// Only notify write events.
-	w.wr.FilterOps(watcher.Op(evts))
+	// Only notify specified events.
+	var ops []watcher.Op
+	for _, v := range evts {
+		ops = append(ops, watcher.Op(v))
+	}
+	w.wr.FilterOps(ops...)
+
+	// Watch this folder for changes.
+	if err := w.wr.Add(path); err != nil {
+		return err
+	}
+	// Checked here, so forwarding goroutine is not started if watcher.Start fails.
+	if interval < time.Nanosecond {
+		return watcher.ErrDurationTooShort
+	}
 
 	go func() {
 		for {
 			select {
 			case event := <-w.wr.Event:
-				fmt.Println(event) // Print the event's info.
 				notifyCh <- ChangedEvent{Path: event.Path, Event: WatchEvent(event.Op)}
-				fmt.Println("ok")
 			case err := <-w.wr.Error:
 				notifyCh <- ChangedEvent{Err: err}
 				return
@@ -65,9 +81,8 @@ func (w *FsWatcher) Loop(path string, evts WatchEvent, interval time.Duration, n
 		}
 	}()
 
-	// Watch this folder for changes.
-	if err := w.wr.Add(path); err != nil {
-		return err
+	if ready != nil {
+		close(ready)
 	}
 
 	// Start the watching process - it'll check for changes every 100ms.
@@ -76,3 +91,30 @@ func (w *FsWatcher) Loop(path string, evts WatchEvent, interval time.Duration, n
 	}
 	return nil
 }
+
+// Close stops watching, blocked Loop returns after that.
+func (w *FsWatcher) Close() {
+	w.wr.Close()
+}
+
+// Start is the non-blocking version of Loop, it returns after watching started.
+// WatchEvent values are not bit flags, so multiple events are passed one by one.
+func (w *FsWatcher) Start(path string, interval time.Duration, notifyCh chan ChangedEvent, evts ...WatchEvent) error {
+	errCh := make(chan error, 1)
+	ready := make(chan struct{})
+	go func() {
+		errCh <- w.loop(path, evts, interval, notifyCh, ready)
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			err = gerrors.New("watcher of %s stopped unexpectedly", path)
+		}
+		return err
+	case <-ready:
+		// watcher.Start can't fail now, wait until it starts polling.
+		w.wr.Wait()
+		return nil
+	}
+}
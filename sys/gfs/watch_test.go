@@ -0,0 +1,45 @@
+package gfs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFsWatcher_Start(t *testing.T) {
+	dir := t.TempDir()
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		if err := NewWatcher().Start(filepath.Join(dir, "not-exist"), 10*time.Millisecond, make(chan ChangedEvent)); err == nil {
+			t.Error("watching nonexistent path should fail")
+			return
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := runtime.NumGoroutine(); n > before+2 {
+		t.Errorf("goroutines leak after failed Start, %d before, %d after", before, n)
+		return
+	}
+
+	w := NewWatcher()
+	notifyCh := make(chan ChangedEvent, 1)
+	if err := w.Start(dir, 10*time.Millisecond, notifyCh, Create); err != nil {
+		t.Error(err)
+		return
+	}
+	defer w.Close()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+	select {
+	case evt := <-notifyCh:
+		if evt.Event != Create || filepath.Base(evt.Path) != "a.txt" {
+			t.Errorf("unexpected event %+v", evt)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("event not received")
+	}
+}
@@ -0,0 +1,35 @@
+package gsignal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+type SignalHandler func(sig os.Signal)
+
+// Relay calls handler for every caught signal until returned stop function is called.
+// Unlike RegisterExitCallback, it never exits current process. Empty sigs means all incoming signals.
+func Relay(handler SignalHandler, sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 8)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				handler(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}